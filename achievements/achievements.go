@@ -0,0 +1,87 @@
+package achievements
+
+import (
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"time"
+)
+
+// Event represents a goal that has just been stored, with score between both users before and after it.
+//
+type Event struct {
+	Goal        models.Goal
+	ScoreBefore models.Score
+	ScoreAfter  models.Score
+}
+
+// Rule represents an achievement that users can unlock.
+//
+// IsUnlocked checks if submitted user unlocks the achievement with submitted event (as scorer or as opponent).
+// Rules are only checked for users who have not already unlocked them.
+//
+type Rule struct {
+	Code        string
+	Name        string
+	Description string
+	IsUnlocked  func(tx *pop.Connection, userID string, event Event) (unlocked bool, ruleError error)
+}
+
+// FindRule returns rule corresponding to submitted code.
+//
+func FindRule(code string) (rule Rule, existingRule bool) {
+	for _, rule = range Rules {
+		if rule.Code == code {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Unlock checks all rules for both users involved in submitted event and stores newly unlocked achievements.
+//
+func Unlock(tx *pop.Connection, event Event) (unlockedAchievements []models.Achievement, unlockError error) {
+	for _, userID := range []string{event.Goal.ScorerId, event.Goal.OpponentId} {
+		var userAchievements []models.Achievement
+		var alreadyUnlocked = make(map[string]bool)
+
+		unlockError = tx.Where("user_id = ?", userID).All(&userAchievements)
+		if unlockError != nil {
+			return unlockedAchievements, unlockError
+		}
+		for _, userAchievement := range userAchievements {
+			alreadyUnlocked[userAchievement.Code] = true
+		}
+
+		for _, rule := range Rules {
+			if alreadyUnlocked[rule.Code] {
+				continue
+			}
+
+			unlocked, ruleError := rule.IsUnlocked(tx, userID, event)
+			if ruleError != nil {
+				return unlockedAchievements, ruleError
+			}
+			if !unlocked {
+				continue
+			}
+
+			unlockedAchievement := models.Achievement{UserId: userID, Code: rule.Code, UnlockedAt: event.Goal.CreatedAt}
+			if unlockedAchievement.UnlockedAt.IsZero() {
+				unlockedAchievement.UnlockedAt = time.Now()
+			}
+
+			var validateError *validate.Errors
+			validateError, unlockError = tx.ValidateAndCreate(&unlockedAchievement)
+			if unlockError != nil {
+				return unlockedAchievements, unlockError
+			}
+			if validateError != nil && len(validateError.Errors) != 0 {
+				return unlockedAchievements, validateError
+			}
+			unlockedAchievements = append(unlockedAchievements, unlockedAchievement)
+		}
+	}
+
+	return unlockedAchievements, nil
+}
@@ -0,0 +1,145 @@
+package achievements
+
+import (
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/office"
+	"time"
+)
+
+// Rules lists all achievements that users can unlock.
+//
+// To define a new achievement, just add a new rule to this list: it will be checked after each stored goal.
+// Never change the code of an existing rule, as it is stored with unlocked achievements.
+//
+var Rules = []Rule{
+	{
+		Code:        "first_goal",
+		Name:        "First blood",
+		Description: "Score a first goal",
+		IsUnlocked:  scoredGoalOfKind(models.GoalKindClassic, models.GoalKindDemi),
+	},
+	{
+		Code:        "first_gamelle",
+		Name:        "Gamelle!",
+		Description: "Score a first gamelle",
+		IsUnlocked:  scoredGoalOfKind(models.GoalKindGamelle),
+	},
+	{
+		Code:        "first_pissette",
+		Name:        "Useless but beautiful",
+		Description: "Score a first goal with the pissette player",
+		IsUnlocked:  scoredGoalOfKind(models.GoalKindPissette),
+	},
+	{
+		Code:        "first_demi",
+		Name:        "Midfield sniper",
+		Description: "Score a first goal from the midfield",
+		IsUnlocked:  scoredGoalOfKind(models.GoalKindDemi),
+	},
+	{
+		Code:        "first_set",
+		Name:        "Set in stone",
+		Description: "Win a first set",
+		IsUnlocked:  wonSet,
+	},
+	{
+		Code:        "clean_sheet",
+		Name:        "Clean sheet",
+		Description: "Win a set 10-0",
+		IsUnlocked:  wonSetWithoutConcedingPoint,
+	},
+	{
+		Code:        "big_balance",
+		Name:        "Jackpot",
+		Description: "Cash a balance of 6 points or more",
+		IsUnlocked:  cashedBalance(6),
+	},
+	{
+		Code:        "marathon",
+		Name:        "Marathon",
+		Description: "Play 10 sets in a day",
+		IsUnlocked:  playedSetsInDay(10),
+	},
+}
+
+// scoredGoalOfKind checks if user scored a goal of one of submitted kinds.
+//
+func scoredGoalOfKind(kinds ...string) func(tx *pop.Connection, userID string, event Event) (unlocked bool, ruleError error) {
+	return func(tx *pop.Connection, userID string, event Event) (unlocked bool, ruleError error) {
+		if event.Goal.ScorerId != userID {
+			return false, nil
+		}
+		for _, kind := range kinds {
+			if event.Goal.Kind == kind {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// wonSet checks if user won a set with this goal.
+//
+func wonSet(tx *pop.Connection, userID string, event Event) (unlocked bool, ruleError error) {
+	return event.Goal.SetFinished && event.Goal.ScorerId == userID, nil
+}
+
+// wonSetWithoutConcedingPoint checks if user won a set with this goal while opponent had no point.
+//
+// Points of sets imported from history are unknown, so that they never unlock it.
+//
+func wonSetWithoutConcedingPoint(tx *pop.Connection, userID string, event Event) (unlocked bool, ruleError error) {
+	if !event.Goal.SetFinished || event.Goal.ScorerId != userID || event.Goal.Kind == models.GoalKindSetResult {
+		return false, nil
+	}
+
+	opponentPoints := event.ScoreBefore.User1Points
+	if event.ScoreBefore.User1Id == userID {
+		opponentPoints = event.ScoreBefore.User2Points
+	}
+	return opponentPoints <= 0, nil
+}
+
+// cashedBalance checks if user cashed at least submitted number of points in balance with this goal.
+//
+func cashedBalance(minimumPoints int) func(tx *pop.Connection, userID string, event Event) (unlocked bool, ruleError error) {
+	return func(tx *pop.Connection, userID string, event Event) (unlocked bool, ruleError error) {
+		return event.Goal.ScorerId == userID && event.Goal.BalanceCashed >= minimumPoints, nil
+	}
+}
+
+// playedSetsInDay checks if user played at least submitted number of sets during day of goal (in office timezone).
+//
+func playedSetsInDay(minimumSets int) func(tx *pop.Connection, userID string, event Event) (unlocked bool, ruleError error) {
+	return func(tx *pop.Connection, userID string, event Event) (unlocked bool, ruleError error) {
+		if !event.Goal.SetFinished {
+			return false, nil
+		}
+
+		location, ruleError := office.Location()
+		if ruleError != nil {
+			return false, ruleError
+		}
+		startOfDay, endOfDay := dayOf(event.Goal.CreatedAt, location)
+
+		setsInDay, ruleError := tx.Where("set_finished AND created_at >= ? AND created_at < ? AND (scorer_id = ? OR opponent_id = ?)", startOfDay.UTC(), endOfDay.UTC(), userID, userID).Count(models.Goal{})
+		if ruleError != nil {
+			return false, ruleError
+		}
+		return setsInDay >= minimumSets, nil
+	}
+}
+
+// dayOf returns beginning and end (excluded) of day of submitted time in submitted location.
+//
+// Goals not stored yet have no time: current day is then returned. Goals imported from history keep their own day.
+//
+func dayOf(goalTime time.Time, location *time.Location) (startOfDay time.Time, endOfDay time.Time) {
+	if goalTime.IsZero() {
+		goalTime = time.Now()
+	}
+	goalTime = goalTime.In(location)
+	startOfDay = time.Date(goalTime.Year(), goalTime.Month(), goalTime.Day(), 0, 0, 0, 0, location)
+	return startOfDay, startOfDay.AddDate(0, 0, 1)
+}
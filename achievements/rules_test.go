@@ -0,0 +1,87 @@
+package achievements
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+	"time"
+)
+
+// TestRulesCodes tests that all rules have a unique code and a description.
+//
+func TestRulesCodes(t *testing.T) {
+	var codes = make(map[string]bool)
+
+	assertHandler := assert.New(t)
+
+	for _, rule := range Rules {
+		assertHandler.NotEmpty(rule.Code, "Rule without code: all rules should have a code")
+		assertHandler.NotEmpty(rule.Name, "Rule without name: all rules should have a name")
+		assertHandler.NotEmpty(rule.Description, "Rule without description: all rules should have a description")
+		assertHandler.NotNil(rule.IsUnlocked, "Rule without check: all rules should have an unlocking check")
+		assertHandler.False(codes[rule.Code], "Duplicated rule code: all rules codes should be unique")
+		codes[rule.Code] = true
+	}
+
+	_, existingRule := FindRule("first_gamelle")
+	assertHandler.True(existingRule, "Existing rule: FindRule function should find rule")
+	_, existingRule = FindRule("zizou")
+	assertHandler.False(existingRule, "Not existing rule: FindRule function should not find rule")
+}
+
+// TestRulesUnlocking tests unlocking checks of rules not depending on database.
+//
+func TestRulesUnlocking(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	gamelleEvent := Event{Goal: models.Goal{ScorerId: "user1", OpponentId: "user2", Kind: models.GoalKindGamelle}}
+	unlocked, _ := scoredGoalOfKind(models.GoalKindGamelle)(nil, "user1", gamelleEvent)
+	assertHandler.True(unlocked, "Gamelle scored: scorer should unlock gamelle achievement")
+	unlocked, _ = scoredGoalOfKind(models.GoalKindGamelle)(nil, "user2", gamelleEvent)
+	assertHandler.False(unlocked, "Gamelle suffered: opponent should not unlock gamelle achievement")
+
+	cleanSheetEvent := Event{
+		Goal:        models.Goal{ScorerId: "user2", OpponentId: "user1", Kind: models.GoalKindClassic, SetFinished: true},
+		ScoreBefore: models.Score{User1Id: "user1", User2Id: "user2", User1Points: 0, User2Points: 9},
+	}
+	unlocked, _ = wonSetWithoutConcedingPoint(nil, "user2", cleanSheetEvent)
+	assertHandler.True(unlocked, "Set won 10-0: winner should unlock clean sheet achievement")
+	unlocked, _ = wonSetWithoutConcedingPoint(nil, "user1", cleanSheetEvent)
+	assertHandler.False(unlocked, "Set lost 0-10: loser should not unlock clean sheet achievement")
+
+	importedSetEvent := Event{
+		Goal:        models.Goal{ScorerId: "user2", OpponentId: "user1", Kind: models.GoalKindSetResult, SetFinished: true},
+		ScoreBefore: models.Score{User1Id: "user1", User2Id: "user2"},
+	}
+	unlocked, _ = wonSet(nil, "user2", importedSetEvent)
+	assertHandler.True(unlocked, "Imported set won: winner should unlock first set achievement")
+	unlocked, _ = wonSetWithoutConcedingPoint(nil, "user2", importedSetEvent)
+	assertHandler.False(unlocked, "Imported set won with unknown points: winner should not unlock clean sheet achievement")
+
+	tightSetEvent := Event{
+		Goal:        models.Goal{ScorerId: "user2", OpponentId: "user1", Kind: models.GoalKindClassic, SetFinished: true},
+		ScoreBefore: models.Score{User1Id: "user1", User2Id: "user2", User1Points: 9, User2Points: 9},
+	}
+	unlocked, _ = wonSetWithoutConcedingPoint(nil, "user2", tightSetEvent)
+	assertHandler.False(unlocked, "Set won 10-9: winner should not unlock clean sheet achievement")
+
+	balanceEvent := Event{Goal: models.Goal{ScorerId: "user1", OpponentId: "user2", Kind: models.GoalKindClassic, BalanceCashed: 6}}
+	unlocked, _ = cashedBalance(6)(nil, "user1", balanceEvent)
+	assertHandler.True(unlocked, "6 points balance cashed: scorer should unlock balance achievement")
+	unlocked, _ = cashedBalance(8)(nil, "user1", balanceEvent)
+	assertHandler.False(unlocked, "6 points balance cashed: scorer should not unlock 8 points balance achievement")
+}
+
+// TestDayOf tests day of goals being computed from their own time, in office timezone.
+//
+func TestDayOf(t *testing.T) {
+	assertHandler := assert.New(t)
+	location := time.FixedZone("Office", 2*60*60)
+
+	startOfDay, endOfDay := dayOf(time.Date(2019, 3, 14, 23, 30, 0, 0, time.UTC), location)
+	assertHandler.Equal(time.Date(2019, 3, 15, 0, 0, 0, 0, location), startOfDay, "Day should begin at midnight in office timezone")
+	assertHandler.Equal(time.Date(2019, 3, 16, 0, 0, 0, 0, location), endOfDay, "Day should end at next midnight in office timezone")
+
+	startOfDay, endOfDay = dayOf(time.Time{}, location)
+	assertHandler.True(!time.Now().Before(startOfDay) && time.Now().Before(endOfDay), "Goals not stored yet should be in current day")
+}
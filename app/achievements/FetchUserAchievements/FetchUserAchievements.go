@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/achievements"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// unlockedAchievement represents an achievement unlocked by one user.
+//
+type unlockedAchievement struct {
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	UnlockedAt  time.Time `json:"unlocked_at"`
+}
+
+// userAchievements represents all achievements unlocked by one user.
+//
+type userAchievements struct {
+	UserID       string                `json:"user_id"`
+	Unlocked     int                   `json:"unlocked"`
+	Available    int                   `json:"available"`
+	Achievements []unlockedAchievement `json:"achievements"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve user id from API request path
+//     - retrieve from DB all achievements unlocked by requested user
+//     - describe each achievement according to its rule
+//     - send HTTP JSON response containing this information
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var dbError, marshalError error
+	var requestedUserID string
+	var requestedUserAchievements []models.Achievement
+	var requestedUserBadges userAchievements
+	var requestedUserBadgesInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	requestedUserID = request.PathParameters["id"]
+	if requestedUserID == "" {
+		return errorResponse("Bad request: you must provide a user id in path", http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.Where("user_id = ?", requestedUserID).Order("unlocked_at").All(&requestedUserAchievements)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve user's achievements for user_id '%s'", requestedUserID), http.StatusInternalServerError)
+	}
+
+	requestedUserBadges = userAchievements{
+		UserID:       requestedUserID,
+		Unlocked:     len(requestedUserAchievements),
+		Available:    len(achievements.Rules),
+		Achievements: []unlockedAchievement{},
+	}
+	for _, requestedUserAchievement := range requestedUserAchievements {
+		badge := unlockedAchievement{Code: requestedUserAchievement.Code, UnlockedAt: requestedUserAchievement.UnlockedAt}
+		if rule, existingRule := achievements.FindRule(requestedUserAchievement.Code); existingRule {
+			badge.Name = rule.Name
+			badge.Description = rule.Description
+		}
+		requestedUserBadges.Achievements = append(requestedUserBadges.Achievements, badge)
+	}
+
+	requestedUserBadgesInJSON, marshalError = json.Marshal(requestedUserBadges)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify user achievements: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(requestedUserBadgesInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
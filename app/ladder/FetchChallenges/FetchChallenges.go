@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/ladder"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ladderChallenges represents ranks of the ladder with challenges between its users.
+//
+type ladderChallenges struct {
+	Ladder     []models.LadderRank `json:"ladder"`
+	Challenges []models.Challenge  `json:"challenges"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve optional user from API request
+//     - forfeit challenges whose deadline has passed
+//     - retrieve ranks of the ladder and challenges (of requested user, if any)
+//     - send HTTP JSON response containing ladder and challenges
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var dbError, marshalError error
+	var result ladderChallenges
+	var resultInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		return ladder.ExpireChallenges(tx, time.Now().UTC())
+	})
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to forfeit expired challenges: %s", dbError), http.StatusInternalServerError)
+	}
+
+	result.Ladder, dbError = ladder.FetchLadder(databaseConnection)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve ladder: %s", dbError), http.StatusInternalServerError)
+	}
+	result.Challenges, dbError = ladder.FetchChallenges(databaseConnection, request.QueryStringParameters["user"])
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve challenges: %s", dbError), http.StatusInternalServerError)
+	}
+
+	resultInJSON, marshalError = json.Marshal(result)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify challenges: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(resultInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
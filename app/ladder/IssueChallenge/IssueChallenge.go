@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/ladder"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// challengeUsers represents users of challenge to issue, as submitted to API.
+//
+type challengeUsers struct {
+	ChallengerId string `json:"challenger_id"`
+	DefenderId   string `json:"defender_id"`
+}
+
+// challengeErrors lists errors raised because of a challenge which cannot be issued.
+var challengeErrors = []error{ladder.ErrSameUser, ladder.ErrNotRanked, ladder.ErrOutOfRange, ladder.ErrChallengeInProgress}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve challenger and defender from JSON body
+//     - forfeit challenges whose deadline has passed
+//     - issue challenge, checking defender is ranked within challenge range above challenger
+//       and that none of them is already part of a challenge in progress
+//     - send HTTP JSON response containing issued challenge
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var submittedUsers = challengeUsers{}
+	var issuedChallenge models.Challenge
+	var issuedChallengeInJSON []byte
+
+	requestError = json.Unmarshal([]byte(request.Body), &submittedUsers)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	now := time.Now().UTC()
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		return ladder.ExpireChallenges(tx, now)
+	})
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to forfeit expired challenges: %s", dbError), http.StatusInternalServerError)
+	}
+
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		issuedChallenge, transactionError = ladder.Issue(tx, submittedUsers.ChallengerId, submittedUsers.DefenderId, now)
+		return transactionError
+	})
+	for _, challengeError := range challengeErrors {
+		if dbError == challengeError {
+			return errorResponse(fmt.Sprintf("Bad request: %s", dbError), http.StatusBadRequest)
+		}
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to issue challenge: %s", dbError), http.StatusInternalServerError)
+	}
+
+	issuedChallengeInJSON, marshalError = json.Marshal(issuedChallenge)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify challenge: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(issuedChallengeInJSON),
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/ladder"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+)
+
+// ladderUser represents user joining the ladder, as submitted to API.
+//
+type ladderUser struct {
+	UserId string `json:"user_id"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve user information from JSON body
+//     - add user at the bottom of the ladder, checking user is not already in the ladder
+//     - send HTTP JSON response containing rank of user
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var submittedUser = ladderUser{}
+	var joinedRank models.LadderRank
+	var joinedRankInJSON []byte
+
+	requestError = json.Unmarshal([]byte(request.Body), &submittedUser)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+	if submittedUser.UserId == "" {
+		return errorResponse("Bad request: you must provide a user id", http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		joinedRank, transactionError = ladder.Join(tx, submittedUser.UserId)
+		return transactionError
+	})
+	if dbError == ladder.ErrAlreadyRanked {
+		return errorResponse(fmt.Sprintf("Bad request: %s", dbError), http.StatusBadRequest)
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to join ladder: %s", dbError), http.StatusInternalServerError)
+	}
+
+	joinedRankInJSON, marshalError = json.Marshal(joinedRank)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify rank: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(joinedRankInJSON),
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
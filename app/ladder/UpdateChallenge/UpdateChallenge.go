@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/ladder"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// challengeAnswers lists answers available to a challenge, by name in API request path.
+var challengeAnswers = map[string]func(tx *pop.Connection, challenge *models.Challenge, at time.Time) error{
+	"accept":  ladder.Accept,
+	"decline": ladder.Decline,
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve challenge id and answer (accept or decline) from API request path
+//     - forfeit challenges whose deadline has passed
+//     - answer challenge, checking it is still pending (creating its match when it is accepted)
+//     - send HTTP JSON response containing answered challenge
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var requestedChallengeID uuid.UUID
+	var requestedChallenge models.Challenge
+	var answeredChallengeInJSON []byte
+
+	requestedChallengeID, requestError = uuid.FromString(request.PathParameters["id"])
+	if requestError != nil {
+		return errorResponse("Bad request: you must provide a valid challenge id in path", http.StatusBadRequest)
+	}
+	challengeAnswer, answerExists := challengeAnswers[request.PathParameters["action"]]
+	if !answerExists {
+		return errorResponse("Bad request: action must be one of 'accept' or 'decline'", http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	now := time.Now().UTC()
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		return ladder.ExpireChallenges(tx, now)
+	})
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to forfeit expired challenges: %s", dbError), http.StatusInternalServerError)
+	}
+
+	dbError = databaseConnection.Find(&requestedChallenge, requestedChallengeID)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Challenge '%s' not found", requestedChallengeID), http.StatusNotFound)
+	}
+
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		return challengeAnswer(tx, &requestedChallenge, now)
+	})
+	if dbError == ladder.ErrChallengeNotPending {
+		return errorResponse(fmt.Sprintf("Bad request: challenge '%s' is %s", requestedChallengeID, requestedChallenge.Status), http.StatusBadRequest)
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to answer challenge: %s", dbError), http.StatusInternalServerError)
+	}
+
+	answeredChallengeInJSON, marshalError = json.Marshal(requestedChallenge)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify challenge: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(answeredChallengeInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
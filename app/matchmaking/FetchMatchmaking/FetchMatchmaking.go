@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/matchmaking"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSuggestionsCount = 5
+const maxSuggestionsCount = 50
+
+// opponentSuggestions represents fairest opponents suggested to a user.
+//
+type opponentSuggestions struct {
+	UserID      string                   `json:"user_id"`
+	Rating      int                      `json:"rating"`
+	Suggestions []matchmaking.Suggestion `json:"suggestions"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// parseTeamUsers retrieves the four distinct users to split into teams from comma-separated list.
+//
+func parseTeamUsers(requestedUsers string) (teamUsers [4]string, parseError error) {
+	var distinctUsers = make(map[string]bool)
+
+	users := strings.Split(requestedUsers, ",")
+	if len(users) != len(teamUsers) {
+		return teamUsers, fmt.Errorf("'users' parameter must list %d users", len(teamUsers))
+	}
+	for index, user := range users {
+		user = strings.TrimSpace(user)
+		if user == "" || distinctUsers[user] {
+			return teamUsers, fmt.Errorf("'users' parameter must list %d distinct users", len(teamUsers))
+		}
+		distinctUsers[user] = true
+		teamUsers[index] = user
+	}
+	return teamUsers, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve requested user (or four users to split into teams), season and number of suggestions from API request
+//     - compute ratings of all users from finished sets (of requested season only, if any)
+//     - for a user, rank all other users by fairness, according to ratings and scores history between them
+//     - for four users, split them into the two teams of two whose ratings are the closest
+//     - send HTTP JSON response containing suggested opponents (or teams)
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError, conversionError error
+	var ratings map[string]float64
+	var seasonID nulls.UUID
+	var responseInJSON []byte
+
+	requestedUserID := request.QueryStringParameters["user"]
+	requestedUsers := request.QueryStringParameters["users"]
+	if (requestedUserID == "") == (requestedUsers == "") {
+		return errorResponse("Bad request: you must provide either 'user' or 'users' parameter", http.StatusBadRequest)
+	}
+
+	suggestionsCount := defaultSuggestionsCount
+	if requestedCount := request.QueryStringParameters["limit"]; requestedCount != "" {
+		suggestionsCount, conversionError = strconv.Atoi(requestedCount)
+		if conversionError != nil || suggestionsCount < 1 || suggestionsCount > maxSuggestionsCount {
+			return errorResponse(fmt.Sprintf("Bad request: 'limit' parameter must be an integer between 1 and %d", maxSuggestionsCount), http.StatusBadRequest)
+		}
+	}
+
+	if requestedSeasonID := request.QueryStringParameters["season_id"]; requestedSeasonID != "" {
+		parsedSeasonID, parseError := uuid.FromString(requestedSeasonID)
+		if parseError != nil {
+			return errorResponse("Bad request: 'season_id' parameter must be a valid season id", http.StatusBadRequest)
+		}
+		seasonID = nulls.NewUUID(parsedSeasonID)
+	}
+
+	var teamUsers [4]string
+	if requestedUsers != "" {
+		teamUsers, requestError = parseTeamUsers(requestedUsers)
+		if requestError != nil {
+			return errorResponse(fmt.Sprintf("Bad request: %s", requestError), http.StatusBadRequest)
+		}
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	ratings, dbError = matchmaking.FetchRatings(databaseConnection, seasonID)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to compute ratings: %s", dbError), http.StatusInternalServerError)
+	}
+
+	if requestedUsers != "" {
+		responseInJSON, marshalError = json.Marshal(matchmaking.FairTeams(teamUsers, ratings))
+	} else {
+		candidates, dbError := matchmaking.FetchCandidates(databaseConnection, seasonID)
+		if dbError != nil {
+			return errorResponse(fmt.Sprintf("Failed to retrieve users: %s", dbError), http.StatusInternalServerError)
+		}
+		histories, dbError := matchmaking.FetchPairHistories(databaseConnection, requestedUserID, seasonID)
+		if dbError != nil {
+			return errorResponse(fmt.Sprintf("Failed to retrieve scores of user '%s': %s", requestedUserID, dbError), http.StatusInternalServerError)
+		}
+
+		responseInJSON, marshalError = json.Marshal(opponentSuggestions{
+			UserID:      requestedUserID,
+			Rating:      int(math.Round(matchmaking.Rating(ratings, requestedUserID))),
+			Suggestions: matchmaking.SuggestOpponents(requestedUserID, candidates, ratings, histories, time.Now(), suggestionsCount),
+		})
+	}
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify matchmaking: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(responseInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/queue"
+	"net/http"
+	"strings"
+)
+
+// queueUser represents user joining queue, as submitted to API.
+//
+type queueUser struct {
+	UserId string `json:"user_id"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve user information from JSON body
+//     - add user at the back of the queue, checking user is not already in queue
+//     - let user play right away if the table is not full
+//     - send HTTP JSON response containing updated queue
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var validateError *validate.Errors
+	var submittedUser = queueUser{}
+	var tableQueue queue.Queue
+	var queueInJSON []byte
+
+	requestError = json.Unmarshal([]byte(request.Body), &submittedUser)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		_, validateError, transactionError = queue.Join(tx, submittedUser.UserId)
+		if transactionError != nil || (validateError != nil && len(validateError.Errors) != 0) {
+			return transactionError
+		}
+		tableQueue, transactionError = queue.Fetch(tx)
+		return transactionError
+	})
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to join queue: %s", dbError), http.StatusInternalServerError)
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return errorResponse(fmt.Sprintf("Bad request: %s", validateError), http.StatusBadRequest)
+	}
+
+	queueInJSON, marshalError = json.Marshal(tableQueue)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify queue: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(queueInJSON),
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/queue"
+	"net/http"
+	"strings"
+)
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve user id from API request path
+//     - remove user from queue, first waiting user taking their place if they were playing
+//     - send HTTP JSON response containing updated queue
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var dbError, marshalError error
+	var tableQueue queue.Queue
+	var queueInJSON []byte
+
+	requestedUserID := request.PathParameters["user_id"]
+	if requestedUserID == "" {
+		return errorResponse("Bad request: you must provide a user id in path", http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		transactionError = queue.Leave(tx, requestedUserID)
+		if transactionError != nil {
+			return transactionError
+		}
+		tableQueue, transactionError = queue.Fetch(tx)
+		return transactionError
+	})
+	if dbError == queue.ErrNotInQueue {
+		return errorResponse(fmt.Sprintf("User '%s' not found in queue", requestedUserID), http.StatusNotFound)
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to leave queue: %s", dbError), http.StatusInternalServerError)
+	}
+
+	queueInJSON, marshalError = json.Marshal(tableQueue)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify queue: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(queueInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -6,17 +6,32 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
 	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/logging"
 	"github.com/vlarrat-theodo/lbc-foosball/models"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // scoreBalance represents sum of sets won and lost by one user.
 //
+// Streaks are counted over all seasons, so that they are left out of balances restricted to a season.
+//
 type scoreBalance struct {
-	Won  int `json:"won"`
-	Lost int `json:"lost"`
+	Won     int             `json:"won"`
+	Lost    int             `json:"lost"`
+	Streaks *balanceStreaks `json:"streaks,omitempty"`
+}
+
+// balanceStreaks represents current and record series of sets won and lost in a row by one user.
+//
+type balanceStreaks struct {
+	CurrentWins   int `json:"current_wins"`
+	CurrentLosses int `json:"current_losses"`
+	BestWins      int `json:"best_wins"`
+	BestLosses    int `json:"best_losses"`
 }
 
 // errorResponse formats API HTTP responses sent when an error occurs.
@@ -33,9 +48,11 @@ func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events
 // handler is the main function launched by Lambda.
 //
 // In this Lambda, it will:
-//     - retrieve user_id from API request
-//     - retrieve from DB all scores regarding requested user
+//     - retrieve user_id (and optional season_id) from API request
+//     - retrieve from DB all scores regarding requested user (in requested season if any)
 //     - calculate sum of won and lost sets by requested user
+//     - retrieve from DB streaks of requested user (unless a season is requested, streaks being all-time)
+//     - log fetched balance as a JSON line, with ID of request and database timings
 //     - send HTTP JSON response containing this information
 //
 func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
@@ -44,22 +61,43 @@ func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatew
 	var dbError, marshalError error
 	var requestedUserID string
 	var requestedUserScores []models.Score
+	var requestedUserStreak models.Streak
 	var requestedUserBalance scoreBalance
 	var requestedUserBalanceInJSON []byte
+	var logger = logging.FromRequest(request, "FetchUserBalance")
+	var timings = logging.Timings{}
 
 	databaseConnection, dbError = databaseConnector.GetConnection()
 	if dbError != nil {
+		logger.Error("Failed to connect to database", logging.Fields{"error": dbError.Error()})
 		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
 	}
 	defer databaseConnection.Close()
 
 	requestedUserID = request.QueryStringParameters["user_id"]
 	if requestedUserID == "" {
+		logger.Warn("Missing user_id parameter", nil)
 		return errorResponse("Bad request: you must provide a value for 'user_id' parameter", http.StatusBadRequest)
 	}
 
-	dbError = databaseConnection.Where("user1_id = ? or user2_id = ?", requestedUserID, requestedUserID).All(&requestedUserScores)
+	userScoresQuery := databaseConnection.Where("(user1_id = ? or user2_id = ?)", requestedUserID, requestedUserID)
+	requestedSeasonID := request.QueryStringParameters["season_id"]
+	if requestedSeasonID != "" {
+		seasonID, parseError := uuid.FromString(requestedSeasonID)
+		if parseError != nil {
+			logger.Warn("Invalid season_id parameter", logging.Fields{"season_id": requestedSeasonID})
+			return errorResponse("Bad request: 'season_id' parameter must be a valid season id", http.StatusBadRequest)
+		}
+		userScoresQuery = userScoresQuery.Where("season_id = ?", seasonID)
+	}
+
+	logger = logger.With(logging.Fields{"user_id": requestedUserID, "season_id": requestedSeasonID})
+
+	dbStart := time.Now()
+	dbError = userScoresQuery.All(&requestedUserScores)
+	timings.Since("find_scores", dbStart)
 	if dbError != nil {
+		logger.Error("Failed to retrieve user's scores", logging.Fields{"error": dbError.Error(), "db_timings_ms": timings})
 		return errorResponse(fmt.Sprintf("Failed to retrieve user's scores for user_id '%s'", requestedUserID), http.StatusInternalServerError)
 	}
 
@@ -74,6 +112,37 @@ func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatew
 		}
 	}
 
+	// Streaks are counted over all seasons: they are left out of balance of a season
+	if requestedSeasonID == "" {
+		streakQuery := databaseConnection.Where("user_id = ?", requestedUserID)
+		dbStart = time.Now()
+		streakExists, dbError := streakQuery.Exists(models.Streak{})
+		timings.Since("find_streak", dbStart)
+		if dbError != nil {
+			logger.Error("Failed to retrieve user's streaks", logging.Fields{"error": dbError.Error(), "db_timings_ms": timings})
+			return errorResponse(fmt.Sprintf("Failed to retrieve user's streaks for user_id '%s'", requestedUserID), http.StatusInternalServerError)
+		}
+
+		requestedUserBalance.Streaks = &balanceStreaks{}
+		if streakExists {
+			dbStart = time.Now()
+			dbError = streakQuery.First(&requestedUserStreak)
+			timings.Since("find_streak", dbStart)
+			if dbError != nil {
+				logger.Error("Failed to retrieve user's streaks", logging.Fields{"error": dbError.Error(), "db_timings_ms": timings})
+				return errorResponse(fmt.Sprintf("Failed to retrieve user's streaks for user_id '%s'", requestedUserID), http.StatusInternalServerError)
+			}
+			requestedUserBalance.Streaks = &balanceStreaks{
+				CurrentWins:   requestedUserStreak.CurrentWins,
+				CurrentLosses: requestedUserStreak.CurrentLosses,
+				BestWins:      requestedUserStreak.BestWins,
+				BestLosses:    requestedUserStreak.BestLosses,
+			}
+		}
+	}
+
+	logger.Info("Balance fetched", logging.Fields{"won": requestedUserBalance.Won, "lost": requestedUserBalance.Lost, "scores": len(requestedUserScores), "db_timings_ms": timings})
+
 	requestedUserBalanceInJSON, marshalError = json.Marshal(requestedUserBalance)
 	if marshalError != nil {
 		return errorResponse(fmt.Sprintf("Failed to JSONify user balance: %s", marshalError), http.StatusInternalServerError)
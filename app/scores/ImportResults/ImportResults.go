@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/scores"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve historical goals and finished sets from CSV or JSON body (format submitted in query string, JSON by default)
+//     - check results against foosball rules, then replay them in chronological order into scores
+//     - roll back import when it is a dry run or when conflicts are found
+//     - send HTTP JSON response containing import report (with conflicts found)
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var results []scores.HistoricalResult
+	var report scores.ImportReport
+	var reportInJSON []byte
+
+	format := request.QueryStringParameters["format"]
+	if format == "" {
+		format = scores.ImportFormatJSON
+	}
+	dryRun := request.QueryStringParameters["dry_run"] == "true"
+
+	body := request.Body
+	if request.IsBase64Encoded {
+		decodedBody, decodeError := base64.StdEncoding.DecodeString(body)
+		if decodeError != nil {
+			return errorResponse(fmt.Sprintf("Bad request body: %s", decodeError), http.StatusBadRequest)
+		}
+		body = string(decodedBody)
+	}
+	results, requestError = scores.ParseResults(strings.NewReader(body), format)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+	if len(results) == 0 {
+		return errorResponse("Bad request: you must provide at least one result", http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	report, dbError = scores.ImportResults(databaseConnection, results, dryRun, time.Now())
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to import results: %s", dbError), http.StatusInternalServerError)
+	}
+
+	reportInJSON, marshalError = json.Marshal(report)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify import report: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	statusCode := http.StatusOK
+	switch {
+	case report.Imported:
+		statusCode = http.StatusCreated
+	case len(report.Conflicts) != 0 && !dryRun:
+		statusCode = http.StatusConflict
+	}
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(reportInJSON),
+		StatusCode: statusCode,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/matchmaking"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/scores"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// handicap represents handicap information submitted to API.
+//
+// When Auto is set, handicap points are computed from rating gap between users (submitted points and multipliers
+// being ignored). Submitting no points and no multiplier removes handicap.
+// When MatchID is set, handicap applies to score of this match instead of usual score between users.
+//
+type handicap struct {
+	User1Id             string `json:"user1_id"`
+	User2Id             string `json:"user2_id"`
+	MatchID             string `json:"match_id"`
+	Auto                bool   `json:"auto"`
+	User1Points         int    `json:"user1_points"`
+	User2Points         int    `json:"user2_points"`
+	User1GoalMultiplier int    `json:"user1_goal_multiplier"`
+	User2GoalMultiplier int    `json:"user2_goal_multiplier"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// applyHandicap sets handicap points and goal multipliers of both users in score, whatever their order in score.
+//
+// Handicap can only change between sets, so that every set is fully played with or without handicap.
+//
+func applyHandicap(scoreToUpdate *models.Score, submittedHandicap handicap) (applyError error) {
+	if scoreToUpdate.User1Points != 0 || scoreToUpdate.User2Points != 0 || scoreToUpdate.GoalsInBalance != 0 {
+		return errors.New("handicap can only be changed between sets")
+	}
+
+	scoreToUpdate.User1HandicapPoints, scoreToUpdate.User2HandicapPoints = submittedHandicap.User1Points, submittedHandicap.User2Points
+	scoreToUpdate.User1GoalMultiplier, scoreToUpdate.User2GoalMultiplier = submittedHandicap.User1GoalMultiplier, submittedHandicap.User2GoalMultiplier
+	if scoreToUpdate.User1Id != submittedHandicap.User1Id {
+		scoreToUpdate.User1HandicapPoints, scoreToUpdate.User2HandicapPoints = submittedHandicap.User2Points, submittedHandicap.User1Points
+		scoreToUpdate.User1GoalMultiplier, scoreToUpdate.User2GoalMultiplier = submittedHandicap.User2GoalMultiplier, submittedHandicap.User1GoalMultiplier
+	}
+	return nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve handicap information from JSON body
+//     - retrieve existing score of requested match, or between users in active season, or create a new one
+//     - compute handicap points from ratings of users when automatic handicap is requested
+//     - store handicap in score, checking no set is in progress
+//     - send HTTP JSON response containing handicapped score
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var validateError *validate.Errors
+	var submittedHandicap = handicap{}
+	var handicappedScore = models.Score{}
+	var handicappedScoreInJSON []byte
+
+	requestError = json.Unmarshal([]byte(request.Body), &submittedHandicap)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+	if submittedHandicap.User1Id == "" || submittedHandicap.User2Id == "" || submittedHandicap.User1Id == submittedHandicap.User2Id {
+		return errorResponse("Bad request: you must provide two different users", http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	if submittedHandicap.MatchID != "" {
+		matchID, parseError := uuid.FromString(submittedHandicap.MatchID)
+		if parseError != nil {
+			return errorResponse("Bad request: you must provide a valid match id", http.StatusBadRequest)
+		}
+		var handicappedMatch models.Match
+		dbError = databaseConnection.Find(&handicappedMatch, matchID)
+		if dbError != nil {
+			return errorResponse(fmt.Sprintf("Match '%s' not found", matchID), http.StatusNotFound)
+		}
+		if handicappedMatch.Status != models.MatchStatusReady {
+			return errorResponse(fmt.Sprintf("Bad request: match '%s' is %s", matchID, handicappedMatch.Status), http.StatusBadRequest)
+		}
+		if !handicappedMatch.HasUsers(submittedHandicap.User1Id, submittedHandicap.User2Id) {
+			return errorResponse(fmt.Sprintf("Bad request: match '%s' is not played between submitted users", matchID), http.StatusBadRequest)
+		}
+		handicappedScore, dbError = scores.FetchMatchScore(databaseConnection, handicappedMatch)
+		if dbError == nil && handicappedScore.ID == uuid.Nil {
+			// As when it is created by first goal of match, new score of match is counted in active season
+			activeSeason, activeSeasonExists, seasonError := models.FindActiveSeason(databaseConnection, time.Now())
+			if activeSeasonExists {
+				handicappedScore.SeasonID = nulls.NewUUID(activeSeason.ID)
+			}
+			dbError = seasonError
+		}
+	} else {
+		handicappedScore, dbError = scores.FetchPairScore(databaseConnection, submittedHandicap.User1Id, submittedHandicap.User2Id, time.Now())
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve existing score: %s", dbError), http.StatusInternalServerError)
+	}
+
+	if submittedHandicap.Auto {
+		// Levels of users are measured over all seasons, ratings of a season starting from scratch
+		ratings, dbError := matchmaking.FetchRatings(databaseConnection, nulls.UUID{})
+		if dbError != nil {
+			return errorResponse(fmt.Sprintf("Failed to compute ratings: %s", dbError), http.StatusInternalServerError)
+		}
+		submittedHandicap.User1Points, submittedHandicap.User2Points = matchmaking.HandicapPoints(matchmaking.Rating(ratings, submittedHandicap.User1Id), matchmaking.Rating(ratings, submittedHandicap.User2Id))
+		submittedHandicap.User1GoalMultiplier, submittedHandicap.User2GoalMultiplier = 0, 0
+	}
+
+	requestError = applyHandicap(&handicappedScore, submittedHandicap)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request: %s", requestError), http.StatusBadRequest)
+	}
+
+	validateError, dbError = databaseConnection.ValidateAndSave(&handicappedScore)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to store handicap: %s", dbError), http.StatusInternalServerError)
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return errorResponse(fmt.Sprintf("Bad request: %s", validateError), http.StatusBadRequest)
+	}
+
+	handicappedScoreInJSON, marshalError = json.Marshal(handicappedScore)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify score: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(handicappedScoreInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
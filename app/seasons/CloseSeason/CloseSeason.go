@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// closing represents season closing information submitted to API.
+//
+// When a next season is submitted, it starts as soon as current season is closed.
+//
+type closing struct {
+	NextSeason *nextSeason `json:"next_season"`
+}
+
+// nextSeason represents information of season starting when current season is closed.
+//
+type nextSeason struct {
+	Name   string    `json:"name"`
+	EndsAt time.Time `json:"ends_at"`
+}
+
+// closedSeason represents a closed season with its final standings, and next season if any.
+//
+type closedSeason struct {
+	Season     models.Season           `json:"season"`
+	Standings  []models.SeasonStanding `json:"standings"`
+	NextSeason *models.Season          `json:"next_season,omitempty"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// closeSeason archives final standings of season, closes it and creates next season if requested.
+//
+// Scores of closed season are kept untouched: next goals will be counted in new scores.
+//
+func closeSeason(tx *pop.Connection, seasonToClose *models.Season, requestedClosing closing, closingTime time.Time) (result closedSeason, closeError error) {
+	var validateError *validate.Errors
+	var seasonScores []models.Score
+
+	closeError = tx.Where("season_id = ?", seasonToClose.ID).All(&seasonScores)
+	if closeError != nil {
+		return result, closeError
+	}
+
+	result.Standings = models.ComputeStandings(seasonScores)
+	for position := range result.Standings {
+		result.Standings[position].SeasonID = seasonToClose.ID
+		validateError, closeError = tx.ValidateAndCreate(&result.Standings[position])
+		if closeError != nil {
+			return result, closeError
+		}
+		if validateError != nil && len(validateError.Errors) != 0 {
+			return result, validateError
+		}
+	}
+
+	seasonToClose.ClosedAt = nulls.NewTime(closingTime)
+	validateError, closeError = tx.ValidateAndUpdate(seasonToClose)
+	if closeError != nil {
+		return result, closeError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return result, validateError
+	}
+	result.Season = *seasonToClose
+
+	if requestedClosing.NextSeason != nil {
+		createdSeason := models.Season{
+			Name:     requestedClosing.NextSeason.Name,
+			StartsAt: closingTime,
+			EndsAt:   requestedClosing.NextSeason.EndsAt.UTC(),
+		}
+		validateError, closeError = tx.ValidateAndCreate(&createdSeason)
+		if closeError != nil {
+			return result, closeError
+		}
+		if validateError != nil && len(validateError.Errors) != 0 {
+			return result, validateError
+		}
+		result.NextSeason = &createdSeason
+	}
+
+	return result, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve season id from API request path and optional next season from JSON body
+//     - compute and archive final standings of season
+//     - close season and start next season if requested
+//     - send HTTP JSON response containing closed season, its standings and next season
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var requestedSeasonID uuid.UUID
+	var requestedClosing = closing{}
+	var seasonToClose models.Season
+	var result closedSeason
+	var resultInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	requestedSeasonID, requestError = uuid.FromString(request.PathParameters["id"])
+	if requestError != nil {
+		return errorResponse("Bad request: you must provide a valid season id in path", http.StatusBadRequest)
+	}
+
+	if request.Body != "" {
+		requestError = json.Unmarshal([]byte(request.Body), &requestedClosing)
+		if requestError != nil {
+			return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+		}
+	}
+
+	dbError = databaseConnection.Find(&seasonToClose, requestedSeasonID)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Season '%s' not found", requestedSeasonID), http.StatusNotFound)
+	}
+	if seasonToClose.IsClosed() {
+		return errorResponse(fmt.Sprintf("Bad request: season '%s' is already closed", requestedSeasonID), http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		result, transactionError = closeSeason(tx, &seasonToClose, requestedClosing, time.Now().UTC())
+		return transactionError
+	})
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to close season: %s", dbError), http.StatusInternalServerError)
+	}
+
+	resultInJSON, marshalError = json.Marshal(result)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify closed season: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(resultInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// season represents season information submitted to API.
+//
+type season struct {
+	Name     string    `json:"name"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve season information from JSON body (season starts now if no start date is submitted)
+//     - create season, checking no other season is still running (not closed yet)
+//     - send HTTP JSON response containing created season
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var validateError *validate.Errors
+	var submittedSeason = season{}
+	var createdSeason models.Season
+	var createdSeasonInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	requestError = json.Unmarshal([]byte(request.Body), &submittedSeason)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+	if submittedSeason.StartsAt.IsZero() {
+		submittedSeason.StartsAt = time.Now()
+	}
+
+	createdSeason = models.Season{
+		Name:     submittedSeason.Name,
+		StartsAt: submittedSeason.StartsAt.UTC(),
+		EndsAt:   submittedSeason.EndsAt.UTC(),
+	}
+
+	validateError, dbError = databaseConnection.ValidateAndCreate(&createdSeason)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to create season: %s", dbError), http.StatusInternalServerError)
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return errorResponse(fmt.Sprintf("Bad request: %s", validateError), http.StatusBadRequest)
+	}
+
+	createdSeasonInJSON, marshalError = json.Marshal(createdSeason)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify season: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(createdSeasonInJSON),
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
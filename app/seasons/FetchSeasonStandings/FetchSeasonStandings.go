@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+)
+
+// seasonStandings represents a season with its standings (final ones if season is closed).
+//
+type seasonStandings struct {
+	Season    models.Season           `json:"season"`
+	Final     bool                    `json:"final"`
+	Standings []models.SeasonStanding `json:"standings"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve season id from API request path
+//     - retrieve from DB archived standings if season is closed, or compute them from season scores otherwise
+//     - send HTTP JSON response containing season standings
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var requestedSeasonID uuid.UUID
+	var requestedSeasonStandings = seasonStandings{Standings: []models.SeasonStanding{}}
+	var seasonScores []models.Score
+	var standingsInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	requestedSeasonID, requestError = uuid.FromString(request.PathParameters["id"])
+	if requestError != nil {
+		return errorResponse("Bad request: you must provide a valid season id in path", http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.Find(&requestedSeasonStandings.Season, requestedSeasonID)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Season '%s' not found", requestedSeasonID), http.StatusNotFound)
+	}
+
+	if requestedSeasonStandings.Season.IsClosed() {
+		requestedSeasonStandings.Final = true
+		dbError = databaseConnection.Where("season_id = ?", requestedSeasonID).Order("rank").All(&requestedSeasonStandings.Standings)
+	} else {
+		dbError = databaseConnection.Where("season_id = ?", requestedSeasonID).All(&seasonScores)
+		requestedSeasonStandings.Standings = models.ComputeStandings(seasonScores)
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve standings of season '%s': %s", requestedSeasonID, dbError), http.StatusInternalServerError)
+	}
+
+	standingsInJSON, marshalError = json.Marshal(requestedSeasonStandings)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify season standings: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(standingsInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
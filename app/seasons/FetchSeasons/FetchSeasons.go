@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// seasonStatus represents a season and whether it is currently running.
+//
+type seasonStatus struct {
+	models.Season
+	Active bool `json:"active"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve from DB all seasons, from most recent to oldest
+//     - flag season currently running
+//     - send HTTP JSON response containing these seasons
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var dbError, marshalError error
+	var seasons []models.Season
+	var seasonsStatuses = []seasonStatus{}
+	var seasonsInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	activeSeason, activeSeasonExists, dbError := models.FindActiveSeason(databaseConnection, time.Now())
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve active season: %s", dbError), http.StatusInternalServerError)
+	}
+
+	dbError = databaseConnection.Order("starts_at DESC").All(&seasons)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve seasons: %s", dbError), http.StatusInternalServerError)
+	}
+
+	for _, season := range seasons {
+		seasonsStatuses = append(seasonsStatuses, seasonStatus{Season: season, Active: activeSeasonExists && season.ID == activeSeason.ID})
+	}
+
+	seasonsInJSON, marshalError = json.Marshal(seasonsStatuses)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify seasons: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(seasonsInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
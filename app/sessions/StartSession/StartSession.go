@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sessionUsers represents users of session to start, as submitted to API.
+//
+type sessionUsers struct {
+	User1Id string `json:"user1_id"`
+	User2Id string `json:"user2_id"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve users of session from JSON body
+//     - start session, checking no other session is in progress between both users
+//     - send HTTP JSON response containing started session
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var validateError *validate.Errors
+	var submittedUsers = sessionUsers{}
+	var startedSession models.Session
+	var startedSessionInJSON []byte
+
+	requestError = json.Unmarshal([]byte(request.Body), &submittedUsers)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	startedSession = models.Session{
+		User1Id:   submittedUsers.User1Id,
+		User2Id:   submittedUsers.User2Id,
+		Status:    models.SessionStatusPlaying,
+		StartedAt: time.Now().UTC(),
+	}
+
+	validateError, dbError = databaseConnection.ValidateAndCreate(&startedSession)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to start session: %s", dbError), http.StatusInternalServerError)
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return errorResponse(fmt.Sprintf("Bad request: %s", validateError), http.StatusBadRequest)
+	}
+
+	startedSessionInJSON, marshalError = json.Marshal(startedSession)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify session: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(startedSessionInJSON),
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
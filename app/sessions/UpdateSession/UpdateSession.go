@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/sessions"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sessionActions lists actions available on a session, by name in API request path.
+var sessionActions = map[string]func(session *models.Session, at time.Time) error{
+	"pause":  sessions.Pause,
+	"resume": sessions.Resume,
+	"end":    sessions.End,
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve session id and action (pause, resume or end) from API request path
+//     - apply action to session, checking session status allows it
+//     - send HTTP JSON response containing updated session, with time played so far
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, actionError, marshalError error
+	var validateError *validate.Errors
+	var requestedSessionID uuid.UUID
+	var requestedSession models.Session
+	var sessionGoals []models.Goal
+	var updatedSessionInJSON []byte
+
+	requestedSessionID, requestError = uuid.FromString(request.PathParameters["id"])
+	if requestError != nil {
+		return errorResponse("Bad request: you must provide a valid session id in path", http.StatusBadRequest)
+	}
+	sessionAction, actionExists := sessionActions[request.PathParameters["action"]]
+	if !actionExists {
+		return errorResponse("Bad request: action must be one of 'pause', 'resume' or 'end'", http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	dbError = databaseConnection.Find(&requestedSession, requestedSessionID)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Session '%s' not found", requestedSessionID), http.StatusNotFound)
+	}
+
+	now := time.Now().UTC()
+	actionError = sessionAction(&requestedSession, now)
+	if actionError != nil {
+		return errorResponse(fmt.Sprintf("Bad request: %s", actionError), http.StatusBadRequest)
+	}
+
+	validateError, dbError = databaseConnection.ValidateAndUpdate(&requestedSession)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to update session: %s", dbError), http.StatusInternalServerError)
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return errorResponse(fmt.Sprintf("Bad request: %s", validateError), http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.Where("session_id = ?", requestedSession.ID).Order("created_at").All(&sessionGoals)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve goals of session: %s", dbError), http.StatusInternalServerError)
+	}
+
+	updatedSessionInJSON, marshalError = json.Marshal(sessions.BuildLiveSession(requestedSession, sessionGoals, now))
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify session: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(updatedSessionInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
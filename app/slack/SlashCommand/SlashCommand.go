@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/logging"
+	"github.com/vlarrat-theodo/lbc-foosball/metrics"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/scores"
+	"github.com/vlarrat-theodo/lbc-foosball/slack"
+	"github.com/vlarrat-theodo/lbc-foosball/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// replyResponse formats API HTTP responses containing a Slack reply.
+//
+// Slack only displays replies of successful responses, so that replies refusing a command are sent with a 200 status.
+//
+func replyResponse(reply slack.Reply) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	replyInJSON, marshalError := json.Marshal(reply)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify reply: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(replyInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// requestHeader returns value of submitted header, whatever the case API Gateway forwarded it with.
+//
+func requestHeader(request events.APIGatewayProxyRequest, headerName string) (headerValue string) {
+	for name, value := range request.Headers {
+		if strings.EqualFold(name, headerName) {
+			return value
+		}
+	}
+	return ""
+}
+
+// requestBody returns raw body of request, decoding it when API Gateway encoded it in base64.
+//
+func requestBody(request events.APIGatewayProxyRequest) (body string, decodeError error) {
+	if !request.IsBase64Encoded {
+		return request.Body, nil
+	}
+	decodedBody, decodeError := base64.StdEncoding.DecodeString(request.Body)
+	return string(decodedBody), decodeError
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - check that request has been signed by Slack with shared signing secret
+//     - parse command typed after "/foosball" (e.g. "goal @alice vs @bob p3 gamelle")
+//     - convert Slack users to foosball users
+//     - store goal exactly as StoreGoal Lambda does
+//     - record metrics of goal and handler latency, flushed to CloudWatch through logs
+//     - log outcome of goal as a JSON line, with ID of request and Slack user who typed command
+//     - trace command parsing, score lookup, score update and storage in spans, exported when configured
+//     - send HTTP JSON response containing a Slack message with current score between users
+//
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError error
+	var command slack.Command
+	var goalScore models.Score
+
+	var logger = logging.FromRequest(request, "SlashCommand")
+
+	defer metrics.Flush("SlashCommand")
+	defer metrics.HandlerDuration.ObserveSince(time.Now(), "SlashCommand")
+	defer tracing.Flush(ctx)
+
+	ctx, span := tracing.Start(ctx, "SlashCommand handler", attribute.String("request_id", request.RequestContext.RequestID))
+	defer func() {
+		span.SetAttributes(attribute.Int("http.status_code", APIResponse.StatusCode))
+		tracing.End(span, nil)
+	}()
+
+	body, requestError := requestBody(request)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+
+	requestError = slack.Verify(slack.SigningSecret(), requestHeader(request, "X-Slack-Request-Timestamp"), requestHeader(request, "X-Slack-Signature"), body, time.Now())
+	if requestError == slack.ErrMissingSigningSecret {
+		return errorResponse(fmt.Sprintf("Failed to verify request: %s", requestError), http.StatusInternalServerError)
+	}
+	if requestError != nil {
+		logger.Warn("Unauthorized Slack request", logging.Fields{"error": requestError.Error()})
+		return errorResponse(fmt.Sprintf("Unauthorized: %s", requestError), http.StatusUnauthorized)
+	}
+
+	_, parseSpan := tracing.Start(ctx, "parse_request")
+	form, requestError := url.ParseQuery(body)
+	if requestError != nil {
+		tracing.End(parseSpan, requestError)
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+
+	command, requestError = slack.ParseCommand(form.Get("text"), slack.UserMapping())
+	tracing.End(parseSpan, requestError)
+	if requestError != nil {
+		metrics.ValidationFailuresTotal.Inc("invalid_command")
+		return replyResponse(slack.FormatError(requestError))
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		metrics.DBErrorsTotal.Inc("connect")
+		logger.Error("Failed to connect to database", logging.Fields{"error": dbError.Error()})
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	goalScore, dbError = scores.StoreGoal(ctx, databaseConnection, command.Goal, time.Now(), logger.With(logging.Fields{"slack_user_id": form.Get("user_id")}))
+	if goalError, refusedGoal := dbError.(scores.GoalError); refusedGoal {
+		return replyResponse(slack.FormatError(goalError))
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to create/update score: %s", dbError), http.StatusInternalServerError)
+	}
+
+	return replyResponse(slack.FormatScoreboard(command, goalScore))
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	tracingError := tracing.Init("SlashCommand")
+	if tracingError != nil {
+		logging.New(logging.Fields{"handler": "SlashCommand"}).Error("Failed to configure tracing", logging.Fields{"error": tracingError.Error()})
+	}
+	lambda.Start(handler)
+}
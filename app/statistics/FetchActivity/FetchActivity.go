@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/office"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+const defaultActivityDays = 365
+const maxActivityDays = 3 * 366
+
+// goalActivity represents one goal as used to compute activity (sets imported from history only counting as sets).
+//
+type goalActivity struct {
+	CreatedAt   time.Time `db:"created_at"`
+	ScorerID    string    `db:"scorer_id"`
+	Kind        string    `db:"kind"`
+	SetFinished bool      `db:"set_finished"`
+}
+
+// dayActivity represents number of goals and finished sets on one day.
+//
+type dayActivity struct {
+	Date  string `json:"date"`
+	Goals int    `json:"goals"`
+	Sets  int    `json:"sets"`
+}
+
+// hourActivity represents number of goals and finished sets during one hour of the day, all days together.
+//
+type hourActivity struct {
+	Hour  int `json:"hour"`
+	Goals int `json:"goals"`
+	Sets  int `json:"sets"`
+}
+
+// activity represents time series of goals and finished sets, by day and by hour.
+//
+type activity struct {
+	UserID   string         `json:"user_id,omitempty"`
+	Timezone string         `json:"timezone"`
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Days     []dayActivity  `json:"days"`
+	Hours    []hourActivity `json:"hours"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// parseActivityRange computes first and last days of requested activity period (last 365 days by default).
+//
+func parseActivityRange(requestedFrom string, requestedTo string, now time.Time) (fromDay time.Time, toDay time.Time, parseError error) {
+	toDay = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if requestedTo != "" {
+		toDay, parseError = time.ParseInLocation(dateLayout, requestedTo, now.Location())
+		if parseError != nil {
+			return fromDay, toDay, fmt.Errorf("'to' parameter must be a date formatted as YYYY-MM-DD")
+		}
+	}
+
+	fromDay = toDay.AddDate(0, 0, 1-defaultActivityDays)
+	if requestedFrom != "" {
+		fromDay, parseError = time.ParseInLocation(dateLayout, requestedFrom, now.Location())
+		if parseError != nil {
+			return fromDay, toDay, fmt.Errorf("'from' parameter must be a date formatted as YYYY-MM-DD")
+		}
+	}
+
+	if fromDay.After(toDay) {
+		return fromDay, toDay, fmt.Errorf("'from' parameter must be before 'to' parameter")
+	}
+	if toDay.Sub(fromDay) > maxActivityDays*24*time.Hour {
+		return fromDay, toDay, fmt.Errorf("requested period must not exceed %d days", maxActivityDays)
+	}
+
+	return fromDay, toDay, nil
+}
+
+// computeActivity dispatches goals by day and by hour in office timezone.
+//
+// All days of requested period and all hours are always present in time series, even without any goal.
+// When a user is requested, only goals scored by this user are counted, but all sets played by this user are counted.
+//
+func computeActivity(goals []goalActivity, userID string, fromDay time.Time, toDay time.Time) (computedActivity activity) {
+	var dayIndexes = make(map[string]int)
+
+	computedActivity.UserID = userID
+	computedActivity.Timezone = fromDay.Location().String()
+	computedActivity.From = fromDay.Format(dateLayout)
+	computedActivity.To = toDay.Format(dateLayout)
+	computedActivity.Days = []dayActivity{}
+	computedActivity.Hours = make([]hourActivity, 24)
+
+	for day := fromDay; !day.After(toDay); day = day.AddDate(0, 0, 1) {
+		dayIndexes[day.Format(dateLayout)] = len(computedActivity.Days)
+		computedActivity.Days = append(computedActivity.Days, dayActivity{Date: day.Format(dateLayout)})
+	}
+	for hour := range computedActivity.Hours {
+		computedActivity.Hours[hour].Hour = hour
+	}
+
+	for _, goal := range goals {
+		goalTime := goal.CreatedAt.In(fromDay.Location())
+		dayIndex, inPeriod := dayIndexes[goalTime.Format(dateLayout)]
+		if !inPeriod {
+			continue
+		}
+
+		if goal.Kind != models.GoalKindSetResult && (userID == "" || goal.ScorerID == userID) {
+			computedActivity.Days[dayIndex].Goals++
+			computedActivity.Hours[goalTime.Hour()].Goals++
+		}
+		if goal.SetFinished {
+			computedActivity.Days[dayIndex].Sets++
+			computedActivity.Hours[goalTime.Hour()].Sets++
+		}
+	}
+
+	return computedActivity
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve optional user_id and period (from/to dates) from API request
+//     - retrieve from DB all goals of this period (involving requested user if any)
+//     - dispatch goals and finished sets by day and by hour in office timezone
+//     - send HTTP JSON response containing these time series
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var dbError, marshalError, locationError, rangeError error
+	var location *time.Location
+	var fromDay, toDay time.Time
+	var requestedUserID string
+	var periodGoals []goalActivity
+	var activityInJSON []byte
+
+	location, locationError = office.Location()
+	if locationError != nil {
+		return errorResponse(fmt.Sprintf("Failed to load office timezone: %s", locationError), http.StatusInternalServerError)
+	}
+
+	fromDay, toDay, rangeError = parseActivityRange(request.QueryStringParameters["from"], request.QueryStringParameters["to"], time.Now().In(location))
+	if rangeError != nil {
+		return errorResponse(fmt.Sprintf("Bad request: %s", rangeError), http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	const periodGoalsQuery = "SELECT created_at, scorer_id, kind, set_finished FROM goals WHERE created_at >= ? AND created_at < ?"
+	periodStart := fromDay.UTC()
+	periodEnd := toDay.AddDate(0, 0, 1).UTC()
+
+	requestedUserID = request.QueryStringParameters["user_id"]
+	if requestedUserID != "" {
+		dbError = databaseConnection.RawQuery(periodGoalsQuery+" AND (scorer_id = ? OR opponent_id = ?)", periodStart, periodEnd, requestedUserID, requestedUserID).All(&periodGoals)
+	} else {
+		dbError = databaseConnection.RawQuery(periodGoalsQuery, periodStart, periodEnd).All(&periodGoals)
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve goals: %s", dbError), http.StatusInternalServerError)
+	}
+
+	activityInJSON, marshalError = json.Marshal(computeActivity(periodGoals, requestedUserID, fromDay, toDay))
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify activity: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(activityInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
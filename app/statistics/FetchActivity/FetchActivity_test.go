@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+	"time"
+)
+
+// TestParseActivityRange tests parseActivityRange function for default, valid and invalid periods.
+//
+func TestParseActivityRange(t *testing.T) {
+	var rangeError error
+	var fromDay, toDay time.Time
+
+	assertHandler := assert.New(t)
+	location, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2026, 10, 19, 15, 30, 0, 0, location)
+
+	fromDay, toDay, rangeError = parseActivityRange("", "", now)
+	assertHandler.Nil(rangeError, "Default period: parseActivityRange function should not raise an error")
+	assertHandler.Equal(time.Date(2025, 10, 20, 0, 0, 0, 0, location), fromDay, "Default period: first day not computed as expected")
+	assertHandler.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, location), toDay, "Default period: last day not computed as expected")
+
+	fromDay, toDay, rangeError = parseActivityRange("2026-09-01", "2026-09-30", now)
+	assertHandler.Nil(rangeError, "Requested period: parseActivityRange function should not raise an error")
+	assertHandler.Equal(time.Date(2026, 9, 1, 0, 0, 0, 0, location), fromDay, "Requested period: first day not computed as expected")
+	assertHandler.Equal(time.Date(2026, 9, 30, 0, 0, 0, 0, location), toDay, "Requested period: last day not computed as expected")
+
+	_, _, rangeError = parseActivityRange("2026-09-30", "2026-09-01", now)
+	assertHandler.NotNil(rangeError, "Reversed period: parseActivityRange function should raise an error")
+
+	_, _, rangeError = parseActivityRange("01/09/2026", "", now)
+	assertHandler.NotNil(rangeError, "Badly formatted date: parseActivityRange function should raise an error")
+
+	_, _, rangeError = parseActivityRange("2010-01-01", "", now)
+	assertHandler.NotNil(rangeError, "Too long period: parseActivityRange function should raise an error")
+}
+
+// TestComputeActivity tests computeActivity function for goals dispatched in office timezone.
+//
+func TestComputeActivity(t *testing.T) {
+	assertHandler := assert.New(t)
+	location, _ := time.LoadLocation("Europe/Paris")
+	fromDay := time.Date(2026, 10, 1, 0, 0, 0, 0, location)
+	toDay := time.Date(2026, 10, 3, 0, 0, 0, 0, location)
+
+	goals := []goalActivity{
+		{CreatedAt: time.Date(2026, 9, 30, 21, 0, 0, 0, time.UTC), ScorerID: "user1"},
+		{CreatedAt: time.Date(2026, 9, 30, 23, 30, 0, 0, time.UTC), ScorerID: "user1"},
+		{CreatedAt: time.Date(2026, 10, 2, 10, 5, 0, 0, time.UTC), ScorerID: "user2", SetFinished: true},
+		{CreatedAt: time.Date(2026, 10, 2, 10, 10, 0, 0, time.UTC), ScorerID: "user1"},
+		{CreatedAt: time.Date(2026, 10, 3, 9, 0, 0, 0, time.UTC), ScorerID: "user1", Kind: models.GoalKindSetResult, SetFinished: true},
+		{CreatedAt: time.Date(2026, 10, 4, 8, 0, 0, 0, time.UTC), ScorerID: "user1"},
+	}
+
+	globalActivity := computeActivity(goals, "", fromDay, toDay)
+	assertHandler.Equal("Europe/Paris", globalActivity.Timezone, "Global activity: timezone not set as expected")
+	assertHandler.Equal([]dayActivity{
+		{Date: "2026-10-01", Goals: 1, Sets: 0},
+		{Date: "2026-10-02", Goals: 2, Sets: 1},
+		{Date: "2026-10-03", Goals: 0, Sets: 1},
+	}, globalActivity.Days, "Global activity: days not computed as expected")
+	assertHandler.Len(globalActivity.Hours, 24, "Global activity: all hours should be present")
+	assertHandler.Equal(hourActivity{Hour: 1, Goals: 1}, globalActivity.Hours[1], "Global activity: goal after midnight not dispatched as expected")
+	assertHandler.Equal(hourActivity{Hour: 12, Goals: 2, Sets: 1}, globalActivity.Hours[12], "Global activity: goals at noon not dispatched as expected")
+	assertHandler.Equal(hourActivity{Hour: 11, Goals: 0, Sets: 1}, globalActivity.Hours[11], "Global activity: imported set should only count as a set")
+
+	userActivity := computeActivity(goals, "user2", fromDay, toDay)
+	assertHandler.Equal("user2", userActivity.UserID, "User activity: user_id not set as expected")
+	assertHandler.Equal(dayActivity{Date: "2026-10-02", Goals: 1, Sets: 1}, userActivity.Days[1], "User activity: only user goals should be counted")
+}
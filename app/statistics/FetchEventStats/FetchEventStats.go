@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+)
+
+// demiBalancePoints is the number of points stored in balance by each "demi" goal.
+const demiBalancePoints = 2
+
+// kindGoals represents number of goals of one kind scored by one user against one opponent.
+//
+type kindGoals struct {
+	ScorerID      string `db:"scorer_id"`
+	OpponentID    string `db:"opponent_id"`
+	Kind          string `db:"kind"`
+	Goals         int    `db:"goals"`
+	BalanceCashed int    `db:"balance_cashed"`
+}
+
+// eventCounts represents number of special events ("gamelle", "pissette", "demi") and balance points.
+//
+type eventCounts struct {
+	GamellesScored      int `json:"gamelles_scored"`
+	GamellesSuffered    int `json:"gamelles_suffered"`
+	DemiGamelles        int `json:"demi_gamelles"`
+	Pissettes           int `json:"pissettes"`
+	Demis               int `json:"demis"`
+	BalancePointsStored int `json:"balance_points_stored"`
+	BalancePointsCashed int `json:"balance_points_cashed"`
+}
+
+// userEventCounts represents special events counts of one user.
+//
+type userEventCounts struct {
+	UserID string `json:"user_id"`
+	eventCounts
+}
+
+// eventStats represents special events counts overall and for each user.
+//
+type eventStats struct {
+	Overall eventCounts            `json:"overall"`
+	Users   map[string]eventCounts `json:"users"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// addScorerEvents adds to counts special events scored by a user.
+//
+func (c *eventCounts) addScorerEvents(goalsOfKind kindGoals) {
+	switch goalsOfKind.Kind {
+	case models.GoalKindGamelle:
+		c.GamellesScored += goalsOfKind.Goals
+	case models.GoalKindDemiGamelle:
+		c.DemiGamelles += goalsOfKind.Goals
+	case models.GoalKindPissette:
+		c.Pissettes += goalsOfKind.Goals
+	case models.GoalKindDemi:
+		c.Demis += goalsOfKind.Goals
+		c.BalancePointsStored += goalsOfKind.Goals * demiBalancePoints
+	}
+	c.BalancePointsCashed += goalsOfKind.BalanceCashed
+}
+
+// addOpponentEvents adds to counts special events suffered by a user.
+//
+func (c *eventCounts) addOpponentEvents(goalsOfKind kindGoals) {
+	if goalsOfKind.Kind == models.GoalKindGamelle {
+		c.GamellesSuffered += goalsOfKind.Goals
+	}
+}
+
+// computeEventStats generates special events statistics overall and for each user.
+//
+func computeEventStats(goalsByKind []kindGoals) (stats eventStats) {
+	stats.Users = make(map[string]eventCounts)
+
+	for _, goalsOfKind := range goalsByKind {
+		scorerCounts := stats.Users[goalsOfKind.ScorerID]
+		scorerCounts.addScorerEvents(goalsOfKind)
+		stats.Users[goalsOfKind.ScorerID] = scorerCounts
+
+		opponentCounts := stats.Users[goalsOfKind.OpponentID]
+		opponentCounts.addOpponentEvents(goalsOfKind)
+		stats.Users[goalsOfKind.OpponentID] = opponentCounts
+
+		stats.Overall.addScorerEvents(goalsOfKind)
+		stats.Overall.addOpponentEvents(goalsOfKind)
+	}
+
+	return stats
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve optional user_id from API request
+//     - retrieve from DB number of goals of each kind (involving requested user if any)
+//     - calculate special events counts overall and for each user
+//     - send HTTP JSON response containing counts for requested user, or overall and for each user
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var dbError, marshalError error
+	var requestedUserID string
+	var goalsByKind []kindGoals
+	var stats eventStats
+	var statsInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	// Sets imported from history are recorded as goals, but are not events
+	const goalsByKindQuery = "SELECT scorer_id, opponent_id, kind, COUNT(*) AS goals, SUM(balance_cashed) AS balance_cashed FROM goals WHERE kind <> ? %s GROUP BY scorer_id, opponent_id, kind"
+
+	requestedUserID = request.QueryStringParameters["user_id"]
+	if requestedUserID != "" {
+		dbError = databaseConnection.RawQuery(fmt.Sprintf(goalsByKindQuery, "AND (scorer_id = ? OR opponent_id = ?)"), models.GoalKindSetResult, requestedUserID, requestedUserID).All(&goalsByKind)
+	} else {
+		dbError = databaseConnection.RawQuery(fmt.Sprintf(goalsByKindQuery, ""), models.GoalKindSetResult).All(&goalsByKind)
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve goals: %s", dbError), http.StatusInternalServerError)
+	}
+
+	stats = computeEventStats(goalsByKind)
+
+	if requestedUserID != "" {
+		statsInJSON, marshalError = json.Marshal(userEventCounts{UserID: requestedUserID, eventCounts: stats.Users[requestedUserID]})
+	} else {
+		statsInJSON, marshalError = json.Marshal(stats)
+	}
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify events statistics: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(statsInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
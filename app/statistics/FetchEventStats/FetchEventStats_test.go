@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+)
+
+// TestComputeEventStats tests computeEventStats function for goals of all kinds between several users.
+//
+func TestComputeEventStats(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	goalsByKind := []kindGoals{
+		{ScorerID: "user1", OpponentID: "user2", Kind: models.GoalKindGamelle, Goals: 2},
+		{ScorerID: "user1", OpponentID: "user2", Kind: models.GoalKindDemi, Goals: 3},
+		{ScorerID: "user1", OpponentID: "user2", Kind: models.GoalKindClassic, Goals: 5, BalanceCashed: 4},
+		{ScorerID: "user2", OpponentID: "user1", Kind: models.GoalKindPissette, Goals: 1},
+		{ScorerID: "user2", OpponentID: "user1", Kind: models.GoalKindDemiGamelle, Goals: 1},
+		{ScorerID: "user3", OpponentID: "user1", Kind: models.GoalKindGamelle, Goals: 1},
+	}
+
+	stats := computeEventStats(goalsByKind)
+
+	awaitedUser1Counts := eventCounts{GamellesScored: 2, GamellesSuffered: 1, Demis: 3, BalancePointsStored: 6, BalancePointsCashed: 4}
+	awaitedUser2Counts := eventCounts{GamellesSuffered: 2, DemiGamelles: 1, Pissettes: 1}
+	awaitedUser3Counts := eventCounts{GamellesScored: 1}
+	awaitedOverallCounts := eventCounts{GamellesScored: 3, GamellesSuffered: 3, DemiGamelles: 1, Pissettes: 1, Demis: 3, BalancePointsStored: 6, BalancePointsCashed: 4}
+
+	assertHandler.Equal(awaitedUser1Counts, stats.Users["user1"], "User1 events: counts not computed as expected")
+	assertHandler.Equal(awaitedUser2Counts, stats.Users["user2"], "User2 events: counts not computed as expected")
+	assertHandler.Equal(awaitedUser3Counts, stats.Users["user3"], "User3 events: counts not computed as expected")
+	assertHandler.Equal(awaitedOverallCounts, stats.Overall, "Overall events: counts not computed as expected")
+}
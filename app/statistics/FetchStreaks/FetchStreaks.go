@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultLeaderboardSize = 10
+const maxLeaderboardSize = 100
+
+// leaderboardOrders lists SQL orders applied for each type of leaderboard.
+var leaderboardOrders = map[string]string{
+	"wins":   "best_wins DESC, current_wins DESC, user_id",
+	"losses": "best_losses DESC, current_losses DESC, user_id",
+}
+
+// streakRank represents streaks of one user and its position in leaderboard.
+//
+type streakRank struct {
+	Rank          int    `json:"rank"`
+	UserID        string `json:"user_id"`
+	CurrentWins   int    `json:"current_wins"`
+	CurrentLosses int    `json:"current_losses"`
+	BestWins      int    `json:"best_wins"`
+	BestLosses    int    `json:"best_losses"`
+}
+
+// streaksLeaderboard represents users ranked according to their record streaks.
+//
+type streaksLeaderboard struct {
+	Type        string       `json:"type"`
+	Leaderboard []streakRank `json:"leaderboard"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve leaderboard type ("wins" by default, or "losses") and size from API request
+//     - retrieve from DB streaks of best ranked users
+//     - send HTTP JSON response containing leaderboard
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var dbError, marshalError, conversionError error
+	var leaderboard = streaksLeaderboard{Type: "wins", Leaderboard: []streakRank{}}
+	var leaderboardSize = defaultLeaderboardSize
+	var rankedStreaks []models.Streak
+	var leaderboardInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	if requestedType := request.QueryStringParameters["type"]; requestedType != "" {
+		leaderboard.Type = requestedType
+	}
+	leaderboardOrder, existingType := leaderboardOrders[leaderboard.Type]
+	if !existingType {
+		return errorResponse("Bad request: 'type' parameter must be 'wins' or 'losses'", http.StatusBadRequest)
+	}
+
+	if requestedSize := request.QueryStringParameters["limit"]; requestedSize != "" {
+		leaderboardSize, conversionError = strconv.Atoi(requestedSize)
+		if conversionError != nil || leaderboardSize < 1 || leaderboardSize > maxLeaderboardSize {
+			return errorResponse(fmt.Sprintf("Bad request: 'limit' parameter must be an integer between 1 and %d", maxLeaderboardSize), http.StatusBadRequest)
+		}
+	}
+
+	dbError = databaseConnection.Order(leaderboardOrder).Limit(leaderboardSize).All(&rankedStreaks)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve streaks: %s", dbError), http.StatusInternalServerError)
+	}
+
+	for position, rankedStreak := range rankedStreaks {
+		leaderboard.Leaderboard = append(leaderboard.Leaderboard, streakRank{
+			Rank:          position + 1,
+			UserID:        rankedStreak.UserId,
+			CurrentWins:   rankedStreak.CurrentWins,
+			CurrentLosses: rankedStreak.CurrentLosses,
+			BestWins:      rankedStreak.BestWins,
+			BestLosses:    rankedStreak.BestLosses,
+		})
+	}
+
+	leaderboardInJSON, marshalError = json.Marshal(leaderboard)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify streaks leaderboard: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(leaderboardInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
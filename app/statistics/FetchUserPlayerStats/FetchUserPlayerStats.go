@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// playerGoals represents number of goals scored by one user with one player.
+//
+type playerGoals struct {
+	Player string `db:"player"`
+	Goals  int    `db:"goals"`
+}
+
+// goalsShare represents number of goals and their percentage among all goals scored by one user.
+//
+type goalsShare struct {
+	Goals      int     `json:"goals"`
+	Percentage float64 `json:"percentage"`
+}
+
+// playerStats represents goals scored by one user, detailed by player and by field position.
+//
+type playerStats struct {
+	UserID    string                `json:"user_id"`
+	Goals     int                   `json:"goals"`
+	Players   map[string]goalsShare `json:"players"`
+	Positions map[string]goalsShare `json:"positions"`
+}
+
+var authorizedPlayers = [...]string{"p1", "p2", "p3", "p4", "p5", "p6", "p7", "p8", "p9", "p10", "p11"}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// percentage returns share of part in total, rounded to 2 decimals (0 if total is 0).
+//
+func percentage(part int, total int) (roundedPercentage float64) {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
+
+// computePlayerStats generates user statistics according to goals scored with each player.
+//
+// All players and positions are always present in statistics, even when no goal has been scored with them.
+//
+func computePlayerStats(userID string, goalsByPlayer []playerGoals) (userStats playerStats) {
+	var goalsCountByPlayer = make(map[string]int)
+	var goalsCountByPosition = make(map[string]int)
+
+	userStats.UserID = userID
+	userStats.Players = make(map[string]goalsShare)
+	userStats.Positions = make(map[string]goalsShare)
+
+	for _, playerGoal := range goalsByPlayer {
+		goalsCountByPlayer[playerGoal.Player] += playerGoal.Goals
+		goalsCountByPosition[models.PlayerPosition(playerGoal.Player)] += playerGoal.Goals
+		userStats.Goals += playerGoal.Goals
+	}
+
+	for _, player := range authorizedPlayers {
+		userStats.Players[player] = goalsShare{
+			Goals:      goalsCountByPlayer[player],
+			Percentage: percentage(goalsCountByPlayer[player], userStats.Goals),
+		}
+	}
+	for _, position := range models.Positions {
+		userStats.Positions[position] = goalsShare{
+			Goals:      goalsCountByPosition[position],
+			Percentage: percentage(goalsCountByPosition[position], userStats.Goals),
+		}
+	}
+
+	return userStats
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve user_id from API request
+//     - retrieve from DB number of goals scored by requested user with each player
+//     - calculate goals counts and percentages by player and by field position
+//     - send HTTP JSON response containing this information
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var dbError, marshalError error
+	var requestedUserID string
+	var requestedUserGoals []playerGoals
+	var requestedUserStatsInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	requestedUserID = request.QueryStringParameters["user_id"]
+	if requestedUserID == "" {
+		return errorResponse("Bad request: you must provide a value for 'user_id' parameter", http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.RawQuery("SELECT player, COUNT(*) AS goals FROM goals WHERE scorer_id = ? AND kind <> ? GROUP BY player", requestedUserID, models.GoalKindSetResult).All(&requestedUserGoals)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve user's goals for user_id '%s'", requestedUserID), http.StatusInternalServerError)
+	}
+
+	requestedUserStatsInJSON, marshalError = json.Marshal(computePlayerStats(requestedUserID, requestedUserGoals))
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify user statistics: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(requestedUserStatsInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
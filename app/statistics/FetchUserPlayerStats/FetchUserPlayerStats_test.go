@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// TestComputePlayerStatsWithoutGoals tests computePlayerStats function for user who never scored.
+//
+func TestComputePlayerStatsWithoutGoals(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	userStats := computePlayerStats("user1", nil)
+
+	assertHandler.Equal("user1", userStats.UserID, "No goal: user_id not set as expected")
+	assertHandler.Equal(0, userStats.Goals, "No goal: total goals should be 0")
+	assertHandler.Len(userStats.Players, 11, "No goal: all players should be present")
+	assertHandler.Len(userStats.Positions, 5, "No goal: all positions should be present")
+	assertHandler.Equal(goalsShare{Goals: 0, Percentage: 0}, userStats.Players["p1"], "No goal: player share not computed as expected")
+	assertHandler.Equal(goalsShare{Goals: 0, Percentage: 0}, userStats.Positions["demi"], "No goal: position share not computed as expected")
+}
+
+// TestComputePlayerStatsByPosition tests computePlayerStats function for goals scored with several players.
+//
+func TestComputePlayerStatsByPosition(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	goalsByPlayer := []playerGoals{
+		{Player: "p1", Goals: 1},
+		{Player: "p3", Goals: 2},
+		{Player: "p4", Goals: 3},
+		{Player: "p6", Goals: 3},
+		{Player: "p9", Goals: 1},
+		{Player: "p11", Goals: 2},
+	}
+
+	userStats := computePlayerStats("user1", goalsByPlayer)
+
+	assertHandler.Equal(12, userStats.Goals, "Several goals: total goals not computed as expected")
+	assertHandler.Equal(goalsShare{Goals: 1, Percentage: 8.33}, userStats.Players["p1"], "Several goals: p1 share not computed as expected")
+	assertHandler.Equal(goalsShare{Goals: 0, Percentage: 0}, userStats.Players["p2"], "Several goals: p2 share not computed as expected")
+	assertHandler.Equal(goalsShare{Goals: 3, Percentage: 25}, userStats.Players["p4"], "Several goals: p4 share not computed as expected")
+	assertHandler.Equal(goalsShare{Goals: 1, Percentage: 8.33}, userStats.Positions["goalkeeper"], "Several goals: goalkeeper share not computed as expected")
+	assertHandler.Equal(goalsShare{Goals: 2, Percentage: 16.67}, userStats.Positions["defender"], "Several goals: defender share not computed as expected")
+	assertHandler.Equal(goalsShare{Goals: 6, Percentage: 50}, userStats.Positions["demi"], "Several goals: demi share not computed as expected")
+	assertHandler.Equal(goalsShare{Goals: 1, Percentage: 8.33}, userStats.Positions["pissette"], "Several goals: pissette share not computed as expected")
+	assertHandler.Equal(goalsShare{Goals: 2, Percentage: 16.67}, userStats.Positions["forward"], "Several goals: forward share not computed as expected")
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+)
+
+const defaultSetsToWin = 2
+
+// Default group stage of group-stage-plus-knockout tournaments: groups of 4, whose first 2 play the knockout.
+const (
+	defaultGroupSize          = 4
+	defaultQualifiersPerGroup = 2
+)
+
+// tournament represents tournament information submitted to API.
+//
+// HomeAndAway is only used by round-robin tournaments, and Rounds by Swiss-system tournaments
+// (enough rounds to designate a single unbeaten participant by default).
+// GroupSize and QualifiersPerGroup are only used by group-stage-plus-knockout tournaments.
+//
+type tournament struct {
+	Name               string `json:"name"`
+	Format             string `json:"format"`
+	SetsToWin          int    `json:"sets_to_win"`
+	HomeAndAway        bool   `json:"home_and_away"`
+	Rounds             int    `json:"rounds"`
+	GroupSize          int    `json:"group_size"`
+	QualifiersPerGroup int    `json:"qualifiers_per_group"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve tournament information from JSON body (single elimination in 2 winning sets by default)
+//     - create tournament, opened to registrations
+//     - send HTTP JSON response containing created tournament
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var validateError *validate.Errors
+	var submittedTournament = tournament{}
+	var createdTournament models.Tournament
+	var createdTournamentInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	requestError = json.Unmarshal([]byte(request.Body), &submittedTournament)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+	if submittedTournament.Format == "" {
+		submittedTournament.Format = models.TournamentFormatSingleElimination
+	}
+	if submittedTournament.SetsToWin == 0 {
+		submittedTournament.SetsToWin = defaultSetsToWin
+	}
+
+	createdTournament = models.Tournament{
+		Name:      submittedTournament.Name,
+		Format:    submittedTournament.Format,
+		Status:    models.TournamentStatusRegistration,
+		SetsToWin: submittedTournament.SetsToWin,
+	}
+	switch submittedTournament.Format {
+	case models.TournamentFormatRoundRobin:
+		createdTournament.HomeAndAway = submittedTournament.HomeAndAway
+	case models.TournamentFormatSwiss:
+		createdTournament.Rounds = submittedTournament.Rounds
+	case models.TournamentFormatGroupsKnockout:
+		createdTournament.GroupSize = submittedTournament.GroupSize
+		if createdTournament.GroupSize == 0 {
+			createdTournament.GroupSize = defaultGroupSize
+		}
+		createdTournament.QualifiersPerGroup = submittedTournament.QualifiersPerGroup
+		if createdTournament.QualifiersPerGroup == 0 {
+			createdTournament.QualifiersPerGroup = defaultQualifiersPerGroup
+		}
+	}
+
+	validateError, dbError = databaseConnection.ValidateAndCreate(&createdTournament)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to create tournament: %s", dbError), http.StatusInternalServerError)
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return errorResponse(fmt.Sprintf("Bad request: %s", validateError), http.StatusBadRequest)
+	}
+
+	createdTournamentInJSON, marshalError = json.Marshal(createdTournament)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify tournament: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(createdTournamentInJSON),
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/tournaments"
+	"net/http"
+	"strings"
+)
+
+// tournamentStandings represents standings of a tournament.
+//
+// Group-stage-plus-knockout tournaments are ranked through tables of their groups instead.
+//
+type tournamentStandings struct {
+	Tournament models.Tournament            `json:"tournament"`
+	Standings  []tournaments.Standing       `json:"standings"`
+	Groups     []tournaments.GroupStandings `json:"groups,omitempty"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve tournament id from API request path
+//     - check that tournament format is ranked through standings (or group tables)
+//     - compute standings (or group tables) from finished matches of tournament and their scores
+//     - send HTTP JSON response containing tournament standings
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var requestedTournamentID uuid.UUID
+	var requestedStandings = tournamentStandings{}
+	var standingsInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	requestedTournamentID, requestError = uuid.FromString(request.PathParameters["id"])
+	if requestError != nil {
+		return errorResponse("Bad request: you must provide a valid tournament id in path", http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.Find(&requestedStandings.Tournament, requestedTournamentID)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Tournament '%s' not found", requestedTournamentID), http.StatusNotFound)
+	}
+
+	switch {
+	case requestedStandings.Tournament.Format == models.TournamentFormatGroupsKnockout:
+		requestedStandings.Groups, dbError = tournaments.FetchGroupStandings(databaseConnection, requestedStandings.Tournament)
+	case tournaments.HasStandings(requestedStandings.Tournament.Format):
+		requestedStandings.Standings, dbError = tournaments.FetchStandings(databaseConnection, requestedStandings.Tournament)
+	default:
+		return errorResponse(fmt.Sprintf("Bad request: %s tournaments have no standings", requestedStandings.Tournament.Format), http.StatusBadRequest)
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve standings of tournament '%s': %s", requestedTournamentID, dbError), http.StatusInternalServerError)
+	}
+
+	standingsInJSON, marshalError = json.Marshal(requestedStandings)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify tournament standings: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(standingsInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/tournaments"
+	"net/http"
+	"strings"
+)
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve tournament id from API request path
+//     - check that tournament is a running Swiss-system tournament whose matches are all finished
+//     - pair participants according to current standings, avoiding rematches, and generate matches of next round
+//     - send HTTP JSON response containing generated round
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var requestedTournamentID uuid.UUID
+	var requestedTournament models.Tournament
+	var tournamentMatches []models.Match
+	var roundInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	requestedTournamentID, requestError = uuid.FromString(request.PathParameters["id"])
+	if requestError != nil {
+		return errorResponse("Bad request: you must provide a valid tournament id in path", http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.Find(&requestedTournament, requestedTournamentID)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Tournament '%s' not found", requestedTournamentID), http.StatusNotFound)
+	}
+	if requestedTournament.Format != models.TournamentFormatSwiss {
+		return errorResponse(fmt.Sprintf("Bad request: rounds of %s tournaments cannot be generated", requestedTournament.Format), http.StatusBadRequest)
+	}
+	if requestedTournament.Status != models.TournamentStatusRunning {
+		return errorResponse(fmt.Sprintf("Bad request: tournament '%s' is %s", requestedTournamentID, requestedTournament.Status), http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		tournamentMatches, transactionError = tournaments.NextRound(tx, &requestedTournament)
+		return transactionError
+	})
+	if dbError == tournaments.ErrRoundInProgress || dbError == tournaments.ErrNoRoundLeft {
+		return errorResponse(fmt.Sprintf("Bad request: %s", dbError), http.StatusBadRequest)
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to generate next round: %s", dbError), http.StatusInternalServerError)
+	}
+
+	roundInJSON, marshalError = json.Marshal(tournaments.BuildBracket(requestedTournament, tournamentMatches, nil))
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify round: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(roundInJSON),
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"strings"
+)
+
+// participant represents participant information submitted to API.
+//
+// Seed is optional: unseeded participants are seeded according to their set balance when tournament starts.
+//
+type participant struct {
+	UserId string `json:"user_id"`
+	Seed   int    `json:"seed"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve tournament id from API request path and participant information from JSON body
+//     - check that tournament is still opened to registrations
+//     - register participant, checking user is not already registered
+//     - send HTTP JSON response containing registered participant
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var validateError *validate.Errors
+	var requestedTournamentID uuid.UUID
+	var requestedTournament models.Tournament
+	var submittedParticipant = participant{}
+	var registeredParticipant models.TournamentParticipant
+	var registeredParticipantInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	requestedTournamentID, requestError = uuid.FromString(request.PathParameters["id"])
+	if requestError != nil {
+		return errorResponse("Bad request: you must provide a valid tournament id in path", http.StatusBadRequest)
+	}
+
+	requestError = json.Unmarshal([]byte(request.Body), &submittedParticipant)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.Find(&requestedTournament, requestedTournamentID)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Tournament '%s' not found", requestedTournamentID), http.StatusNotFound)
+	}
+	if requestedTournament.Status != models.TournamentStatusRegistration {
+		return errorResponse(fmt.Sprintf("Bad request: tournament '%s' is %s", requestedTournamentID, requestedTournament.Status), http.StatusBadRequest)
+	}
+
+	registeredParticipant = models.TournamentParticipant{
+		TournamentID: requestedTournamentID,
+		UserId:       submittedParticipant.UserId,
+		Seed:         submittedParticipant.Seed,
+	}
+
+	validateError, dbError = databaseConnection.ValidateAndCreate(&registeredParticipant)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to register participant: %s", dbError), http.StatusInternalServerError)
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return errorResponse(fmt.Sprintf("Bad request: %s", validateError), http.StatusBadRequest)
+	}
+
+	registeredParticipantInJSON, marshalError = json.Marshal(registeredParticipant)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify participant: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(registeredParticipantInJSON),
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
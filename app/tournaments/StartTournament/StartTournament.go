@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/tournaments"
+	"net/http"
+	"strings"
+)
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve tournament id from API request path
+//     - check that tournament is opened to registrations and has enough participants
+//     - seed participants and generate all matches of tournament
+//     - send HTTP JSON response containing tournament bracket
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var requestedTournamentID uuid.UUID
+	var requestedTournament models.Tournament
+	var tournamentMatches []models.Match
+	var bracketInJSON []byte
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	requestedTournamentID, requestError = uuid.FromString(request.PathParameters["id"])
+	if requestError != nil {
+		return errorResponse("Bad request: you must provide a valid tournament id in path", http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.Find(&requestedTournament, requestedTournamentID)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Tournament '%s' not found", requestedTournamentID), http.StatusNotFound)
+	}
+	if requestedTournament.Status != models.TournamentStatusRegistration {
+		return errorResponse(fmt.Sprintf("Bad request: tournament '%s' is %s", requestedTournamentID, requestedTournament.Status), http.StatusBadRequest)
+	}
+
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		tournamentMatches, transactionError = tournaments.Start(tx, &requestedTournament)
+		return transactionError
+	})
+	if dbError == tournaments.ErrTooManyRounds {
+		return errorResponse(fmt.Sprintf("Bad request: %s", dbError), http.StatusBadRequest)
+	}
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to start tournament: %s", dbError), http.StatusInternalServerError)
+	}
+
+	bracketInJSON, marshalError = json.Marshal(tournaments.BuildBracket(requestedTournament, tournamentMatches, nil))
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify bracket: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(bracketInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/webhooks"
+	"time"
+)
+
+// handler is the main function launched by Lambda, on a schedule rather than on API requests.
+//
+// In this Lambda, it will:
+//     - retrieve pending deliveries whose next attempt is due
+//     - send their payloads to webhooks, scheduling next attempt of failed deliveries
+//
+func handler() (handlerError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var dbError error
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return fmt.Errorf("failed to connect to database: %s", dbError)
+	}
+	defer databaseConnection.Close()
+
+	dbError = webhooks.DeliverPending(databaseConnection, webhooks.NewClient(), time.Now())
+	if dbError != nil {
+		return fmt.Errorf("failed to deliver webhooks: %s", dbError)
+	}
+	return nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
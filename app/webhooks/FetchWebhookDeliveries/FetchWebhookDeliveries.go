@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/webhooks"
+	"net/http"
+	"strings"
+)
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve webhook id from API request path, and optional delivery status from query string
+//     - retrieve deliveries of webhook, from newest to oldest
+//     - send HTTP JSON response containing delivery log of webhook
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var requestedWebhookID uuid.UUID
+	var requestedWebhook models.Webhook
+	var deliveries []models.WebhookDelivery
+	var deliveriesInJSON []byte
+
+	requestedWebhookID, requestError = uuid.FromString(request.PathParameters["id"])
+	if requestError != nil {
+		return errorResponse("Bad request: you must provide a valid webhook id in path", http.StatusBadRequest)
+	}
+	requestedStatus := request.QueryStringParameters["status"]
+	if requestedStatus != "" && requestedStatus != models.DeliveryStatusPending && requestedStatus != models.DeliveryStatusDelivered && requestedStatus != models.DeliveryStatusFailed {
+		return errorResponse("Bad request: status must be one of 'pending', 'delivered' or 'failed'", http.StatusBadRequest)
+	}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	dbError = databaseConnection.Find(&requestedWebhook, requestedWebhookID)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Webhook '%s' not found", requestedWebhookID), http.StatusNotFound)
+	}
+
+	deliveries, dbError = webhooks.FetchDeliveries(databaseConnection, requestedWebhook.ID, requestedStatus)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to retrieve deliveries: %s", dbError), http.StatusInternalServerError)
+	}
+
+	deliveriesInJSON, marshalError = json.Marshal(deliveries)
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify deliveries: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(deliveriesInJSON),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
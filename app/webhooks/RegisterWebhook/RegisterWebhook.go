@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/webhooks"
+	"net/http"
+	"strings"
+)
+
+// webhookRegistration represents webhook information submitted to API.
+//
+type webhookRegistration struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// registeredWebhook represents webhook sent back to API, its secret being only sent at registration.
+//
+type registeredWebhook struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+// errorResponse formats API HTTP responses sent when an error occurs.
+//
+func errorResponse(errorMessage string, errorStatusCode int) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	errorMessage = strings.ReplaceAll(errorMessage, "\"", "\\\"")
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       fmt.Sprintf("{\"error\": \"%s\"}", errorMessage),
+		StatusCode: errorStatusCode,
+	}, nil
+}
+
+// handler is the main function launched by Lambda.
+//
+// In this Lambda, it will:
+//     - retrieve webhook information from JSON body
+//     - generate secret used to sign payloads sent to webhook
+//     - store webhook, subscribing it to all events if none is submitted
+//     - send HTTP JSON response containing webhook and its secret
+//
+func handler(request events.APIGatewayProxyRequest) (APIResponse events.APIGatewayProxyResponse, APIError error) {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var requestError, dbError, marshalError error
+	var validateError *validate.Errors
+	var submittedWebhook = webhookRegistration{}
+	var webhookInJSON []byte
+
+	requestError = json.Unmarshal([]byte(request.Body), &submittedWebhook)
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Bad request body: %s", requestError), http.StatusBadRequest)
+	}
+	if len(submittedWebhook.Events) == 0 {
+		submittedWebhook.Events = models.WebhookEvents[:]
+	}
+
+	secret, requestError := webhooks.GenerateSecret()
+	if requestError != nil {
+		return errorResponse(fmt.Sprintf("Failed to generate webhook secret: %s", requestError), http.StatusInternalServerError)
+	}
+	webhook := models.Webhook{URL: submittedWebhook.URL, Secret: secret, Events: strings.Join(submittedWebhook.Events, ",")}
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to connect to database: %s", dbError), http.StatusInternalServerError)
+	}
+	defer databaseConnection.Close()
+
+	validateError, dbError = databaseConnection.ValidateAndCreate(&webhook)
+	if dbError != nil {
+		return errorResponse(fmt.Sprintf("Failed to create webhook: %s", dbError), http.StatusInternalServerError)
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return errorResponse(fmt.Sprintf("Bad request: %s", validateError), http.StatusBadRequest)
+	}
+
+	webhookInJSON, marshalError = json.Marshal(registeredWebhook{Webhook: webhook, Secret: webhook.Secret})
+	if marshalError != nil {
+		return errorResponse(fmt.Sprintf("Failed to JSONify webhook: %s", marshalError), http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(webhookInJSON),
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// Main launches Lambda function.
+//
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/scores"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Main imports historical results from a CSV or JSON file, for files too large to be sent to API.
+//
+// It will:
+//     - read historical goals and finished sets from submitted file (format deduced from its extension, unless submitted)
+//     - check results against foosball rules, then replay them in chronological order into scores
+//     - roll back import when it is a dry run or when conflicts are found
+//     - print import report (with conflicts found) as JSON, exiting with an error status when results were not imported
+//
+func main() {
+	filePath := flag.String("file", "", "CSV or JSON file of historical results")
+	format := flag.String("format", "", "format of file (csv or json), deduced from file extension by default")
+	dryRun := flag.Bool("dry-run", false, "report conflicts without importing results")
+	flag.Parse()
+
+	if *filePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*filePath)), ".")
+	}
+
+	file, fileError := os.Open(*filePath)
+	if fileError != nil {
+		log.Fatalf("Failed to open file: %s", fileError)
+	}
+	defer file.Close()
+	results, fileError := scores.ParseResults(file, *format)
+	if fileError != nil {
+		log.Fatalf("Failed to read results: %s", fileError)
+	}
+
+	databaseConnection, dbError := db.DatabaseConnector{}.GetConnection()
+	if dbError != nil {
+		log.Fatalf("Failed to connect to database: %s", dbError)
+	}
+	defer databaseConnection.Close()
+
+	report, dbError := scores.ImportResults(databaseConnection, results, *dryRun, time.Now())
+	if dbError != nil {
+		log.Fatalf("Failed to import results: %s", dbError)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if !*dryRun && !report.Imported {
+		log.Fatalf("Results were not imported: %d conflict(s) found", len(report.Conflicts))
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+	"github.com/vlarrat-theodo/lbc-foosball/db"
+	"github.com/vlarrat-theodo/lbc-foosball/export"
+	"github.com/vlarrat-theodo/lbc-foosball/graph"
+	"github.com/vlarrat-theodo/lbc-foosball/logging"
+	"github.com/vlarrat-theodo/lbc-foosball/metrics"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/scoreboard"
+	"github.com/vlarrat-theodo/lbc-foosball/scores"
+	"github.com/vlarrat-theodo/lbc-foosball/stream"
+	"github.com/vlarrat-theodo/lbc-foosball/tracing"
+	"github.com/vlarrat-theodo/lbc-foosball/webhooks"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// defaultAddress is the address server listens on, unless configured otherwise.
+const defaultAddress = ":8080"
+
+// Delays before reconnecting to database when listening connection is lost, and between checks of this connection.
+const (
+	minReconnectInterval = 10 * time.Second
+	maxReconnectInterval = time.Minute
+	pingInterval         = 90 * time.Second
+)
+
+// listen dispatches events notified by database to streams, until listener is closed.
+//
+// Events notified while connection was lost cannot be dispatched: reconnecting streams then get current score.
+//
+func listen(listener *pq.Listener, hub *stream.Hub) {
+	for {
+		select {
+		case notification, open := <-listener.Notify:
+			if !open {
+				return
+			}
+			if notification == nil {
+				log.Println("Reconnected to database, events may have been missed")
+				continue
+			}
+			if dispatchError := hub.Dispatch([]byte(notification.Extra)); dispatchError != nil {
+				log.Printf("Failed to dispatch event: %s", dispatchError)
+			}
+		case <-time.After(pingInterval):
+			if pingError := listener.Ping(); pingError != nil {
+				log.Printf("Failed to ping database: %s", pingError)
+			}
+		}
+	}
+}
+
+// Main launches standalone HTTP server streaming live scores and exports, as Lambda functions cannot keep connections
+// open (nor stream their responses).
+//
+// It will:
+//     - listen to events notified by database when goals are stored, sets are finished or matches are finished
+//     - serve "/stream/score" endpoint, streaming score between two users as Server-Sent Events
+//     - serve "/ws/match" endpoint, connecting scoreboards of a match through WebSockets (goals being submitted on it)
+//     - serve "/export" endpoint, streaming all scores or goals as CSV or NDJSON
+//     - serve "/metrics" endpoint, exposing metrics of goals stored through scoreboards and of handlers to Prometheus
+//     - serve "/graphql" endpoint, executing GraphQL queries and mutations, and live score subscriptions through WebSockets
+//     - trace goals stored through scoreboards or GraphQL in spans, exported when configured
+//
+func main() {
+	var databaseConnection *pop.Connection
+	var databaseConnector = db.DatabaseConnector{}
+	var dbError error
+
+	tracingError := tracing.Init("foosball-server")
+	if tracingError != nil {
+		log.Fatalf("Failed to configure tracing: %s", tracingError)
+	}
+	defer tracing.Shutdown(context.Background())
+
+	databaseConnection, dbError = databaseConnector.GetConnection()
+	if dbError != nil {
+		log.Fatalf("Failed to connect to database: %s", dbError)
+	}
+	defer databaseConnection.Close()
+
+	listener := pq.NewListener(databaseConnector.GetListenerConnectionString(), minReconnectInterval, maxReconnectInterval, func(event pq.ListenerEventType, listenerError error) {
+		if listenerError != nil {
+			log.Printf("Database listener error: %s", listenerError)
+		}
+	})
+	dbError = listener.Listen(webhooks.NotifyChannel)
+	if dbError != nil {
+		log.Fatalf("Failed to listen to database events: %s", dbError)
+	}
+	defer listener.Close()
+
+	hub := stream.NewHub()
+	go listen(listener, hub)
+
+	http.Handle("/stream/score", stream.Handler{Hub: hub, Snapshot: func(firstUserID string, secondUserID string) (score interface{}, snapshotError error) {
+		pairScore, snapshotError := scores.FetchPairScore(databaseConnection, firstUserID, secondUserID, time.Now())
+		return scores.NormalizeScore(pairScore), snapshotError
+	}})
+
+	http.Handle("/ws/match", scoreboard.Handler{
+		Hub:            hub,
+		AllowedOrigins: scoreboard.AllowedOrigins(),
+		FindMatch: func(matchID uuid.UUID) (scoreMatch models.Match, findError error) {
+			findError = databaseConnection.Find(&scoreMatch, matchID)
+			return scoreMatch, findError
+		},
+		Snapshot: func(scoreMatch models.Match) (score interface{}, snapshotError error) {
+			matchScore, snapshotError := scores.FetchMatchScore(databaseConnection, scoreMatch)
+			return scores.NormalizeScore(matchScore), snapshotError
+		},
+		Submit: func(submittedGoal scores.Goal) (score interface{}, submitError error) {
+			defer metrics.HandlerDuration.ObserveSince(time.Now(), "ScoreboardGoal")
+			goalScore, submitError := scores.StoreGoal(context.Background(), databaseConnection, submittedGoal, time.Now(), logging.New(logging.Fields{"handler": "ScoreboardGoal", "request_id": uuid.Must(uuid.NewV4()).String()}))
+			return scores.NormalizeScore(goalScore), submitError
+		},
+	})
+
+	http.Handle("/export", metrics.Instrument("Export", export.Handler{DatabaseConnection: databaseConnection}))
+	http.Handle("/metrics", metrics.Handler())
+
+	graphHandler, schemaError := graph.NewHandler(&graph.Resolver{DatabaseConnection: databaseConnection, Hub: hub})
+	if schemaError != nil {
+		log.Fatalf("Failed to parse GraphQL schema: %s", schemaError)
+	}
+	http.Handle("/graphql", graphHandler)
+
+	address := os.Getenv("STREAM_ADDRESS")
+	if address == "" {
+		address = defaultAddress
+	}
+	log.Printf("Streaming live scores on %s", address)
+	log.Fatal(http.ListenAndServe(address, nil))
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"fmt"
 	"github.com/gobuffalo/pop"
 	"os"
 )
@@ -32,3 +33,13 @@ func (p DatabaseConnector) GetConnection() (dbConnector *pop.Connection, dbConne
 
 	return dbConnection, dbError
 }
+
+// GetListenerConnectionString returns connection string of database specified in environment variables, used to
+// listen to database notifications outside of pop.
+//
+func (p DatabaseConnector) GetListenerConnectionString() (connectionString string) {
+	return fmt.Sprintf(
+		"host=%s port=%s dbname=%s user=%s password=%s sslmode=%s",
+		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"), os.Getenv("DB_USERNAME"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_SSLMODE"),
+	)
+}
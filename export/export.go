@@ -0,0 +1,315 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/office"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Formats of exports: CSV (with a header line) or newline delimited JSON (one JSON object per line).
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Datasets which can be exported.
+const (
+	DatasetScores = "scores"
+	DatasetGoals  = "goals"
+)
+
+// dateLayout is the layout of dates submitted to export a period.
+const dateLayout = "2006-01-02"
+
+// batchSize is the number of records retrieved at once, so that tables are never loaded entirely in memory.
+const batchSize = 500
+
+// Errors raised when an export cannot be requested.
+var (
+	ErrUnknownFormat  = errors.New("format must be one of 'csv' or 'ndjson'")
+	ErrUnknownDataset = errors.New("dataset must be one of 'scores' or 'goals'")
+)
+
+// Writer writes exported records in a given format.
+//
+type Writer interface {
+	// WriteRecord writes one record, as it would be sent by API.
+	WriteRecord(record interface{}) (writeError error)
+	// Flush sends records written so far.
+	Flush() (flushError error)
+}
+
+// csvWriter writes records as CSV lines, with one column per JSON field of records.
+//
+type csvWriter struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+// ndjsonWriter writes records as JSON objects, one per line.
+//
+type ndjsonWriter struct {
+	output  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// Period represents period of exported records (by creation time), both bounds being optional.
+//
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// Handler serves exports of scores and goals (see ServeHTTP).
+//
+type Handler struct {
+	DatabaseConnection *pop.Connection
+}
+
+// Columns returns columns of a model in CSV exports: its JSON fields, in order of declaration.
+//
+func Columns(model interface{}) (columns []string) {
+	modelType := reflect.TypeOf(model)
+	for index := 0; index < modelType.NumField(); index++ {
+		jsonName := strings.Split(modelType.Field(index).Tag.Get("json"), ",")[0]
+		if jsonName != "" && jsonName != "-" {
+			columns = append(columns, jsonName)
+		}
+	}
+	return columns
+}
+
+// NewWriter creates a writer of submitted format, CSV exports starting with columns of model.
+//
+func NewWriter(format string, output io.Writer, model interface{}) (writer Writer, writerError error) {
+	switch format {
+	case FormatCSV:
+		columns := Columns(model)
+		csvOutput := csv.NewWriter(output)
+		return csvWriter{writer: csvOutput, columns: columns}, csvOutput.Write(columns)
+	case FormatNDJSON:
+		ndjsonOutput := bufio.NewWriter(output)
+		return ndjsonWriter{output: ndjsonOutput, encoder: json.NewEncoder(ndjsonOutput)}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// WriteRecord writes one record as a CSV line, values being formatted as in JSON (strings being unquoted, and null
+// values being left empty).
+//
+func (w csvWriter) WriteRecord(record interface{}) (writeError error) {
+	var fields map[string]json.RawMessage
+
+	recordInJSON, writeError := json.Marshal(record)
+	if writeError != nil {
+		return writeError
+	}
+	writeError = json.Unmarshal(recordInJSON, &fields)
+	if writeError != nil {
+		return writeError
+	}
+
+	line := make([]string, len(w.columns))
+	for index, column := range w.columns {
+		var stringValue string
+		switch rawValue := fields[column]; {
+		case string(rawValue) == "null" || rawValue == nil:
+		case json.Unmarshal(rawValue, &stringValue) == nil:
+			line[index] = stringValue
+		default:
+			line[index] = string(rawValue)
+		}
+	}
+	return w.writer.Write(line)
+}
+
+// Flush sends CSV lines written so far.
+//
+func (w csvWriter) Flush() (flushError error) {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// WriteRecord writes one record as a JSON line.
+//
+func (w ndjsonWriter) WriteRecord(record interface{}) (writeError error) {
+	return w.encoder.Encode(record)
+}
+
+// Flush sends JSON lines written so far.
+//
+func (w ndjsonWriter) Flush() (flushError error) {
+	return w.output.Flush()
+}
+
+// ParsePeriod parses optional first and last days of an export (both included), in office timezone.
+//
+func ParsePeriod(requestedFrom string, requestedTo string, location *time.Location) (period Period, parseError error) {
+	if requestedFrom != "" {
+		period.From, parseError = time.ParseInLocation(dateLayout, requestedFrom, location)
+		if parseError != nil {
+			return period, fmt.Errorf("'from' parameter must be a date formatted as YYYY-MM-DD")
+		}
+	}
+	if requestedTo != "" {
+		period.To, parseError = time.ParseInLocation(dateLayout, requestedTo, location)
+		if parseError != nil {
+			return period, fmt.Errorf("'to' parameter must be a date formatted as YYYY-MM-DD")
+		}
+		period.To = period.To.AddDate(0, 0, 1)
+	}
+	if !period.From.IsZero() && !period.To.IsZero() && !period.From.Before(period.To) {
+		return period, fmt.Errorf("'from' parameter must be before 'to' parameter")
+	}
+	return period, nil
+}
+
+// Export writes all records of dataset created during period, from oldest to newest, flushing them after each batch.
+//
+// Records are retrieved by batches (see batchSize), each batch starting after last record of previous one.
+//
+func Export(tx *pop.Connection, dataset string, period Period, writer Writer) (exportError error) {
+	var lastCreatedAt time.Time
+	var lastID uuid.UUID
+
+	for firstBatch := true; ; firstBatch = false {
+		batchQuery := tx.Order("created_at, id").Limit(batchSize)
+		if !period.From.IsZero() {
+			batchQuery = batchQuery.Where("created_at >= ?", period.From)
+		}
+		if !period.To.IsZero() {
+			batchQuery = batchQuery.Where("created_at < ?", period.To)
+		}
+		if !firstBatch {
+			batchQuery = batchQuery.Where("(created_at, id) > (?, ?)", lastCreatedAt, lastID)
+		}
+
+		var records []interface{}
+		switch dataset {
+		case DatasetScores:
+			var scores []models.Score
+			exportError = batchQuery.All(&scores)
+			for _, score := range scores {
+				records = append(records, score)
+				lastCreatedAt, lastID = score.CreatedAt, score.ID
+			}
+		case DatasetGoals:
+			var goals []models.Goal
+			exportError = batchQuery.All(&goals)
+			for _, goal := range goals {
+				records = append(records, goal)
+				lastCreatedAt, lastID = goal.CreatedAt, goal.ID
+			}
+		default:
+			return ErrUnknownDataset
+		}
+		if exportError != nil {
+			return exportError
+		}
+
+		for _, record := range records {
+			exportError = writer.WriteRecord(record)
+			if exportError != nil {
+				return exportError
+			}
+		}
+		exportError = writer.Flush()
+		if exportError != nil || len(records) < batchSize {
+			return exportError
+		}
+	}
+}
+
+// ServeHTTP streams scores or goals (dataset submitted in query string, scores by default) as CSV or NDJSON.
+//
+// Records can be restricted to those created between "from" and "to" days (both included, in office timezone).
+//
+func (h Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	var model interface{}
+	var contentType string
+
+	if request.Method != http.MethodGet {
+		writeError(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := request.URL.Query()
+	dataset := query.Get("dataset")
+	switch dataset {
+	case "", DatasetScores:
+		dataset, model = DatasetScores, models.Score{}
+	case DatasetGoals:
+		model = models.Goal{}
+	default:
+		writeError(writer, fmt.Sprintf("Bad request: %s", ErrUnknownDataset), http.StatusBadRequest)
+		return
+	}
+	switch query.Get("format") {
+	case FormatCSV:
+		contentType = "text/csv"
+	case FormatNDJSON:
+		contentType = "application/x-ndjson"
+	default:
+		writeError(writer, fmt.Sprintf("Bad request: %s", ErrUnknownFormat), http.StatusBadRequest)
+		return
+	}
+
+	location, locationError := office.Location()
+	if locationError != nil {
+		writeError(writer, fmt.Sprintf("Failed to load office timezone: %s", locationError), http.StatusInternalServerError)
+		return
+	}
+	period, requestError := ParsePeriod(query.Get("from"), query.Get("to"), location)
+	if requestError != nil {
+		writeError(writer, fmt.Sprintf("Bad request: %s", requestError), http.StatusBadRequest)
+		return
+	}
+
+	writer.Header().Set("Content-Type", contentType)
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", dataset, query.Get("format")))
+	exportWriter, exportError := NewWriter(query.Get("format"), flushingWriter{writer}, model)
+	if exportError == nil {
+		exportError = Export(h.DatabaseConnection, dataset, period, exportWriter)
+	}
+	if exportError != nil {
+		// Response has already started: export is cut short, which clients notice as CSV or JSON is left incomplete
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// flushingWriter sends data to client as soon as it is written, instead of buffering the whole response.
+//
+type flushingWriter struct {
+	writer http.ResponseWriter
+}
+
+// Write writes data in response, then sends it to client.
+//
+func (w flushingWriter) Write(data []byte) (written int, writeError error) {
+	written, writeError = w.writer.Write(data)
+	if flusher, streamable := w.writer.(http.Flusher); streamable {
+		flusher.Flush()
+	}
+	return written, writeError
+}
+
+// writeError formats HTTP responses sent when an error occurs, as API does.
+//
+func writeError(writer http.ResponseWriter, errorMessage string, errorStatusCode int) {
+	errorInJSON, _ := json.Marshal(map[string]string{"error": errorMessage})
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(errorStatusCode)
+	writer.Write(errorInJSON)
+}
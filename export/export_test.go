@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestColumns tests Columns function listing JSON fields of models.
+//
+func TestColumns(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	columns := Columns(models.Score{})
+	assertHandler.Equal("id", columns[0], "First column should be ID of score")
+	assertHandler.Contains(columns, "user1_points", "Columns should be JSON fields of score")
+	assertHandler.Contains(columns, "match_id", "Columns should be JSON fields of score")
+	assertHandler.NotContains(columns, "User1Points", "Columns should not be names of Go fields")
+}
+
+// TestCSVWriter tests CSV writer formatting records as their JSON fields.
+//
+func TestCSVWriter(t *testing.T) {
+	assertHandler := assert.New(t)
+	var output bytes.Buffer
+
+	scoreID := uuid.Must(uuid.NewV4())
+	matchID := uuid.Must(uuid.NewV4())
+	createdAt := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	writer, writerError := NewWriter(FormatCSV, &output, models.Score{})
+	assertHandler.Nil(writerError, "Writer should be created")
+
+	assertHandler.Nil(writer.WriteRecord(models.Score{ID: scoreID, CreatedAt: createdAt, User1Id: "user1", User2Id: "user2", User1Points: 3}), "Score should be written")
+	assertHandler.Nil(writer.WriteRecord(models.Score{ID: scoreID, CreatedAt: createdAt, User1Id: "user1", User2Id: "user3", MatchID: nulls.NewUUID(matchID)}), "Score should be written")
+	assertHandler.Nil(writer.Flush(), "Scores should be flushed")
+
+	lines, readError := csv.NewReader(&output).ReadAll()
+	assertHandler.Nil(readError, "Export should be valid CSV")
+	assertHandler.Len(lines, 3, "Export should contain header line and one line per score")
+
+	firstScore, secondScore := make(map[string]string), make(map[string]string)
+	for index, column := range lines[0] {
+		firstScore[column], secondScore[column] = lines[1][index], lines[2][index]
+	}
+	assertHandler.Equal(scoreID.String(), firstScore["id"], "Strings should be unquoted")
+	assertHandler.Equal("2026-10-19T12:30:00Z", firstScore["created_at"], "Times should be formatted as in JSON")
+	assertHandler.Equal("3", firstScore["user1_points"], "Numbers should be formatted as in JSON")
+	assertHandler.Equal("", firstScore["match_id"], "Null values should be left empty")
+	assertHandler.Equal(matchID.String(), secondScore["match_id"], "Valid nullable values should be written")
+}
+
+// TestNDJSONWriter tests NDJSON writer writing one JSON record per line.
+//
+func TestNDJSONWriter(t *testing.T) {
+	assertHandler := assert.New(t)
+	var output bytes.Buffer
+
+	writer, writerError := NewWriter(FormatNDJSON, &output, models.Goal{})
+	assertHandler.Nil(writerError, "Writer should be created")
+	assertHandler.Nil(writer.WriteRecord(models.Goal{ScorerId: "user1", OpponentId: "user2", Player: "p1"}), "Goal should be written")
+	assertHandler.Nil(writer.WriteRecord(models.Goal{ScorerId: "user2", OpponentId: "user1", Player: "p4", Gamelle: true}), "Goal should be written")
+	assertHandler.Equal(0, output.Len(), "Goals should not be sent before flush")
+	assertHandler.Nil(writer.Flush(), "Goals should be flushed")
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	assertHandler.Len(lines, 2, "Export should contain one line per goal")
+	var secondGoal map[string]interface{}
+	assertHandler.Nil(json.Unmarshal([]byte(lines[1]), &secondGoal), "Each line should be a JSON object")
+	assertHandler.Equal("p4", secondGoal["player"], "Goal should be written with its JSON fields")
+	assertHandler.Equal(true, secondGoal["gamelle"], "Goal should be written with its JSON fields")
+}
+
+// TestNewWriterUnknownFormat tests NewWriter function refusing unknown formats.
+//
+func TestNewWriterUnknownFormat(t *testing.T) {
+	_, writerError := NewWriter("xlsx", &bytes.Buffer{}, models.Score{})
+	assert.Equal(t, ErrUnknownFormat, writerError, "Unknown format should be refused")
+}
+
+// TestParsePeriod tests ParsePeriod function parsing days of an export in office timezone.
+//
+func TestParsePeriod(t *testing.T) {
+	assertHandler := assert.New(t)
+	paris, _ := time.LoadLocation("Europe/Paris")
+
+	period, parseError := ParsePeriod("", "", paris)
+	assertHandler.Nil(parseError, "Period should be optional")
+	assertHandler.True(period.From.IsZero() && period.To.IsZero(), "Period without days should not restrict export")
+
+	period, parseError = ParsePeriod("2026-10-01", "2026-10-19", paris)
+	assertHandler.Nil(parseError, "Period should be parsed")
+	assertHandler.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, paris), period.From, "Period should start at beginning of first day")
+	assertHandler.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, paris), period.To, "Last day should be included")
+
+	period, parseError = ParsePeriod("2026-10-19", "2026-10-19", paris)
+	assertHandler.Nil(parseError, "Period of a single day should be parsed")
+	assertHandler.Equal(24*time.Hour, period.To.Sub(period.From), "Period should last one day")
+
+	_, parseError = ParsePeriod("19/10/2026", "", paris)
+	assertHandler.NotNil(parseError, "Invalid first day should be refused")
+	_, parseError = ParsePeriod("", "tomorrow", paris)
+	assertHandler.NotNil(parseError, "Invalid last day should be refused")
+	_, parseError = ParsePeriod("2026-10-19", "2026-10-01", paris)
+	assertHandler.NotNil(parseError, "Period ending before its start should be refused")
+}
+
+// TestServeHTTPBadRequests tests ServeHTTP function refusing invalid exports before querying database.
+//
+func TestServeHTTPBadRequests(t *testing.T) {
+	assertHandler := assert.New(t)
+	handler := Handler{}
+
+	for _, target := range []string{
+		"/export",
+		"/export?format=xlsx",
+		"/export?format=csv&dataset=users",
+		"/export?format=csv&from=yesterday",
+	} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		assertHandler.Equal(http.StatusBadRequest, recorder.Code, "Invalid export should be refused: %s", target)
+		assertHandler.Contains(recorder.Body.String(), "Bad request", "Error should be explained: %s", target)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/export?format=csv", nil))
+	assertHandler.Equal(http.StatusMethodNotAllowed, recorder.Code, "Only GET method should be allowed")
+}
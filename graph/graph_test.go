@@ -0,0 +1,257 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/stream"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingFetcher returns data of users user1 and user2, counting batches it is asked for.
+//
+type countingFetcher struct {
+	mutex       sync.Mutex
+	scoreID     uuid.UUID
+	scoreCalls  [][]string
+	streakCalls int
+	goalCalls   int
+	goalLimits  []int
+}
+
+// fetchUserScores returns two scores of user1, recording users it is asked for.
+//
+func (f *countingFetcher) fetchUserScores(userIDs []string, seasonID string) (userScores []models.Score, fetchError error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	sortedUserIDs := append([]string{}, userIDs...)
+	sort.Strings(sortedUserIDs)
+	f.scoreCalls = append(f.scoreCalls, sortedUserIDs)
+	return []models.Score{
+		{ID: f.scoreID, User1Id: "user1", User2Id: "user2", User1Sets: 3, User2Sets: 1, User1Points: 4},
+		{ID: uuid.Must(uuid.NewV4()), User1Id: "user3", User2Id: "user1", User1Sets: 2, User2Sets: 5},
+	}, nil
+}
+
+// fetchStreaks returns streaks of user1.
+//
+func (f *countingFetcher) fetchStreaks(userIDs []string) (streaks []models.Streak, fetchError error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.streakCalls++
+	return []models.Streak{{UserId: "user1", BestWins: 4}}, nil
+}
+
+// fetchScoreGoals returns goals of first score of user1, most recent first, recording limit it is asked for.
+//
+func (f *countingFetcher) fetchScoreGoals(scoreIDs []string, limit int) (scoreGoals []models.Goal, fetchError error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.goalCalls++
+	f.goalLimits = append(f.goalLimits, limit)
+	return []models.Goal{
+		{ScoreID: f.scoreID, Kind: models.GoalKindGamelle, CreatedAt: time.Now()},
+		{ScoreID: f.scoreID, Kind: models.GoalKindClassic, CreatedAt: time.Now().Add(-time.Minute)},
+	}, nil
+}
+
+// TestNewHandler tests resolvers matching schema.
+//
+func TestNewHandler(t *testing.T) {
+	_, schemaError := NewHandler(&Resolver{})
+	assert.Nil(t, schemaError, "Resolvers should match schema")
+}
+
+// TestQueryBatching tests scores, streaks and goals of several users being retrieved at once.
+//
+func TestQueryBatching(t *testing.T) {
+	assertHandler := assert.New(t)
+	dataFetcher := &countingFetcher{scoreID: uuid.Must(uuid.NewV4())}
+	handler, _ := newHandler(&Resolver{}, dataFetcher)
+
+	query := `{
+		first: user(id: "user1") { balance { won lost streaks { bestWins } } scores { id goals(first: 1) { kind } } }
+		second: user(id: "user2") { balance { won lost streaks { bestWins } } scores { id } }
+	}`
+	body, _ := json.Marshal(Request{Query: query})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+
+	var response struct {
+		Data map[string]struct {
+			Balance struct {
+				Won     int
+				Lost    int
+				Streaks struct{ BestWins int }
+			}
+			Scores []struct {
+				ID    string
+				Goals []struct{ Kind string }
+			}
+		}
+		Errors []interface{}
+	}
+	assertHandler.Equal(http.StatusOK, recorder.Code, "Query should be executed")
+	assertHandler.Nil(json.Unmarshal(recorder.Body.Bytes(), &response), "Response should be JSON")
+	assertHandler.Empty(response.Errors, "Query should be executed without errors")
+	assertHandler.Equal(8, response.Data["first"].Balance.Won, "Sets won in all scores of user should be summed")
+	assertHandler.Equal(3, response.Data["first"].Balance.Lost, "Sets lost in all scores of user should be summed")
+	assertHandler.Equal(4, response.Data["first"].Balance.Streaks.BestWins, "Streaks of user should be returned")
+	assertHandler.Equal(0, response.Data["second"].Balance.Streaks.BestWins, "Empty streaks should be returned for users without streaks")
+	assertHandler.Len(response.Data["second"].Scores, 1, "Only scores of user should be returned")
+	assertHandler.Equal([]struct{ Kind string }{{Kind: models.GoalKindGamelle}}, response.Data["first"].Scores[0].Goals, "Most recent goals should be returned first")
+
+	assertHandler.Equal([][]string{{"user1", "user2"}}, dataFetcher.scoreCalls, "Scores of all users should be retrieved at once")
+	assertHandler.Equal(1, dataFetcher.streakCalls, "Streaks of all users should be retrieved at once")
+	assertHandler.Equal(1, dataFetcher.goalCalls, "Goals of all scores should be retrieved at once")
+	assertHandler.Equal([]int{1}, dataFetcher.goalLimits, "Only requested number of goals should be retrieved per score")
+}
+
+// TestSeasonBalance tests all-time streaks being left out of balance of a season.
+//
+func TestSeasonBalance(t *testing.T) {
+	assertHandler := assert.New(t)
+	dataFetcher := &countingFetcher{scoreID: uuid.Must(uuid.NewV4())}
+	handler, _ := newHandler(&Resolver{}, dataFetcher)
+
+	body, _ := json.Marshal(Request{Query: `{ user(id: "user1") { balance(seasonId: "` + uuid.Must(uuid.NewV4()).String() + `") { won streaks { bestWins } } } }`})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+
+	assertHandler.Equal(http.StatusOK, recorder.Code, "Query should be executed")
+	assertHandler.Contains(recorder.Body.String(), `"streaks":null`, "Streaks should be left out of balance of a season")
+	assertHandler.NotContains(recorder.Body.String(), `"errors"`, "Query should be executed without errors")
+	assertHandler.Equal(0, dataFetcher.streakCalls, "Streaks should not be retrieved for balance of a season")
+}
+
+// TestBadRequests tests requests which cannot be executed.
+//
+func TestBadRequests(t *testing.T) {
+	assertHandler := assert.New(t)
+	handler, _ := newHandler(&Resolver{}, &countingFetcher{})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/graphql", nil))
+	assertHandler.Equal(http.StatusMethodNotAllowed, recorder.Code, "Only POST requests should be executed")
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("{")))
+	assertHandler.Equal(http.StatusBadRequest, recorder.Code, "Invalid bodies should be refused")
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ unknown }"}`)))
+	assertHandler.Equal(http.StatusOK, recorder.Code, "Invalid queries should be answered with GraphQL errors")
+	assertHandler.Contains(recorder.Body.String(), `"errors"`, "Invalid queries should be answered with GraphQL errors")
+}
+
+// TestQueryLimits tests queries too deep or too long being refused before being resolved.
+//
+func TestQueryLimits(t *testing.T) {
+	assertHandler := assert.New(t)
+	dataFetcher := &countingFetcher{scoreID: uuid.Must(uuid.NewV4())}
+	handler, _ := newHandler(&Resolver{}, dataFetcher)
+
+	deepQuery := `{ user(id: "user1") { scores { user1 { scores { user2 { scores { user1 { scores { user2 { id } } } } } } } } } }`
+	body, _ := json.Marshal(Request{Query: deepQuery})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+	assertHandler.Equal(http.StatusOK, recorder.Code, "Too deep query should be answered with GraphQL errors")
+	assertHandler.Contains(recorder.Body.String(), `"errors"`, "Too deep query should be refused")
+	assertHandler.NotContains(recorder.Body.String(), `"data"`, "Too deep query should not be resolved")
+
+	longQuery := `{ user(id: "user1") { id ` + strings.Repeat("id ", maxQueryLength/3) + `} }`
+	body, _ = json.Marshal(Request{Query: longQuery})
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+	assertHandler.Equal(http.StatusBadRequest, recorder.Code, "Too long query should be refused")
+
+	assertHandler.Empty(dataFetcher.scoreCalls, "Refused queries should not retrieve any data")
+}
+
+// readMessage reads next message sent on a subscriptions connection.
+//
+func readMessage(t *testing.T, connection *websocket.Conn) (message Message) {
+	connection.SetReadDeadline(time.Now().Add(5 * time.Second))
+	assert.Nil(t, connection.ReadJSON(&message), "A message should be received")
+	return message
+}
+
+// TestLiveScore tests liveScore subscription sending score between users after each goal.
+//
+func TestLiveScore(t *testing.T) {
+	assertHandler := assert.New(t)
+	hub := stream.NewHub()
+	handler, _ := newHandler(&Resolver{Hub: hub}, &countingFetcher{})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{Subprotocol}}
+	connection, _, dialError := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/graphql", nil)
+	if !assertHandler.Nil(dialError, "Connection should be upgraded") {
+		return
+	}
+	defer connection.Close()
+
+	connection.WriteJSON(Message{Type: MessageConnectionInit})
+	assertHandler.Equal(MessageConnectionAck, readMessage(t, connection).Type, "Connection should be acknowledged")
+	connection.WriteJSON(Message{Type: MessagePing})
+	assertHandler.Equal(MessagePong, readMessage(t, connection).Type, "Pings should be answered")
+
+	// Subscriber gets goals it missed, as a reconnecting client would
+	skipped := hub.Publish(stream.EventScore, stream.PairKey("user1", "user2"), json.RawMessage(`{"user1": {"sets": 0, "points": 1}, "user2": {"sets": 0, "points": 0}, "goals_in_balance": 0}`))
+	hub.Publish(stream.EventSet, stream.PairKey("user1", "user2"), json.RawMessage(`{}`))
+	published := hub.Publish(stream.EventScore, stream.PairKey("user2", "user1"), json.RawMessage(`{"user1": {"sets": 1, "points": 0}, "user2": {"sets": 0, "points": 3, "handicap_points": 2, "goal_multiplier": 2}, "goals_in_balance": 1}`))
+
+	payload, _ := json.Marshal(Request{
+		Query:     `subscription ($lastEventId: String) { liveScore(user1: "user2", user2: "user1", lastEventId: $lastEventId) { eventId goalsInBalance players { user { id } points sets handicapPoints goalMultiplier } } }`,
+		Variables: map[string]interface{}{"lastEventId": strconv.FormatUint(skipped.ID, 10)},
+	})
+	connection.WriteJSON(Message{ID: "live", Type: MessageSubscribe, Payload: payload})
+
+	next := readMessage(t, connection)
+	assertHandler.Equal(MessageNext, next.Type, "Score should be sent")
+	assertHandler.Equal("live", next.ID, "Score should be sent for subscription")
+	assertHandler.JSONEq(`{"data": {"liveScore": {"eventId": "`+strconv.FormatUint(published.ID, 10)+`", "goalsInBalance": 1, "players": [
+		{"user": {"id": "user2"}, "points": 3, "sets": 0, "handicapPoints": 2, "goalMultiplier": 2},
+		{"user": {"id": "user1"}, "points": 0, "sets": 1, "handicapPoints": 0, "goalMultiplier": 1}
+	]}}}`, string(next.Payload), "Score should be sent with players in order of subscription, other events being skipped")
+
+	connection.WriteJSON(Message{ID: "live", Type: MessageComplete})
+	payload, _ = json.Marshal(Request{Query: `{ user(id: "user1") { id } }`})
+	connection.WriteJSON(Message{ID: "query", Type: MessageSubscribe, Payload: payload})
+	next = readMessage(t, connection)
+	assertHandler.Equal("query", next.ID, "Completed subscriptions should not send scores anymore")
+	assertHandler.JSONEq(`{"data": {"user": {"id": "user1"}}}`, string(next.Payload), "Queries should be executed on connection")
+	assertHandler.Equal(Message{ID: "query", Type: MessageComplete}, readMessage(t, connection), "Queries should be completed by server")
+}
+
+// TestSubscriptionsProtocol tests connections breaking subscriptions protocol being closed.
+//
+func TestSubscriptionsProtocol(t *testing.T) {
+	handler, _ := newHandler(&Resolver{Hub: stream.NewHub()}, &countingFetcher{})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{Subprotocol}}
+	connection, _, dialError := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/graphql", nil)
+	if !assert.Nil(t, dialError, "Connection should be upgraded") {
+		return
+	}
+	defer connection.Close()
+
+	connection.WriteJSON(Message{ID: "live", Type: MessageSubscribe, Payload: json.RawMessage(`{"query": "{ user(id: \"user1\") { id } }"}`)})
+	connection.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, readError := connection.ReadMessage()
+	assert.True(t, websocket.IsCloseError(readError, closeUnauthorized), "Subscriptions before connection initialisation should close connection")
+}
+
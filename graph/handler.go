@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/vlarrat-theodo/lbc-foosball/metrics"
+	"github.com/vlarrat-theodo/lbc-foosball/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"time"
+)
+
+// maxRequestSize is the maximum size of GraphQL requests.
+const maxRequestSize = 1 << 20
+
+// maxQueryDepth is the maximum nesting of fields in queries, and maxQueryLength their maximum length (checked before
+// parsing them): schema being recursive (users having scores, which have users), deeper queries would multiply batches
+// of database queries.
+const (
+	maxQueryDepth  = 8
+	maxQueryLength = 10000
+)
+
+// requestIDKey is the key of ID of request in context of a request.
+type requestIDKey struct{}
+
+// Request represents a GraphQL request, as sent by clients in body of HTTP requests (and in subscribe messages).
+//
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Handler serves GraphQL requests (see ServeHTTP).
+//
+type Handler struct {
+	schema  *graphql.Schema
+	fetcher fetcher
+}
+
+// NewHandler creates handler of GraphQL requests, resolved by submitted resolver.
+//
+func NewHandler(resolver *Resolver) (handler *Handler, schemaError error) {
+	return newHandler(resolver, databaseFetcher{databaseConnection: resolver.DatabaseConnection})
+}
+
+// newHandler creates handler of GraphQL requests, loaders retrieving data through submitted fetcher.
+//
+// Queries deeper than maxQueryDepth are refused.
+//
+func newHandler(resolver interface{}, dataFetcher fetcher) (handler *Handler, schemaError error) {
+	schema, schemaError := graphql.ParseSchema(Schema, resolver, graphql.MaxDepth(maxQueryDepth))
+	if schemaError != nil {
+		return nil, schemaError
+	}
+	return &Handler{schema: schema, fetcher: dataFetcher}, nil
+}
+
+// requestID returns ID of request being resolved.
+//
+func requestID(ctx context.Context) (id string) {
+	id, _ = ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ServeHTTP executes GraphQL request sent as JSON in body of a POST request, or serves subscriptions of a WebSocket
+// connection (see serveSubscriptions).
+//
+// Each request gets its own loaders (so that scores of all users of a query are retrieved at once), and an ID
+// ("X-Request-Id" header if sent) logged with goals it stores.
+//
+func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Access-Control-Allow-Origin", "*")
+	if websocket.IsWebSocketUpgrade(request) {
+		h.serveSubscriptions(writer, request)
+		return
+	}
+	// Subscriptions are long-lived: only requests executed at once are timed
+	defer metrics.HandlerDuration.ObserveSince(time.Now(), "GraphQL")
+
+	switch request.Method {
+	case http.MethodOptions:
+		writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Request-Id")
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPost:
+	default:
+		writeError(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var graphQLRequest Request
+	decodeError := json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxRequestSize)).Decode(&graphQLRequest)
+	if decodeError != nil {
+		writeError(writer, fmt.Sprintf("Bad request body: %s", decodeError), http.StatusBadRequest)
+		return
+	}
+	if graphQLRequest.Query == "" {
+		writeError(writer, "Bad request: you must provide a query", http.StatusBadRequest)
+		return
+	}
+	if len(graphQLRequest.Query) > maxQueryLength {
+		writeError(writer, fmt.Sprintf("Bad request: query must not exceed %d characters", maxQueryLength), http.StatusBadRequest)
+		return
+	}
+
+	ctx := h.requestContext(request.Context(), request.Header.Get("X-Request-Id"))
+	ctx, span := tracing.Start(ctx, "GraphQL", attribute.String("graphql.operation.name", graphQLRequest.OperationName))
+	response := h.schema.Exec(ctx, graphQLRequest.Query, graphQLRequest.OperationName, graphQLRequest.Variables)
+	span.SetAttributes(attribute.Int("graphql.errors", len(response.Errors)))
+	tracing.End(span, nil)
+
+	responseInJSON, marshalError := json.Marshal(response)
+	if marshalError != nil {
+		writeError(writer, fmt.Sprintf("Failed to JSONify response: %s", marshalError), http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(responseInJSON)
+}
+
+// requestContext returns context of a GraphQL request, with its loaders and its ID (generated when not submitted).
+//
+func (h *Handler) requestContext(ctx context.Context, id string) (requestContext context.Context) {
+	if id == "" {
+		id = uuid.Must(uuid.NewV4()).String()
+	}
+	return withLoaders(context.WithValue(ctx, requestIDKey{}, id), h.fetcher)
+}
+
+// writeError formats HTTP responses sent when a request cannot be executed, as API does.
+//
+func writeError(writer http.ResponseWriter, errorMessage string, errorStatusCode int) {
+	errorInJSON, _ := json.Marshal(map[string]string{"error": errorMessage})
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(errorStatusCode)
+	writer.Write(errorInJSON)
+}
@@ -0,0 +1,241 @@
+package graph
+
+import (
+	"context"
+	"github.com/gobuffalo/pop"
+	"github.com/graph-gophers/dataloader"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"strconv"
+	"strings"
+)
+
+// loadersKey is the key of loaders in context of a request.
+type loadersKey struct{}
+
+// fetcher retrieves data of several users or scores at once, so that loaders avoid one query per user or score.
+//
+type fetcher interface {
+	fetchUserScores(userIDs []string, seasonID string) (userScores []models.Score, fetchError error)
+	fetchStreaks(userIDs []string) (streaks []models.Streak, fetchError error)
+	fetchScoreGoals(scoreIDs []string, limit int) (scoreGoals []models.Goal, fetchError error)
+}
+
+// databaseFetcher retrieves data from database.
+//
+type databaseFetcher struct {
+	databaseConnection *pop.Connection
+}
+
+// loaders batch retrievals of scores and streaks of users, and of goals of scores, requested while a query is resolved.
+//
+// Loaders cache what they retrieved, so that they are created for each request.
+//
+type loaders struct {
+	scoresByUser  *dataloader.Loader
+	streaksByUser *dataloader.Loader
+	goalsByScore  *dataloader.Loader
+}
+
+// withLoaders returns context of a request, with loaders retrieving data through submitted fetcher.
+//
+func withLoaders(ctx context.Context, dataFetcher fetcher) (loadersContext context.Context) {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		scoresByUser:  dataloader.NewBatchedLoader(scoresBatch(dataFetcher)),
+		streaksByUser: dataloader.NewBatchedLoader(streaksBatch(dataFetcher)),
+		goalsByScore:  dataloader.NewBatchedLoader(goalsBatch(dataFetcher)),
+	})
+}
+
+// loadersFrom returns loaders of a request.
+//
+func loadersFrom(ctx context.Context) (requestLoaders *loaders) {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// userScoresKey returns loader key of scores of a user (in submitted season, or in all seasons if empty).
+//
+func userScoresKey(userID string, seasonID string) (key dataloader.Key) {
+	return dataloader.StringKey(userID + "|" + seasonID)
+}
+
+// loadUserScores retrieves scores of a user (in submitted season, or in all seasons if empty).
+//
+func loadUserScores(ctx context.Context, userID string, seasonID string) (userScores []models.Score, loadError error) {
+	loaded, loadError := loadersFrom(ctx).scoresByUser.Load(ctx, userScoresKey(userID, seasonID))()
+	if loadError != nil {
+		return nil, loadError
+	}
+	return loaded.([]models.Score), nil
+}
+
+// loadStreak retrieves streaks of a user (empty streaks when user has not finished any set yet).
+//
+func loadStreak(ctx context.Context, userID string) (userStreak models.Streak, loadError error) {
+	loaded, loadError := loadersFrom(ctx).streaksByUser.Load(ctx, dataloader.StringKey(userID))()
+	if loadError != nil {
+		return userStreak, loadError
+	}
+	return loaded.(models.Streak), nil
+}
+
+// scoreGoalsKey returns loader key of most recent goals of a score (at most submitted limit).
+//
+func scoreGoalsKey(scoreID string, limit int) (key dataloader.Key) {
+	return dataloader.StringKey(scoreID + "|" + strconv.Itoa(limit))
+}
+
+// loadScoreGoals retrieves most recent goals of a score (at most submitted limit), most recent first.
+//
+func loadScoreGoals(ctx context.Context, scoreID string, limit int) (scoreGoals []models.Goal, loadError error) {
+	loaded, loadError := loadersFrom(ctx).goalsByScore.Load(ctx, scoreGoalsKey(scoreID, limit))()
+	if loadError != nil {
+		return nil, loadError
+	}
+	return loaded.([]models.Goal), nil
+}
+
+// scoresBatch returns batch function of scores of users, running one query per requested season.
+//
+func scoresBatch(dataFetcher fetcher) (batch dataloader.BatchFunc) {
+	return func(ctx context.Context, keys dataloader.Keys) (results []*dataloader.Result) {
+		var seasonIDs []string
+		var usersBySeason = make(map[string][]string)
+		var scoresBySeason = make(map[string][]models.Score)
+		var errorsBySeason = make(map[string]error)
+
+		for _, key := range keys {
+			keyParts := strings.SplitN(key.String(), "|", 2)
+			if _, seasonRequested := usersBySeason[keyParts[1]]; !seasonRequested {
+				seasonIDs = append(seasonIDs, keyParts[1])
+			}
+			usersBySeason[keyParts[1]] = append(usersBySeason[keyParts[1]], keyParts[0])
+		}
+		for _, seasonID := range seasonIDs {
+			scoresBySeason[seasonID], errorsBySeason[seasonID] = dataFetcher.fetchUserScores(usersBySeason[seasonID], seasonID)
+		}
+
+		for _, key := range keys {
+			keyParts := strings.SplitN(key.String(), "|", 2)
+			if errorsBySeason[keyParts[1]] != nil {
+				results = append(results, &dataloader.Result{Error: errorsBySeason[keyParts[1]]})
+				continue
+			}
+			userScores := []models.Score{}
+			for _, seasonScore := range scoresBySeason[keyParts[1]] {
+				if seasonScore.User1Id == keyParts[0] || seasonScore.User2Id == keyParts[0] {
+					userScores = append(userScores, seasonScore)
+				}
+			}
+			results = append(results, &dataloader.Result{Data: userScores})
+		}
+		return results
+	}
+}
+
+// streaksBatch returns batch function of streaks of users.
+//
+func streaksBatch(dataFetcher fetcher) (batch dataloader.BatchFunc) {
+	return func(ctx context.Context, keys dataloader.Keys) (results []*dataloader.Result) {
+		streaks, fetchError := dataFetcher.fetchStreaks(keys.Keys())
+
+		streaksByUser := make(map[string]models.Streak)
+		for _, userStreak := range streaks {
+			streaksByUser[userStreak.UserId] = userStreak
+		}
+		for _, key := range keys {
+			if fetchError != nil {
+				results = append(results, &dataloader.Result{Error: fetchError})
+				continue
+			}
+			results = append(results, &dataloader.Result{Data: streaksByUser[key.String()]})
+		}
+		return results
+	}
+}
+
+// goalsBatch returns batch function of goals of scores, running one query per requested limit.
+//
+func goalsBatch(dataFetcher fetcher) (batch dataloader.BatchFunc) {
+	return func(ctx context.Context, keys dataloader.Keys) (results []*dataloader.Result) {
+		var limits []string
+		var scoresByLimit = make(map[string][]string)
+		var goalsByLimit = make(map[string]map[string][]models.Goal)
+		var errorsByLimit = make(map[string]error)
+
+		for _, key := range keys {
+			keyParts := strings.SplitN(key.String(), "|", 2)
+			if _, limitRequested := scoresByLimit[keyParts[1]]; !limitRequested {
+				limits = append(limits, keyParts[1])
+			}
+			scoresByLimit[keyParts[1]] = append(scoresByLimit[keyParts[1]], keyParts[0])
+		}
+		for _, limit := range limits {
+			limitValue, _ := strconv.Atoi(limit)
+			scoreGoals, fetchError := dataFetcher.fetchScoreGoals(scoresByLimit[limit], limitValue)
+			errorsByLimit[limit] = fetchError
+			goalsByLimit[limit] = make(map[string][]models.Goal)
+			for _, scoreGoal := range scoreGoals {
+				scoreID := scoreGoal.ScoreID.String()
+				goalsByLimit[limit][scoreID] = append(goalsByLimit[limit][scoreID], scoreGoal)
+			}
+		}
+
+		for _, key := range keys {
+			keyParts := strings.SplitN(key.String(), "|", 2)
+			if errorsByLimit[keyParts[1]] != nil {
+				results = append(results, &dataloader.Result{Error: errorsByLimit[keyParts[1]]})
+				continue
+			}
+			results = append(results, &dataloader.Result{Data: append([]models.Goal{}, goalsByLimit[keyParts[1]][keyParts[0]]...)})
+		}
+		return results
+	}
+}
+
+// inCondition returns SQL condition matching submitted values in a column, with values as query arguments.
+//
+// Pop only expands a single "IN (?)" clause per condition, while scores of users are searched in both user columns:
+// placeholders are thus written here (a single value being compared with "=", which Pop would expand otherwise).
+//
+func inCondition(column string, values []string) (condition string, args []interface{}) {
+	for _, value := range values {
+		args = append(args, value)
+	}
+	if len(values) == 1 {
+		return column + " = ?", args
+	}
+	return column + " IN (" + strings.TrimSuffix(strings.Repeat("?,", len(values)), ",") + ")", args
+}
+
+// fetchUserScores retrieves scores of users (in submitted season, or in all seasons if empty).
+//
+func (f databaseFetcher) fetchUserScores(userIDs []string, seasonID string) (userScores []models.Score, fetchError error) {
+	user1Condition, args := inCondition("user1_id", userIDs)
+	user2Condition, _ := inCondition("user2_id", userIDs)
+	scoresQuery := f.databaseConnection.Where("("+user1Condition+" OR "+user2Condition+")", append(args, args...)...)
+	if seasonID != "" {
+		scoresQuery = scoresQuery.Where("season_id = ?", seasonID)
+	}
+	fetchError = scoresQuery.Order("updated_at DESC").All(&userScores)
+	return userScores, fetchError
+}
+
+// fetchStreaks retrieves streaks of users.
+//
+func (f databaseFetcher) fetchStreaks(userIDs []string) (streaks []models.Streak, fetchError error) {
+	condition, args := inCondition("user_id", userIDs)
+	fetchError = f.databaseConnection.Where(condition, args...).All(&streaks)
+	return streaks, fetchError
+}
+
+// fetchScoreGoals retrieves most recent goals of scores (at most submitted limit per score), most recent first.
+//
+// Goals are ranked in each score by database, so that only requested goals are retrieved, however long scores are.
+//
+func (f databaseFetcher) fetchScoreGoals(scoreIDs []string, limit int) (scoreGoals []models.Goal, fetchError error) {
+	condition, args := inCondition("score_id", scoreIDs)
+	rankedGoalsQuery := "SELECT id, ROW_NUMBER() OVER (PARTITION BY score_id ORDER BY created_at DESC) AS score_rank FROM goals WHERE " + condition
+	fetchError = f.databaseConnection.Where("id IN (SELECT id FROM ("+rankedGoalsQuery+") AS ranked_goals WHERE score_rank <= ?)", append(args, limit)...).
+		Order("created_at DESC").All(&scoreGoals)
+	return scoreGoals, fetchError
+}
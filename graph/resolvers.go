@@ -0,0 +1,626 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/vlarrat-theodo/lbc-foosball/logging"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/scores"
+	"github.com/vlarrat-theodo/lbc-foosball/stream"
+	"strconv"
+	"time"
+)
+
+// maxGoals is the maximum number of goals returned in a goal history.
+const maxGoals = 200
+
+// Resolver resolves queries, mutations and subscriptions of Schema.
+//
+// Hub is the hub of events of scores, to which live scores are subscribed.
+//
+type Resolver struct {
+	DatabaseConnection *pop.Connection
+	Hub                *stream.Hub
+}
+
+// GoalError is raised when a goal submitted to recordGoal mutation cannot be stored, HTTP status code being added
+// to extensions of GraphQL error (as API would send it).
+//
+type GoalError struct {
+	scores.GoalError
+}
+
+// userRow represents a user ID read in database.
+//
+type userRow struct {
+	ID string `db:"id"`
+}
+
+// goalInput represents goal information submitted to recordGoal mutation.
+//
+type goalInput struct {
+	Scorer   string
+	Opponent string
+	Player   string
+	Gamelle  bool
+	MatchID  *graphql.ID
+}
+
+// userResolver resolves fields of a user.
+//
+type userResolver struct {
+	id string
+}
+
+// scoreResolver resolves fields of a score between two users.
+//
+type scoreResolver struct {
+	score models.Score
+}
+
+// playerScoreResolver resolves fields of score of one user.
+//
+type playerScoreResolver struct {
+	userID         string
+	points         int
+	sets           int
+	handicapPoints int
+	goalMultiplier int
+}
+
+// liveScoreResolver resolves fields of a score sent to subscribers after a goal.
+//
+type liveScoreResolver struct {
+	eventID        uint64
+	players        []*playerScoreResolver
+	goalsInBalance int
+}
+
+// balanceResolver resolves fields of sum of sets won and lost by one user.
+//
+type balanceResolver struct {
+	won    int
+	lost   int
+	streak *models.Streak
+}
+
+// streaksResolver resolves fields of current and record series of sets won and lost in a row by one user.
+//
+type streaksResolver struct {
+	streak models.Streak
+}
+
+// goalResolver resolves fields of a stored goal.
+//
+type goalResolver struct {
+	goal models.Goal
+}
+
+// Extensions returns HTTP status code of error, sent in extensions of GraphQL error.
+//
+func (e GoalError) Extensions() (extensions map[string]interface{}) {
+	return map[string]interface{}{"status": e.StatusCode}
+}
+
+// seasonFilter returns season ID submitted as argument (empty if none).
+//
+func seasonFilter(seasonID *graphql.ID) (filter string) {
+	if seasonID == nil {
+		return ""
+	}
+	return string(*seasonID)
+}
+
+// goalLimit returns number of goals to be returned in a goal history (at least one, and no more than maxGoals).
+//
+func goalLimit(first int32) (limit int) {
+	switch {
+	case first < 1:
+		return 1
+	case first > maxGoals:
+		return maxGoals
+	}
+	return int(first)
+}
+
+// goalHistory returns goals of a goal history, among submitted ones (most recent first).
+//
+func goalHistory(goals []models.Goal, first int32) (history []*goalResolver) {
+	history = []*goalResolver{}
+	for index := 0; index < len(goals) && index < goalLimit(first); index++ {
+		history = append(history, &goalResolver{goal: goals[index]})
+	}
+	return history
+}
+
+// Users returns users having played at least one set (in submitted season, if any), sorted by ID.
+//
+func (r *Resolver) Users(ctx context.Context, args struct{ SeasonID *graphql.ID }) (users []*userResolver, resolveError error) {
+	var userRows []userRow
+
+	usersQuery := "SELECT user1_id AS id FROM scores UNION SELECT user2_id AS id FROM scores ORDER BY id"
+	var queryArgs []interface{}
+	if seasonID := seasonFilter(args.SeasonID); seasonID != "" {
+		usersQuery = "SELECT user1_id AS id FROM scores WHERE season_id = ? UNION SELECT user2_id AS id FROM scores WHERE season_id = ? ORDER BY id"
+		queryArgs = []interface{}{seasonID, seasonID}
+	}
+	resolveError = r.DatabaseConnection.RawQuery(usersQuery, queryArgs...).All(&userRows)
+	if resolveError != nil {
+		return nil, fmt.Errorf("Failed to retrieve users: %s", resolveError)
+	}
+
+	users = []*userResolver{}
+	for _, row := range userRows {
+		users = append(users, &userResolver{id: row.ID})
+	}
+	return users, nil
+}
+
+// User returns submitted user.
+//
+func (r *Resolver) User(args struct{ ID string }) (user *userResolver) {
+	return &userResolver{id: args.ID}
+}
+
+// Score returns usual score between users in active season.
+//
+func (r *Resolver) Score(args struct{ User1, User2 string }) (score *scoreResolver, resolveError error) {
+	if args.User1 == args.User2 {
+		return nil, fmt.Errorf("Bad request: you must provide two different users")
+	}
+	pairScore, resolveError := scores.FetchPairScore(r.DatabaseConnection, args.User1, args.User2, time.Now())
+	if resolveError != nil {
+		return nil, fmt.Errorf("Failed to retrieve score: %s", resolveError)
+	}
+	return &scoreResolver{score: pairScore}, nil
+}
+
+// Balance returns sum of sets won and lost by submitted user (in submitted season, if any), with their all-time streaks
+// when no season is submitted.
+//
+func (r *Resolver) Balance(ctx context.Context, args struct {
+	UserID   string
+	SeasonID *graphql.ID
+}) (balance *balanceResolver, resolveError error) {
+	return (&userResolver{id: args.UserID}).Balance(ctx, struct{ SeasonID *graphql.ID }{args.SeasonID})
+}
+
+// Goals returns goals scored between users (in all seasons and matches), most recent first.
+//
+func (r *Resolver) Goals(args struct {
+	User1 string
+	User2 string
+	First int32
+}) (goals []*goalResolver, resolveError error) {
+	var pairGoals []models.Goal
+
+	resolveError = r.DatabaseConnection.Where("(scorer_id = ? AND opponent_id = ?) OR (scorer_id = ? AND opponent_id = ?)", args.User1, args.User2, args.User2, args.User1).
+		Order("created_at DESC").Limit(goalLimit(args.First)).All(&pairGoals)
+	if resolveError != nil {
+		return nil, fmt.Errorf("Failed to retrieve goals: %s", resolveError)
+	}
+	return goalHistory(pairGoals, args.First), nil
+}
+
+// RecordGoal stores submitted goal exactly as StoreGoal Lambda does, returning updated score.
+//
+// Refused goals raise a GoalError.
+//
+func (r *Resolver) RecordGoal(ctx context.Context, args struct{ Goal goalInput }) (score *scoreResolver, resolveError error) {
+	submittedGoal := scores.Goal{Scorer: args.Goal.Scorer, Opponent: args.Goal.Opponent, Player: args.Goal.Player, Gamelle: args.Goal.Gamelle}
+	if args.Goal.MatchID != nil {
+		submittedGoal.MatchID = string(*args.Goal.MatchID)
+	}
+
+	logger := logging.New(logging.Fields{"handler": "GraphQL", "request_id": requestID(ctx)})
+	goalScore, resolveError := scores.StoreGoal(ctx, r.DatabaseConnection, submittedGoal, time.Now(), logger)
+	if goalError, refusedGoal := resolveError.(scores.GoalError); refusedGoal {
+		return nil, GoalError{goalError}
+	}
+	if resolveError != nil {
+		return nil, fmt.Errorf("Failed to create/update score: %s", resolveError)
+	}
+	return &scoreResolver{score: goalScore}, nil
+}
+
+// LiveScore sends score between users after each goal, until subscription is stopped.
+//
+// A reconnecting subscriber (sending ID of last event it got) first gets events it missed, when they are still known.
+//
+func (r *Resolver) LiveScore(ctx context.Context, args struct {
+	User1       string
+	User2       string
+	LastEventID *string
+}) (liveScores <-chan *liveScoreResolver, resolveError error) {
+	if args.User1 == args.User2 {
+		return nil, fmt.Errorf("Bad request: you must provide two different users")
+	}
+	var lastEventID uint64
+	if args.LastEventID != nil {
+		lastEventID, _ = strconv.ParseUint(*args.LastEventID, 10, 64)
+	}
+
+	pair := stream.PairKey(args.User1, args.User2)
+	events, _, missed, _ := r.Hub.Subscribe(pair, lastEventID)
+	sentScores := make(chan *liveScoreResolver)
+
+	go func() {
+		defer close(sentScores)
+		defer r.Hub.Unsubscribe(pair, events)
+
+		for _, event := range missed {
+			if !sendLiveScore(ctx, sentScores, event, args.User1, args.User2) {
+				return
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, open := <-events:
+				// A subscriber too slow to get its events is stopped: it subscribes again with ID of last event it got
+				if !open || !sendLiveScore(ctx, sentScores, event, args.User1, args.User2) {
+					return
+				}
+			}
+		}
+	}()
+	return sentScores, nil
+}
+
+// sendLiveScore sends score of an event to subscriber (other events being ignored), returning false when subscription
+// is stopped.
+//
+func sendLiveScore(ctx context.Context, sentScores chan<- *liveScoreResolver, event stream.Event, firstUserID string, secondUserID string) (subscribed bool) {
+	if event.Name != stream.EventScore {
+		return true
+	}
+	liveScore, decodeError := decodeLiveScore(event, firstUserID, secondUserID)
+	if decodeError != nil {
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case sentScores <- liveScore:
+		return true
+	}
+}
+
+// decodeLiveScore decodes score of an event (normalized as API sends it, see scores.NormalizeScore), with players in
+// submitted order.
+//
+func decodeLiveScore(event stream.Event, firstUserID string, secondUserID string) (liveScore *liveScoreResolver, decodeError error) {
+	var normalizedScore map[string]json.RawMessage
+	var userScore struct {
+		Sets           int `json:"sets"`
+		Points         int `json:"points"`
+		HandicapPoints int `json:"handicap_points"`
+		GoalMultiplier int `json:"goal_multiplier"`
+	}
+
+	decodeError = json.Unmarshal(event.Data, &normalizedScore)
+	if decodeError != nil {
+		return nil, decodeError
+	}
+
+	liveScore = &liveScoreResolver{eventID: event.ID}
+	if goalsInBalance, balanceExists := normalizedScore["goals_in_balance"]; balanceExists {
+		decodeError = json.Unmarshal(goalsInBalance, &liveScore.goalsInBalance)
+		if decodeError != nil {
+			return nil, decodeError
+		}
+	}
+	for _, userID := range []string{firstUserID, secondUserID} {
+		userScore.Sets, userScore.Points, userScore.HandicapPoints, userScore.GoalMultiplier = 0, 0, 0, 0
+		if rawUserScore, userExists := normalizedScore[userID]; userExists {
+			decodeError = json.Unmarshal(rawUserScore, &userScore)
+			if decodeError != nil {
+				return nil, decodeError
+			}
+		}
+		liveScore.players = append(liveScore.players, &playerScoreResolver{
+			userID:         userID,
+			points:         userScore.Points,
+			sets:           userScore.Sets,
+			handicapPoints: userScore.HandicapPoints,
+			goalMultiplier: userScore.GoalMultiplier,
+		})
+	}
+	return liveScore, nil
+}
+
+// ID returns ID of user.
+//
+func (u *userResolver) ID() (id string) {
+	return u.id
+}
+
+// Scores returns scores of user (in submitted season, if any), most recently updated first.
+//
+func (u *userResolver) Scores(ctx context.Context, args struct{ SeasonID *graphql.ID }) (userScores []*scoreResolver, resolveError error) {
+	loadedScores, resolveError := loadUserScores(ctx, u.id, seasonFilter(args.SeasonID))
+	if resolveError != nil {
+		return nil, fmt.Errorf("Failed to retrieve user's scores for user_id '%s'", u.id)
+	}
+
+	userScores = []*scoreResolver{}
+	for _, loadedScore := range loadedScores {
+		userScores = append(userScores, &scoreResolver{score: loadedScore})
+	}
+	return userScores, nil
+}
+
+// Balance returns sum of sets won and lost by user (in submitted season, if any), with their streaks when no season is
+// submitted (streaks being counted over all seasons).
+//
+func (u *userResolver) Balance(ctx context.Context, args struct{ SeasonID *graphql.ID }) (balance *balanceResolver, resolveError error) {
+	loadedScores, resolveError := loadUserScores(ctx, u.id, seasonFilter(args.SeasonID))
+	if resolveError != nil {
+		return nil, fmt.Errorf("Failed to retrieve user's scores for user_id '%s'", u.id)
+	}
+
+	balance = &balanceResolver{}
+	for _, loadedScore := range loadedScores {
+		switch u.id {
+		case loadedScore.User1Id:
+			balance.won += loadedScore.User1Sets
+			balance.lost += loadedScore.User2Sets
+		case loadedScore.User2Id:
+			balance.won += loadedScore.User2Sets
+			balance.lost += loadedScore.User1Sets
+		}
+	}
+
+	if args.SeasonID != nil {
+		return balance, nil
+	}
+
+	userStreak, resolveError := loadStreak(ctx, u.id)
+	if resolveError != nil {
+		return nil, fmt.Errorf("Failed to retrieve user's streaks for user_id '%s'", u.id)
+	}
+	balance.streak = &userStreak
+	return balance, nil
+}
+
+// ID returns ID of score (nil when users have not played together yet).
+//
+func (s *scoreResolver) ID() (id *graphql.ID) {
+	if s.score.ID == uuid.Nil {
+		return nil
+	}
+	scoreID := graphql.ID(s.score.ID.String())
+	return &scoreID
+}
+
+// User1 returns first user of score.
+//
+func (s *scoreResolver) User1() (user *userResolver) {
+	return &userResolver{id: s.score.User1Id}
+}
+
+// User2 returns second user of score.
+//
+func (s *scoreResolver) User2() (user *userResolver) {
+	return &userResolver{id: s.score.User2Id}
+}
+
+// Players returns score of each user, first user first.
+//
+func (s *scoreResolver) Players() (players []*playerScoreResolver) {
+	for _, userID := range []string{s.score.User1Id, s.score.User2Id} {
+		players = append(players, &playerScoreResolver{
+			userID:         userID,
+			points:         s.score.UserPoints(userID),
+			sets:           s.score.UserSets(userID),
+			handicapPoints: s.score.HandicapPoints(userID),
+			goalMultiplier: s.score.GoalMultiplier(userID),
+		})
+	}
+	return players
+}
+
+// GoalsInBalance returns number of points in balance.
+//
+func (s *scoreResolver) GoalsInBalance() (goalsInBalance int32) {
+	return int32(s.score.GoalsInBalance)
+}
+
+// SeasonID returns ID of season of score (nil when score is not in a season).
+//
+func (s *scoreResolver) SeasonID() (seasonID *graphql.ID) {
+	if !s.score.SeasonID.Valid {
+		return nil
+	}
+	id := graphql.ID(s.score.SeasonID.UUID.String())
+	return &id
+}
+
+// MatchID returns ID of match of score (nil when score is not the one of a match).
+//
+func (s *scoreResolver) MatchID() (matchID *graphql.ID) {
+	if !s.score.MatchID.Valid {
+		return nil
+	}
+	id := graphql.ID(s.score.MatchID.UUID.String())
+	return &id
+}
+
+// UpdatedAt returns time of last update of score, in RFC 3339 format (nil when users have not played together yet).
+//
+func (s *scoreResolver) UpdatedAt() (updatedAt *string) {
+	if s.score.UpdatedAt.IsZero() {
+		return nil
+	}
+	formattedTime := s.score.UpdatedAt.Format(time.RFC3339)
+	return &formattedTime
+}
+
+// Goals returns goals of score, most recent first.
+//
+func (s *scoreResolver) Goals(ctx context.Context, args struct{ First int32 }) (goals []*goalResolver, resolveError error) {
+	if s.ID() == nil {
+		return []*goalResolver{}, nil
+	}
+	loadedGoals, resolveError := loadScoreGoals(ctx, s.score.ID.String(), goalLimit(args.First))
+	if resolveError != nil {
+		return nil, fmt.Errorf("Failed to retrieve goals of score '%s'", s.score.ID)
+	}
+	return goalHistory(loadedGoals, args.First), nil
+}
+
+// User returns user of score.
+//
+func (p *playerScoreResolver) User() (user *userResolver) {
+	return &userResolver{id: p.userID}
+}
+
+// Points returns points of user in current set.
+//
+func (p *playerScoreResolver) Points() (points int32) {
+	return int32(p.points)
+}
+
+// Sets returns sets won by user.
+//
+func (p *playerScoreResolver) Sets() (sets int32) {
+	return int32(p.sets)
+}
+
+// HandicapPoints returns points with which user starts every set (0 without handicap).
+//
+func (p *playerScoreResolver) HandicapPoints() (handicapPoints int32) {
+	return int32(p.handicapPoints)
+}
+
+// GoalMultiplier returns multiplier of "classic" goals of user (1 without handicap).
+//
+func (p *playerScoreResolver) GoalMultiplier() (goalMultiplier int32) {
+	if p.goalMultiplier < 1 {
+		return 1
+	}
+	return int32(p.goalMultiplier)
+}
+
+// EventID returns ID of event of score, to be sent back when subscribing again.
+//
+func (l *liveScoreResolver) EventID() (eventID string) {
+	return strconv.FormatUint(l.eventID, 10)
+}
+
+// Players returns score of each user, in order of subscription.
+//
+func (l *liveScoreResolver) Players() (players []*playerScoreResolver) {
+	return l.players
+}
+
+// GoalsInBalance returns number of points in balance.
+//
+func (l *liveScoreResolver) GoalsInBalance() (goalsInBalance int32) {
+	return int32(l.goalsInBalance)
+}
+
+// Won returns number of sets won by user.
+//
+func (b *balanceResolver) Won() (won int32) {
+	return int32(b.won)
+}
+
+// Lost returns number of sets lost by user.
+//
+func (b *balanceResolver) Lost() (lost int32) {
+	return int32(b.lost)
+}
+
+// Streaks returns streaks of user (nil when balance is restricted to a season).
+//
+func (b *balanceResolver) Streaks() (streaks *streaksResolver) {
+	if b.streak == nil {
+		return nil
+	}
+	return &streaksResolver{streak: *b.streak}
+}
+
+// CurrentWins returns number of sets won in a row until now.
+//
+func (s *streaksResolver) CurrentWins() (currentWins int32) {
+	return int32(s.streak.CurrentWins)
+}
+
+// CurrentLosses returns number of sets lost in a row until now.
+//
+func (s *streaksResolver) CurrentLosses() (currentLosses int32) {
+	return int32(s.streak.CurrentLosses)
+}
+
+// BestWins returns record number of sets won in a row.
+//
+func (s *streaksResolver) BestWins() (bestWins int32) {
+	return int32(s.streak.BestWins)
+}
+
+// BestLosses returns record number of sets lost in a row.
+//
+func (s *streaksResolver) BestLosses() (bestLosses int32) {
+	return int32(s.streak.BestLosses)
+}
+
+// ID returns ID of goal.
+//
+func (g *goalResolver) ID() (id graphql.ID) {
+	return graphql.ID(g.goal.ID.String())
+}
+
+// Scorer returns user who scored goal.
+//
+func (g *goalResolver) Scorer() (user *userResolver) {
+	return &userResolver{id: g.goal.ScorerId}
+}
+
+// Opponent returns opponent of user who scored goal.
+//
+func (g *goalResolver) Opponent() (user *userResolver) {
+	return &userResolver{id: g.goal.OpponentId}
+}
+
+// Player returns player who scored goal (from "p1" to "p11").
+//
+func (g *goalResolver) Player() (player string) {
+	return g.goal.Player
+}
+
+// Gamelle checks if goal was a "gamelle".
+//
+func (g *goalResolver) Gamelle() (gamelle bool) {
+	return g.goal.Gamelle
+}
+
+// Kind returns classification of goal.
+//
+func (g *goalResolver) Kind() (kind string) {
+	return g.goal.Kind
+}
+
+// SetFinished checks if goal finished a set.
+//
+func (g *goalResolver) SetFinished() (setFinished bool) {
+	return g.goal.SetFinished
+}
+
+// BalanceCashed returns number of points in balance cashed by goal.
+//
+func (g *goalResolver) BalanceCashed() (balanceCashed int32) {
+	return int32(g.goal.BalanceCashed)
+}
+
+// CreatedAt returns time of goal, in RFC 3339 format.
+//
+func (g *goalResolver) CreatedAt() (createdAt string) {
+	return g.goal.CreatedAt.Format(time.RFC3339)
+}
@@ -0,0 +1,101 @@
+package graph
+
+// Schema is the GraphQL schema of scoring service.
+//
+// Users are identified by their ID, as in API. Scores are returned between pairs of users (in season active when
+// query is executed), goal history being sorted by descending creation time.
+//
+const Schema = `
+schema {
+	query: Query
+	mutation: Mutation
+	subscription: Subscription
+}
+
+type Query {
+	# Users having played at least one set (in submitted season, if any).
+	users(seasonId: ID): [User!]!
+	user(id: String!): User!
+	# Usual score between users in active season (empty when they have not played together yet).
+	score(user1: String!, user2: String!): Score!
+	balance(userId: String!, seasonId: ID): Balance!
+	# Goals scored between users, most recent first.
+	goals(user1: String!, user2: String!, first: Int = 20): [Goal!]!
+}
+
+type Mutation {
+	# Stores a goal exactly as StoreGoal Lambda does, returning updated score.
+	recordGoal(goal: GoalInput!): Score!
+}
+
+type Subscription {
+	# Score between users after each goal. Reconnecting clients get events missed since lastEventId (when still known).
+	liveScore(user1: String!, user2: String!, lastEventId: String): LiveScore!
+}
+
+type User {
+	id: String!
+	scores(seasonId: ID): [Score!]!
+	balance(seasonId: ID): Balance!
+}
+
+type Score {
+	id: ID
+	user1: User!
+	user2: User!
+	players: [PlayerScore!]!
+	goalsInBalance: Int!
+	seasonId: ID
+	matchId: ID
+	updatedAt: String
+	goals(first: Int = 20): [Goal!]!
+}
+
+type PlayerScore {
+	user: User!
+	points: Int!
+	sets: Int!
+	handicapPoints: Int!
+	goalMultiplier: Int!
+}
+
+type LiveScore {
+	eventId: String!
+	players: [PlayerScore!]!
+	goalsInBalance: Int!
+}
+
+type Balance {
+	won: Int!
+	lost: Int!
+	# Streaks are counted over all seasons: they are null when balance is restricted to a season.
+	streaks: Streaks
+}
+
+type Streaks {
+	currentWins: Int!
+	currentLosses: Int!
+	bestWins: Int!
+	bestLosses: Int!
+}
+
+type Goal {
+	id: ID!
+	scorer: User!
+	opponent: User!
+	player: String!
+	gamelle: Boolean!
+	kind: String!
+	setFinished: Boolean!
+	balanceCashed: Int!
+	createdAt: String!
+}
+
+input GoalInput {
+	scorer: String!
+	opponent: String!
+	player: String!
+	gamelle: Boolean = false
+	matchId: ID
+}
+`
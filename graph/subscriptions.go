@@ -0,0 +1,279 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Subprotocol is the WebSocket subprotocol of subscriptions ("GraphQL over WebSocket" protocol of graphql-ws library).
+const Subprotocol = "graphql-transport-ws"
+
+// Types of messages of subscriptions protocol.
+const (
+	MessageConnectionInit = "connection_init"
+	MessageConnectionAck  = "connection_ack"
+	MessagePing           = "ping"
+	MessagePong           = "pong"
+	MessageSubscribe      = "subscribe"
+	MessageNext           = "next"
+	MessageError          = "error"
+	MessageComplete       = "complete"
+)
+
+// Close codes of connections breaking subscriptions protocol.
+const (
+	closeInvalidMessage      = 4400
+	closeUnauthorized        = 4401
+	closeInitTimeout         = 4408
+	closeSubscriberExists    = 4409
+	closeTooManyInitRequests = 4429
+)
+
+// Limits of connections: time given to client to initialize connection and to write a message, time without any
+// message from client before connection is considered lost (clients answering pings sent in between), and maximum size
+// of messages sent by clients.
+const (
+	initTimeout    = 10 * time.Second
+	writeTimeout   = 5 * time.Second
+	pongTimeout    = 60 * time.Second
+	pingInterval   = 30 * time.Second
+	maxMessageSize = 64 * 1024
+)
+
+// upgrader upgrades HTTP requests to WebSocket connections of subscriptions protocol, clients being served from any
+// origin.
+var upgrader = websocket.Upgrader{
+	Subprotocols: []string{Subprotocol},
+	CheckOrigin:  func(request *http.Request) bool { return true },
+}
+
+// Message represents a message of subscriptions protocol.
+//
+type Message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// subscriptionConnection represents a WebSocket connection of a client, with its running operations.
+//
+// Messages are written by several operations at once: writes (and changes of running operations) are serialized.
+//
+type subscriptionConnection struct {
+	connection   *websocket.Conn
+	mutex        sync.Mutex
+	operations   map[string]context.CancelFunc
+	acknowledged bool
+}
+
+// serveSubscriptions runs operations (usually subscriptions) sent by a client on a WebSocket connection, through
+// "GraphQL over WebSocket" protocol: client initializes connection, then subscribes to operations, each of them
+// sending results as "next" messages until it is completed (by server, or by client).
+//
+func (h *Handler) serveSubscriptions(writer http.ResponseWriter, request *http.Request) {
+	connection, upgradeError := upgrader.Upgrade(writer, request, nil)
+	if upgradeError != nil {
+		// Upgrader already answered request
+		return
+	}
+	defer connection.Close()
+	if connection.Subprotocol() != Subprotocol {
+		closeConnection(connection, websocket.CloseProtocolError, "Unsupported subprotocol")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(request.Context())
+	defer cancel()
+	client := &subscriptionConnection{connection: connection, operations: make(map[string]context.CancelFunc)}
+	go client.keepAlive(ctx)
+
+	connection.SetReadLimit(maxMessageSize)
+	connection.SetReadDeadline(time.Now().Add(initTimeout))
+	connection.SetPongHandler(func(string) error {
+		return connection.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	for {
+		_, rawMessage, readError := connection.ReadMessage()
+		if readError != nil {
+			if !client.acknowledged {
+				closeConnection(connection, closeInitTimeout, "Connection initialisation timeout")
+			}
+			return
+		}
+		connection.SetReadDeadline(time.Now().Add(pongTimeout))
+
+		var message Message
+		if json.Unmarshal(rawMessage, &message) != nil {
+			closeConnection(connection, closeInvalidMessage, "Invalid message received")
+			return
+		}
+		if !h.handleMessage(ctx, client, message) {
+			return
+		}
+	}
+}
+
+// handleMessage handles a message sent by client, returning false when connection must be closed.
+//
+func (h *Handler) handleMessage(ctx context.Context, client *subscriptionConnection, message Message) (open bool) {
+	switch message.Type {
+	case MessageConnectionInit:
+		if client.acknowledged {
+			closeConnection(client.connection, closeTooManyInitRequests, "Too many initialisation requests")
+			return false
+		}
+		client.acknowledged = true
+		return client.write(Message{Type: MessageConnectionAck}) == nil
+	case MessagePing:
+		return client.write(Message{Type: MessagePong}) == nil
+	case MessagePong:
+		return true
+	case MessageSubscribe:
+		if !client.acknowledged {
+			closeConnection(client.connection, closeUnauthorized, "Unauthorized")
+			return false
+		}
+		var graphQLRequest Request
+		if message.ID == "" || json.Unmarshal(message.Payload, &graphQLRequest) != nil {
+			closeConnection(client.connection, closeInvalidMessage, "Invalid message received")
+			return false
+		}
+		if !client.start(message.ID) {
+			closeConnection(client.connection, closeSubscriberExists, "Subscriber for "+message.ID+" already exists")
+			return false
+		}
+		go h.run(ctx, client, message.ID, graphQLRequest)
+		return true
+	case MessageComplete:
+		client.stop(message.ID)
+		return true
+	}
+	closeConnection(client.connection, closeInvalidMessage, "Invalid message received")
+	return false
+}
+
+// run runs an operation of client, sending its results until it is completed.
+//
+// Operations are completed by server when they have no more results (queries and mutations having only one), or by
+// client (results then not being sent anymore).
+//
+func (h *Handler) run(ctx context.Context, client *subscriptionConnection, operationID string, graphQLRequest Request) {
+	ctx, cancel := context.WithCancel(h.requestContext(ctx, ""))
+	defer cancel()
+	client.register(operationID, cancel)
+
+	var responses <-chan interface{}
+	var subscribeError error
+	if len(graphQLRequest.Query) > maxQueryLength {
+		subscribeError = fmt.Errorf("query must not exceed %d characters", maxQueryLength)
+	} else {
+		responses, subscribeError = h.schema.Subscribe(ctx, graphQLRequest.Query, graphQLRequest.OperationName, graphQLRequest.Variables)
+	}
+	if subscribeError != nil {
+		errorsInJSON, _ := json.Marshal([]map[string]string{{"message": subscribeError.Error()}})
+		client.write(Message{ID: operationID, Type: MessageError, Payload: errorsInJSON})
+		client.stop(operationID)
+		return
+	}
+
+	// Results are read until operation ends, even after it was completed by client, so that resolvers are not blocked
+	for response := range responses {
+		if ctx.Err() != nil {
+			continue
+		}
+		responseInJSON, marshalError := json.Marshal(response)
+		if marshalError != nil {
+			continue
+		}
+		client.write(Message{ID: operationID, Type: MessageNext, Payload: responseInJSON})
+	}
+	if client.stop(operationID) {
+		client.write(Message{ID: operationID, Type: MessageComplete})
+	}
+}
+
+// start reserves ID of a new operation, returning false when an operation with this ID is already running.
+//
+func (c *subscriptionConnection) start(operationID string) (started bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, running := c.operations[operationID]; running {
+		return false
+	}
+	c.operations[operationID] = nil
+	return true
+}
+
+// register records function stopping a running operation.
+//
+func (c *subscriptionConnection) register(operationID string, cancel context.CancelFunc) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, running := c.operations[operationID]; running {
+		c.operations[operationID] = cancel
+		return
+	}
+	// Operation was completed by client before it started
+	cancel()
+}
+
+// stop stops a running operation, returning false when it was not running anymore.
+//
+func (c *subscriptionConnection) stop(operationID string) (stopped bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cancel, running := c.operations[operationID]
+	if !running {
+		return false
+	}
+	if cancel != nil {
+		cancel()
+	}
+	delete(c.operations, operationID)
+	return true
+}
+
+// write sends a message to client.
+//
+func (c *subscriptionConnection) write(message Message) (writeError error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.connection.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.connection.WriteJSON(message)
+}
+
+// keepAlive pings client regularly, until connection is closed.
+//
+func (c *subscriptionConnection) keepAlive(ctx context.Context) {
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			c.mutex.Lock()
+			pingError := c.connection.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+			c.mutex.Unlock()
+			if pingError != nil {
+				return
+			}
+		}
+	}
+}
+
+// closeConnection closes connection with submitted code and reason.
+//
+func closeConnection(connection *websocket.Conn, closeCode int, reason string) {
+	connection.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, reason), time.Now().Add(writeTimeout))
+}
@@ -0,0 +1,360 @@
+package ladder
+
+import (
+	"errors"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/tournaments"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultChallengeRange is the number of places above them users can challenge, unless configured otherwise.
+const defaultChallengeRange = 3
+
+// answerDelay is the time given to defender to answer a challenge, playDelay the time given to play an accepted challenge.
+const (
+	answerDelay = 3 * 24 * time.Hour
+	playDelay   = 7 * 24 * time.Hour
+)
+
+// challengeSetsToWin is the number of sets to win a challenge match.
+const challengeSetsToWin = 2
+
+// Errors raised when a challenge cannot be issued or answered.
+var (
+	ErrSameUser            = errors.New("users cannot challenge themselves")
+	ErrNotRanked           = errors.New("both users must be in the ladder")
+	ErrOutOfRange          = errors.New("challenged user is not ranked within challenge range above challenger")
+	ErrChallengeInProgress = errors.New("a challenge is already in progress for one of both users")
+	ErrChallengeNotPending = errors.New("challenge is not waiting for an answer")
+	ErrAlreadyRanked       = errors.New("user is already in the ladder")
+	ErrChallengeExpired    = errors.New("challenge of match has expired")
+)
+
+// ChallengeRange returns number of places above them users can challenge, configured in environment variables.
+//
+func ChallengeRange() (challengeRange int) {
+	challengeRange, conversionError := strconv.Atoi(os.Getenv("LADDER_CHALLENGE_RANGE"))
+	if conversionError != nil || challengeRange < 1 {
+		return defaultChallengeRange
+	}
+	return challengeRange
+}
+
+// CheckChallenge checks that challenger can challenge defender according to ranks of the ladder.
+//
+// Both users must be in the ladder, defender being ranked at most challengeRange places above challenger.
+//
+func CheckChallenge(ranks []models.LadderRank, challengerID string, defenderID string, challengeRange int) (checkError error) {
+	if challengerID == defenderID {
+		return ErrSameUser
+	}
+
+	challengerRank, defenderRank := 0, 0
+	for _, rank := range ranks {
+		switch rank.UserId {
+		case challengerID:
+			challengerRank = rank.Rank
+		case defenderID:
+			defenderRank = rank.Rank
+		}
+	}
+
+	if challengerRank == 0 || defenderRank == 0 {
+		return ErrNotRanked
+	}
+	if gap := challengerRank - defenderRank; gap < 1 || gap > challengeRange {
+		return ErrOutOfRange
+	}
+	return nil
+}
+
+// SwapRanks swaps places of both submitted users in the ladder, returning updated ranks so that they can be saved.
+//
+func SwapRanks(ranks []models.LadderRank, firstUserID string, secondUserID string) (updatedRanks []models.LadderRank) {
+	var firstIndex, secondIndex = -1, -1
+
+	for index, rank := range ranks {
+		switch rank.UserId {
+		case firstUserID:
+			firstIndex = index
+		case secondUserID:
+			secondIndex = index
+		}
+	}
+	if firstIndex == -1 || secondIndex == -1 {
+		return nil
+	}
+
+	ranks[firstIndex].Rank, ranks[secondIndex].Rank = ranks[secondIndex].Rank, ranks[firstIndex].Rank
+	return []models.LadderRank{ranks[firstIndex], ranks[secondIndex]}
+}
+
+// Expire forfeits an open challenge whose deadline has passed, returning whether challenge has been forfeited.
+//
+// A defender not answering in time forfeits challenge, as does a challenger not getting accepted challenge played in time.
+//
+func Expire(challenge *models.Challenge, at time.Time) (forfeited bool) {
+	if !challenge.IsOpen() || !at.After(challenge.Deadline) {
+		return false
+	}
+
+	challenge.WinnerId = challenge.ChallengerId
+	if challenge.Status == models.ChallengeStatusAccepted {
+		challenge.WinnerId = challenge.DefenderId
+	}
+	challenge.Status = models.ChallengeStatusForfeited
+	return true
+}
+
+// FetchLadder retrieves ranks of the ladder, from top to bottom.
+//
+func FetchLadder(tx *pop.Connection) (ranks []models.LadderRank, fetchError error) {
+	ranks = []models.LadderRank{}
+	fetchError = tx.Order("rank").All(&ranks)
+	return ranks, fetchError
+}
+
+// FetchChallenges retrieves challenges (optionally only challenges of submitted user), from newest to oldest.
+//
+func FetchChallenges(tx *pop.Connection, userID string) (challenges []models.Challenge, fetchError error) {
+	challengesQuery := tx.Order("created_at DESC")
+	if userID != "" {
+		challengesQuery = challengesQuery.Where("challenger_id = ? OR defender_id = ?", userID, userID)
+	}
+
+	challenges = []models.Challenge{}
+	fetchError = challengesQuery.All(&challenges)
+	return challenges, fetchError
+}
+
+// Join adds user at the bottom of the ladder.
+//
+func Join(tx *pop.Connection, userID string) (joinedRank models.LadderRank, joinError error) {
+	ranks, joinError := FetchLadder(tx)
+	if joinError != nil {
+		return joinedRank, joinError
+	}
+	if isRanked(ranks, userID) {
+		return joinedRank, ErrAlreadyRanked
+	}
+
+	joinedRank = models.LadderRank{UserId: userID, Rank: len(ranks) + 1}
+	joinError = validateAndSave(tx, &joinedRank)
+	return joinedRank, joinError
+}
+
+// Issue creates a challenge from challenger to defender (see CheckChallenge).
+//
+// Users cannot be part of two open challenges at the same time: expired challenges must be forfeited beforehand
+// (see ExpireChallenges).
+//
+func Issue(tx *pop.Connection, challengerID string, defenderID string, at time.Time) (issuedChallenge models.Challenge, issueError error) {
+	ranks, issueError := FetchLadder(tx)
+	if issueError != nil {
+		return issuedChallenge, issueError
+	}
+	issueError = CheckChallenge(ranks, challengerID, defenderID, ChallengeRange())
+	if issueError != nil {
+		return issuedChallenge, issueError
+	}
+
+	challengeInProgress, issueError := tx.Where("status IN (?, ?)", models.ChallengeStatusPending, models.ChallengeStatusAccepted).
+		Where("(challenger_id IN (?, ?) OR defender_id IN (?, ?))", challengerID, defenderID, challengerID, defenderID).
+		Exists(models.Challenge{})
+	if issueError != nil {
+		return issuedChallenge, issueError
+	}
+	if challengeInProgress {
+		return issuedChallenge, ErrChallengeInProgress
+	}
+
+	issuedChallenge = models.Challenge{
+		ChallengerId: challengerID,
+		DefenderId:   defenderID,
+		Status:       models.ChallengeStatusPending,
+		Deadline:     at.Add(answerDelay),
+	}
+	issueError = validateAndSave(tx, &issuedChallenge)
+	return issuedChallenge, issueError
+}
+
+// Accept accepts a pending challenge, creating its match (goals of challenge must then be submitted in this match).
+//
+// Both users then have until deadline to play match, challenger forfeiting challenge otherwise.
+//
+func Accept(tx *pop.Connection, challenge *models.Challenge, at time.Time) (acceptError error) {
+	if challenge.Status != models.ChallengeStatusPending {
+		return ErrChallengeNotPending
+	}
+
+	challengeMatch := models.Match{
+		User1Id:   challenge.ChallengerId,
+		User2Id:   challenge.DefenderId,
+		Status:    models.MatchStatusReady,
+		SetsToWin: challengeSetsToWin,
+		Bracket:   models.MatchBracketMain,
+	}
+	acceptError = validateAndSave(tx, &challengeMatch)
+	if acceptError != nil {
+		return acceptError
+	}
+
+	challenge.Status = models.ChallengeStatusAccepted
+	challenge.MatchID = nulls.NewUUID(challengeMatch.ID)
+	challenge.Deadline = at.Add(playDelay)
+	return validateAndSave(tx, challenge)
+}
+
+// Decline declines a pending challenge, ranks being left untouched (unlike a challenge left unanswered).
+//
+func Decline(tx *pop.Connection, challenge *models.Challenge, at time.Time) (declineError error) {
+	if challenge.Status != models.ChallengeStatusPending {
+		return ErrChallengeNotPending
+	}
+
+	challenge.Status = models.ChallengeStatusDeclined
+	return validateAndSave(tx, challenge)
+}
+
+// ExpireChallenges forfeits all open challenges whose deadline has passed (see Expire).
+//
+func ExpireChallenges(tx *pop.Connection, at time.Time) (expireError error) {
+	var openChallenges []models.Challenge
+
+	expireError = tx.Where("status IN (?, ?) AND deadline < ?", models.ChallengeStatusPending, models.ChallengeStatusAccepted, at).All(&openChallenges)
+	if expireError != nil {
+		return expireError
+	}
+
+	for index := range openChallenges {
+		if Expire(&openChallenges[index], at) {
+			expireError = resolve(tx, &openChallenges[index])
+			if expireError != nil {
+				return expireError
+			}
+		}
+	}
+	return nil
+}
+
+// RecordMatch completes accepted challenge of a finished match (if any), both users swapping places if challenger won.
+//
+func RecordMatch(tx *pop.Connection, finishedMatch models.Match) (recordError error) {
+	var matchChallenge models.Challenge
+
+	if finishedMatch.Status != models.MatchStatusFinished || finishedMatch.TournamentID.Valid {
+		return nil
+	}
+
+	challengeQuery := tx.Where("match_id = ? AND status = ?", finishedMatch.ID, models.ChallengeStatusAccepted)
+	challengeExists, recordError := challengeQuery.Exists(models.Challenge{})
+	if recordError != nil || !challengeExists {
+		return recordError
+	}
+	recordError = challengeQuery.First(&matchChallenge)
+	if recordError != nil {
+		return recordError
+	}
+
+	matchChallenge.Status = models.ChallengeStatusCompleted
+	matchChallenge.WinnerId = finishedMatch.WinnerId
+	return resolve(tx, &matchChallenge)
+}
+
+// CheckMatchChallenge checks that accepted challenge of a match (if any) has not expired at submitted time,
+// so that no goal is scored in match of a challenge to be forfeited, even before it is expired by ExpireChallenges.
+//
+func CheckMatchChallenge(tx *pop.Connection, challengeMatch models.Match, at time.Time) (checkError error) {
+	var matchChallenge models.Challenge
+
+	if challengeMatch.TournamentID.Valid {
+		return nil
+	}
+
+	challengeQuery := tx.Where("match_id = ? AND status = ?", challengeMatch.ID, models.ChallengeStatusAccepted)
+	challengeExists, checkError := challengeQuery.Exists(models.Challenge{})
+	if checkError != nil || !challengeExists {
+		return checkError
+	}
+	checkError = challengeQuery.First(&matchChallenge)
+	if checkError != nil {
+		return checkError
+	}
+
+	if Expire(&matchChallenge, at) {
+		return ErrChallengeExpired
+	}
+	return nil
+}
+
+// resolve saves a completed or forfeited challenge, swapping places of both users if challenger won.
+//
+// Match of a challenge forfeited after being accepted is finished, so that no goal can be scored in it anymore.
+//
+func resolve(tx *pop.Connection, challenge *models.Challenge) (resolveError error) {
+	resolveError = validateAndSave(tx, challenge)
+	if resolveError != nil {
+		return resolveError
+	}
+
+	if challenge.Status == models.ChallengeStatusForfeited && challenge.MatchID.Valid {
+		var challengeMatch models.Match
+		resolveError = tx.Find(&challengeMatch, challenge.MatchID.UUID)
+		if resolveError != nil {
+			return resolveError
+		}
+		if challengeMatch.Status != models.MatchStatusFinished {
+			resolveError = tournaments.FinishMatch(tx, &challengeMatch, challenge.WinnerId)
+			if resolveError != nil {
+				return resolveError
+			}
+		}
+	}
+
+	if challenge.WinnerId != challenge.ChallengerId {
+		return nil
+	}
+
+	ranks, resolveError := FetchLadder(tx)
+	if resolveError != nil {
+		return resolveError
+	}
+	for _, updatedRank := range SwapRanks(ranks, challenge.ChallengerId, challenge.DefenderId) {
+		resolveError = validateAndSave(tx, &updatedRank)
+		if resolveError != nil {
+			return resolveError
+		}
+	}
+	return nil
+}
+
+// isRanked checks if submitted user is in the ladder.
+//
+func isRanked(ranks []models.LadderRank, userID string) (ranked bool) {
+	for _, rank := range ranks {
+		if rank.UserId == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAndSave validates and saves submitted model, validation errors being returned as an error.
+//
+func validateAndSave(tx *pop.Connection, model interface{}) (saveError error) {
+	var validateError *validate.Errors
+
+	validateError, saveError = tx.ValidateAndSave(model)
+	if saveError != nil {
+		return saveError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return validateError
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package ladder
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+	"time"
+)
+
+// newRanks creates ranks of the ladder for submitted users, from top to bottom.
+//
+func newRanks(users ...string) (ranks []models.LadderRank) {
+	for index, user := range users {
+		ranks = append(ranks, models.LadderRank{UserId: user, Rank: index + 1})
+	}
+	return ranks
+}
+
+// TestCheckChallenge tests CheckChallenge function with users inside and outside challenge range.
+//
+func TestCheckChallenge(t *testing.T) {
+	assertHandler := assert.New(t)
+	ranks := newRanks("user1", "user2", "user3", "user4", "user5")
+
+	assertHandler.NoError(CheckChallenge(ranks, "user5", "user4", 3), "User right above: challenge should be allowed")
+	assertHandler.NoError(CheckChallenge(ranks, "user5", "user2", 3), "User at range limit: challenge should be allowed")
+	assertHandler.Equal(ErrOutOfRange, CheckChallenge(ranks, "user5", "user1", 3), "User out of range: challenge should be refused")
+	assertHandler.Equal(ErrOutOfRange, CheckChallenge(ranks, "user2", "user3", 3), "User below: challenge should be refused")
+	assertHandler.Equal(ErrNotRanked, CheckChallenge(ranks, "user6", "user5", 3), "Unranked challenger: challenge should be refused")
+	assertHandler.Equal(ErrNotRanked, CheckChallenge(ranks, "user5", "user6", 3), "Unranked defender: challenge should be refused")
+	assertHandler.Equal(ErrSameUser, CheckChallenge(ranks, "user5", "user5", 3), "Same user: challenge should be refused")
+}
+
+// TestSwapRanks tests SwapRanks function swapping places of two users.
+//
+func TestSwapRanks(t *testing.T) {
+	assertHandler := assert.New(t)
+	ranks := newRanks("user1", "user2", "user3")
+
+	updatedRanks := SwapRanks(ranks, "user3", "user1")
+	assertHandler.Equal([]models.LadderRank{{UserId: "user3", Rank: 1}, {UserId: "user1", Rank: 3}}, updatedRanks, "Swap: both users should exchange their ranks")
+	assertHandler.Equal(2, ranks[1].Rank, "Swap: other users should keep their ranks")
+	assertHandler.Nil(SwapRanks(ranks, "user1", "user4"), "Unranked user: no rank should change")
+}
+
+// TestExpire tests Expire function forfeiting challenges whose deadline has passed.
+//
+func TestExpire(t *testing.T) {
+	assertHandler := assert.New(t)
+	deadline := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	challenge := models.Challenge{ChallengerId: "user2", DefenderId: "user1", Status: models.ChallengeStatusPending, Deadline: deadline}
+	assertHandler.False(Expire(&challenge, deadline.Add(-time.Hour)), "Before deadline: challenge should stay open")
+	assertHandler.True(Expire(&challenge, deadline.Add(time.Hour)), "Unanswered challenge: challenge should be forfeited")
+	assertHandler.Equal("user2", challenge.WinnerId, "Unanswered challenge: challenger should win")
+
+	challenge = models.Challenge{ChallengerId: "user2", DefenderId: "user1", Status: models.ChallengeStatusAccepted, Deadline: deadline}
+	assertHandler.True(Expire(&challenge, deadline.Add(time.Hour)), "Unplayed challenge: challenge should be forfeited")
+	assertHandler.Equal("user1", challenge.WinnerId, "Unplayed challenge: defender should win")
+
+	challenge = models.Challenge{ChallengerId: "user2", DefenderId: "user1", Status: models.ChallengeStatusDeclined, Deadline: deadline}
+	assertHandler.False(Expire(&challenge, deadline.Add(time.Hour)), "Declined challenge: challenge should not be forfeited")
+}
@@ -0,0 +1,210 @@
+package logging
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level represents severity of a log entry, entries below configured level being discarded.
+//
+type Level int
+
+// Levels of log entries, from most to least verbose.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// levelNames are the names of levels, as configured and as written in log entries.
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// redactedValue replaces values of sensitive fields.
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeys are the parts of field names whose values are never logged.
+var sensitiveKeys = [...]string{"secret", "password", "token", "signature", "authorization", "cookie"}
+
+// stderrMutex prevents entries written concurrently on standard error from being interleaved.
+var stderrMutex sync.Mutex
+
+// Fields represents contextual information attached to log entries.
+//
+type Fields map[string]interface{}
+
+// Timings records durations of database operations of a request, logged in milliseconds.
+//
+type Timings map[string]float64
+
+// Logger writes log entries as JSON lines, each entry carrying fields of logger (such as ID of request).
+//
+type Logger struct {
+	output io.Writer
+	mutex  *sync.Mutex
+	level  Level
+	fields Fields
+}
+
+// ParseLevel returns level of submitted name, info level being used for unknown names.
+//
+func ParseLevel(levelName string) (level Level) {
+	for level, name := range levelNames {
+		if strings.EqualFold(strings.TrimSpace(levelName), name) {
+			return level
+		}
+	}
+	return LevelInfo
+}
+
+// New creates a logger writing on standard error (collected by CloudWatch for Lambda functions), from level
+// configured in LOG_LEVEL environment variable (info by default).
+//
+func New(fields Fields) (logger *Logger) {
+	return &Logger{output: os.Stderr, mutex: &stderrMutex, level: ParseLevel(os.Getenv("LOG_LEVEL")), fields: fields}
+}
+
+// NewWithOutput creates a logger writing on submitted output, from submitted level.
+//
+func NewWithOutput(output io.Writer, level Level, fields Fields) (logger *Logger) {
+	return &Logger{output: output, mutex: &sync.Mutex{}, level: level, fields: fields}
+}
+
+// FromRequest creates a logger whose entries carry ID of API Gateway request and name of handler, so that all
+// entries of a request can be correlated.
+//
+func FromRequest(request events.APIGatewayProxyRequest, handlerName string) (logger *Logger) {
+	return New(Fields{"request_id": request.RequestContext.RequestID, "handler": handlerName})
+}
+
+// With creates a logger whose entries carry submitted fields in addition to those of logger.
+//
+func (l *Logger) With(fields Fields) (logger *Logger) {
+	mergedFields := make(Fields, len(l.fields)+len(fields))
+	for key, value := range l.fields {
+		mergedFields[key] = value
+	}
+	for key, value := range fields {
+		mergedFields[key] = value
+	}
+	return &Logger{output: l.output, mutex: l.mutex, level: l.level, fields: mergedFields}
+}
+
+// Debug writes a debug entry.
+//
+func (l *Logger) Debug(message string, fields Fields) {
+	l.write(LevelDebug, message, fields)
+}
+
+// Info writes an info entry.
+//
+func (l *Logger) Info(message string, fields Fields) {
+	l.write(LevelInfo, message, fields)
+}
+
+// Warn writes a warning entry.
+//
+func (l *Logger) Warn(message string, fields Fields) {
+	l.write(LevelWarn, message, fields)
+}
+
+// Error writes an error entry.
+//
+func (l *Logger) Error(message string, fields Fields) {
+	l.write(LevelError, message, fields)
+}
+
+// write writes an entry as a JSON line, with its time, level, message and fields (sensitive fields being redacted).
+//
+// Fields which cannot be JSONified are replaced by their error, so that entry is still written.
+//
+func (l *Logger) write(level Level, message string, fields Fields) {
+	if level < l.level {
+		return
+	}
+
+	entry := make(map[string]interface{}, len(l.fields)+len(fields)+3)
+	for key, value := range l.fields {
+		entry[key] = value
+	}
+	for key, value := range fields {
+		entry[key] = value
+	}
+	redact(entry)
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = levelNames[level]
+	entry["message"] = message
+
+	entryInJSON, marshalError := json.Marshal(entry)
+	if marshalError != nil {
+		entryInJSON, _ = json.Marshal(map[string]interface{}{"time": entry["time"], "level": entry["level"], "message": message, "log_error": marshalError.Error()})
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.output.Write(append(entryInJSON, '\n'))
+}
+
+// Since records time elapsed since start for submitted database operation (added to previous ones of this operation).
+//
+func (t Timings) Since(operation string, start time.Time) {
+	t[operation] += float64(time.Since(start)) / float64(time.Millisecond)
+}
+
+// redact replaces values of sensitive fields, including fields of nested objects (which are copied, so that fields
+// of logger are never altered).
+//
+func redact(fields map[string]interface{}) {
+	for key, value := range fields {
+		if isSensitive(key) {
+			fields[key] = redactedValue
+			continue
+		}
+		if nestedFields := copyFields(value); nestedFields != nil {
+			redact(nestedFields)
+			fields[key] = nestedFields
+		}
+	}
+}
+
+// copyFields copies value when it is a nested object, returning nil otherwise.
+//
+func copyFields(value interface{}) (copiedFields map[string]interface{}) {
+	switch nestedFields := value.(type) {
+	case Fields:
+		return copyFields(map[string]interface{}(nestedFields))
+	case map[string]interface{}:
+		copiedFields = make(map[string]interface{}, len(nestedFields))
+		for nestedKey, nestedValue := range nestedFields {
+			copiedFields[nestedKey] = nestedValue
+		}
+	case map[string]string:
+		copiedFields = make(map[string]interface{}, len(nestedFields))
+		for nestedKey, nestedValue := range nestedFields {
+			copiedFields[nestedKey] = nestedValue
+		}
+	}
+	return copiedFields
+}
+
+// isSensitive checks if a field name designates a sensitive value.
+//
+func isSensitive(key string) (sensitiveKey bool) {
+	lowerKey := strings.ToLower(key)
+	for _, sensitivePart := range sensitiveKeys {
+		if strings.Contains(lowerKey, sensitivePart) {
+			return true
+		}
+	}
+	return false
+}
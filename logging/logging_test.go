@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEntries reads log entries written as JSON lines.
+//
+func readEntries(t *testing.T, output *bytes.Buffer) (entries []map[string]interface{}) {
+	for _, line := range strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &entry), "Each line should be a JSON object: %s", line)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestParseLevel tests ParseLevel function reading configured levels.
+//
+func TestParseLevel(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	assertHandler.Equal(LevelDebug, ParseLevel("debug"), "Debug level should be read")
+	assertHandler.Equal(LevelWarn, ParseLevel(" WARN "), "Level should be read whatever its case")
+	assertHandler.Equal(LevelError, ParseLevel("error"), "Error level should be read")
+	assertHandler.Equal(LevelInfo, ParseLevel(""), "Info level should be used by default")
+	assertHandler.Equal(LevelInfo, ParseLevel("verbose"), "Info level should be used for unknown levels")
+}
+
+// TestLoggerLevels tests entries below level of logger being discarded.
+//
+func TestLoggerLevels(t *testing.T) {
+	assertHandler := assert.New(t)
+	var output bytes.Buffer
+
+	logger := NewWithOutput(&output, LevelWarn, nil)
+	logger.Debug("debug", nil)
+	logger.Info("info", nil)
+	logger.Warn("warn", nil)
+	logger.Error("error", nil)
+
+	entries := readEntries(t, &output)
+	assertHandler.Len(entries, 2, "Only warnings and errors should be written")
+	assertHandler.Equal("warn", entries[0]["level"], "Level should be written")
+	assertHandler.Equal("error", entries[1]["message"], "Message should be written")
+	_, timeError := time.Parse(time.RFC3339Nano, entries[0]["time"].(string))
+	assertHandler.Nil(timeError, "Time should be written in RFC 3339 format")
+}
+
+// TestLoggerFields tests fields of logger and of entries being written, sensitive fields being redacted.
+//
+func TestLoggerFields(t *testing.T) {
+	assertHandler := assert.New(t)
+	var output bytes.Buffer
+
+	logger := NewWithOutput(&output, LevelDebug, Fields{"request_id": "req-1", "handler": "StoreGoal"})
+	goalLogger := logger.With(Fields{"scorer_id": "user1", "slack_signature": "v0=abc"})
+	goalLogger.Info("Goal stored", Fields{
+		"score_after": map[string]interface{}{"goals_in_balance": 2, "api_token": "xoxb"},
+		"headers":     map[string]string{"Authorization": "Bearer abc", "Content-Type": "application/json"},
+		"password":    "foosball",
+	})
+	logger.Info("Other goal", nil)
+
+	entries := readEntries(t, &output)
+	assertHandler.Len(entries, 2, "All entries should be written")
+	assertHandler.Equal("req-1", entries[0]["request_id"], "Fields of logger should be written")
+	assertHandler.Equal("user1", entries[0]["scorer_id"], "Fields added to logger should be written")
+	assertHandler.Equal(redactedValue, entries[0]["slack_signature"], "Signatures should be redacted")
+	assertHandler.Equal(redactedValue, entries[0]["password"], "Passwords should be redacted")
+	scoreAfter := entries[0]["score_after"].(map[string]interface{})
+	assertHandler.Equal(float64(2), scoreAfter["goals_in_balance"], "Nested fields should be written")
+	assertHandler.Equal(redactedValue, scoreAfter["api_token"], "Nested tokens should be redacted")
+	headers := entries[0]["headers"].(map[string]interface{})
+	assertHandler.Equal(redactedValue, headers["Authorization"], "Authorization headers should be redacted")
+	assertHandler.Equal("application/json", headers["Content-Type"], "Other headers should be written")
+	assertHandler.NotContains(entries[1], "scorer_id", "Fields added to a derived logger should not be written by original logger")
+}
+
+// TestLoggerUnmarshallableField tests entries still being written when a field cannot be JSONified.
+//
+func TestLoggerUnmarshallableField(t *testing.T) {
+	var output bytes.Buffer
+
+	NewWithOutput(&output, LevelInfo, nil).Error("Failed", Fields{"callback": func() {}})
+	entries := readEntries(t, &output)
+	assert.Len(t, entries, 1, "Entry should be written")
+	assert.Equal(t, "Failed", entries[0]["message"], "Message should be written")
+	assert.Contains(t, entries[0], "log_error", "Marshal error should be written")
+}
+
+// TestFromRequest tests FromRequest function correlating entries with API Gateway request.
+//
+func TestFromRequest(t *testing.T) {
+	request := events.APIGatewayProxyRequest{RequestContext: events.APIGatewayProxyRequestContext{RequestID: "c6af9ac6-7b61-11e6-9a41-93e8deadbeef"}}
+
+	logger := FromRequest(request, "StoreGoal")
+	assert.Equal(t, "c6af9ac6-7b61-11e6-9a41-93e8deadbeef", logger.fields["request_id"], "Entries should carry ID of request")
+	assert.Equal(t, "StoreGoal", logger.fields["handler"], "Entries should carry name of handler")
+}
+
+// TestTimings tests Timings recording durations of database operations.
+//
+func TestTimings(t *testing.T) {
+	timings := Timings{}
+	start := time.Now().Add(-20 * time.Millisecond)
+
+	timings.Since("find_score", start)
+	timings.Since("find_score", start)
+	assert.True(t, timings["find_score"] >= 40, "Durations of an operation should be added, in milliseconds")
+}
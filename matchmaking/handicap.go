@@ -0,0 +1,24 @@
+package matchmaking
+
+import (
+	"math"
+)
+
+// ratingGapPerHandicapPoint is the rating gap compensated by one handicap point.
+const ratingGapPerHandicapPoint = 50.0
+
+// maxHandicapPoints is the highest number of handicap points given automatically.
+const maxHandicapPoints = 5
+
+// HandicapPoints computes points with which each of two users should start every set to even their chances.
+//
+// Only the lower rated user gets points: one point per ratingGapPerHandicapPoint of rating gap, up to maxHandicapPoints.
+//
+func HandicapPoints(userRating float64, opponentRating float64) (userPoints int, opponentPoints int) {
+	points := int(math.Min(maxHandicapPoints, math.Round(math.Abs(userRating-opponentRating)/ratingGapPerHandicapPoint)))
+
+	if userRating < opponentRating {
+		return points, 0
+	}
+	return 0, points
+}
@@ -0,0 +1,21 @@
+package matchmaking
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// TestHandicapPoints tests HandicapPoints function giving points to lower rated user.
+//
+func TestHandicapPoints(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	userPoints, opponentPoints := HandicapPoints(1000, 1000)
+	assertHandler.Equal([]int{0, 0}, []int{userPoints, opponentPoints}, "Even ratings: nobody should get handicap points")
+
+	userPoints, opponentPoints = HandicapPoints(1000, 1130)
+	assertHandler.Equal([]int{3, 0}, []int{userPoints, opponentPoints}, "Lower rated user: user should get one point per 50 rating points")
+
+	userPoints, opponentPoints = HandicapPoints(1600, 1000)
+	assertHandler.Equal([]int{0, 5}, []int{userPoints, opponentPoints}, "Huge gap: opponent points should be capped")
+}
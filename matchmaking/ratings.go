@@ -0,0 +1,88 @@
+package matchmaking
+
+import (
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"math"
+)
+
+// InitialRating is the rating of a user who has not finished any set yet.
+const InitialRating = 1000.0
+
+// ratingFactor is the maximum number of rating points exchanged on one set (Elo K-factor).
+const ratingFactor = 32.0
+
+// handicappedRatingFactor is the maximum number of rating points exchanged on a set played with a handicap.
+const handicappedRatingFactor = ratingFactor / 2
+
+// SetResult represents a finished set, won by scorer of its last goal (handicapped if a handicap applied to set).
+//
+type SetResult struct {
+	WinnerID    string `db:"scorer_id"`
+	LoserID     string `db:"opponent_id"`
+	Handicapped bool   `db:"handicapped"`
+}
+
+// ComputeRatings computes Elo rating of each user from finished sets, ordered from oldest to newest.
+//
+// Each user starts at InitialRating: winner of a set takes from loser a number of points
+// that grows with the probability of the opposite result.
+// Sets played with a handicap exchange half as many points, their result telling less about levels of users.
+//
+func ComputeRatings(sets []SetResult) (ratings map[string]float64) {
+	ratings = make(map[string]float64)
+
+	for _, set := range sets {
+		winnerRating, loserRating := Rating(ratings, set.WinnerID), Rating(ratings, set.LoserID)
+		factor := ratingFactor
+		if set.Handicapped {
+			factor = handicappedRatingFactor
+		}
+		exchangedPoints := factor * (1 - WinProbability(winnerRating, loserRating))
+
+		ratings[set.WinnerID] = winnerRating + exchangedPoints
+		ratings[set.LoserID] = loserRating - exchangedPoints
+	}
+
+	return ratings
+}
+
+// Rating returns rating of submitted user (InitialRating for a user without any finished set).
+//
+func Rating(ratings map[string]float64, userID string) (rating float64) {
+	if rating, ratedUser := ratings[userID]; ratedUser {
+		return rating
+	}
+	return InitialRating
+}
+
+// WinProbability returns expected probability that a user wins a set against an opponent, according to their ratings.
+//
+func WinProbability(rating float64, opponentRating float64) (probability float64) {
+	return 1 / (1 + math.Pow(10, (opponentRating-rating)/400))
+}
+
+// FetchRatings retrieves finished sets (of submitted season, or of all seasons if not valid), then computes ratings of
+// users.
+//
+func FetchRatings(tx *pop.Connection, seasonID nulls.UUID) (ratings map[string]float64, fetchError error) {
+	var sets []SetResult
+
+	seasonCondition, seasonArgs := inSeason(seasonID)
+	fetchError = tx.RawQuery("SELECT scorer_id, opponent_id, handicapped FROM goals WHERE set_finished AND "+seasonCondition+" ORDER BY created_at", seasonArgs...).All(&sets)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+
+	return ComputeRatings(sets), nil
+}
+
+// inSeason returns SQL condition restricting rows to submitted season, with its query arguments (condition matching
+// all rows if season is not valid).
+//
+func inSeason(seasonID nulls.UUID) (condition string, args []interface{}) {
+	if !seasonID.Valid {
+		return "TRUE", nil
+	}
+	return "season_id = ?", []interface{}{seasonID.UUID}
+}
@@ -0,0 +1,58 @@
+package matchmaking
+
+import (
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// TestComputeRatings tests ComputeRatings function with points exchanged between users.
+//
+func TestComputeRatings(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	ratings := ComputeRatings([]SetResult{{WinnerID: "user1", LoserID: "user2"}})
+	assertHandler.Equal(1016.0, ratings["user1"], "Even users: winner should take half of rating factor")
+	assertHandler.Equal(984.0, ratings["user2"], "Even users: loser should lose half of rating factor")
+	assertHandler.Equal(InitialRating, Rating(ratings, "user3"), "Unknown user: rating should be initial rating")
+
+	ratings = ComputeRatings([]SetResult{{WinnerID: "user1", LoserID: "user2"}, {WinnerID: "user1", LoserID: "user2"}})
+	assertHandler.InDelta(1030.53, ratings["user1"], 0.01, "Favourite: winner should take less points when expected to win")
+	assertHandler.InDelta(2000.0, ratings["user1"]+ratings["user2"], 0.0001, "Ratings: points should only be exchanged between users")
+}
+
+// TestWinProbability tests WinProbability function for even and uneven ratings.
+//
+func TestWinProbability(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	assertHandler.Equal(0.5, WinProbability(1200, 1200), "Even ratings: both users should be as likely to win")
+	assertHandler.InDelta(0.909, WinProbability(1400, 1000), 0.001, "400 points more: user should win 10 times more often")
+	assertHandler.InDelta(1, WinProbability(1000, 1400)+WinProbability(1400, 1000), 0.0001, "Uneven ratings: probabilities should be complementary")
+}
+
+// TestComputeRatingsHandicapped tests ComputeRatings function with sets played with a handicap.
+//
+func TestComputeRatingsHandicapped(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	ratings := ComputeRatings([]SetResult{{WinnerID: "user1", LoserID: "user2", Handicapped: true}})
+	assertHandler.Equal(1008.0, ratings["user1"], "Handicapped set: winner should take half as many points")
+	assertHandler.Equal(992.0, ratings["user2"], "Handicapped set: loser should lose half as many points")
+}
+
+// TestInSeason tests sets and scores being restricted to a season only when one is submitted.
+//
+func TestInSeason(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	condition, args := inSeason(nulls.UUID{})
+	assertHandler.Equal("TRUE", condition, "No season: all rows should be kept")
+	assertHandler.Empty(args, "No season: condition should have no arguments")
+
+	seasonID := uuid.Must(uuid.NewV4())
+	condition, args = inSeason(nulls.NewUUID(seasonID))
+	assertHandler.Equal("season_id = ?", condition, "Season: only rows of season should be kept")
+	assertHandler.Equal([]interface{}{seasonID}, args, "Season: condition should have season as argument")
+}
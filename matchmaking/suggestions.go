@@ -0,0 +1,148 @@
+package matchmaking
+
+import (
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"math"
+	"sort"
+	"time"
+)
+
+// Weights of criteria used to rank suggested opponents (summing to 1).
+const (
+	ratingWeight  = 0.5
+	recencyWeight = 0.25
+	balanceWeight = 0.25
+)
+
+// recencyPeriod is the time after which two users are not considered as having played together recently anymore.
+const recencyPeriod = 14 * 24 * time.Hour
+
+// PairHistory represents sets played between a user and one opponent, all scores together (from user point of view).
+//
+type PairHistory struct {
+	OpponentID   string    `db:"opponent_id"`
+	SetsWon      int       `db:"sets_won"`
+	SetsLost     int       `db:"sets_lost"`
+	LastPlayedAt time.Time `db:"last_played_at"`
+}
+
+// Suggestion represents an opponent suggested to a user, with criteria of its ranking.
+//
+// WinProbability is the probability that requested user wins a set against suggested opponent.
+// Fairness (between 0 and 1) combines rating closeness, time since last score between both users and balance of their sets.
+//
+type Suggestion struct {
+	UserID         string     `json:"user_id"`
+	Rating         int        `json:"rating"`
+	WinProbability float64    `json:"win_probability"`
+	SetsWon        int        `json:"sets_won"`
+	SetsLost       int        `json:"sets_lost"`
+	LastPlayedAt   nulls.Time `json:"last_played_at"`
+	Fairness       float64    `json:"fairness"`
+}
+
+// SuggestOpponents ranks candidates from fairest to least fair opponent of submitted user, keeping at most limit of them.
+//
+// Fairness favours opponents having a close rating (even win probability), who did not play user recently
+// (or never did), and against whom user won as many sets as they lost.
+//
+func SuggestOpponents(userID string, candidates []string, ratings map[string]float64, histories map[string]PairHistory, now time.Time, limit int) (suggestions []Suggestion) {
+	userRating := Rating(ratings, userID)
+
+	suggestions = []Suggestion{}
+	for _, candidate := range candidates {
+		if candidate == userID {
+			continue
+		}
+		candidateRating := Rating(ratings, candidate)
+		history, playedBefore := histories[candidate]
+
+		winProbability := WinProbability(userRating, candidateRating)
+		ratingCloseness := 1 - math.Abs(2*winProbability-1)
+
+		recency, balance := 1.0, 1.0
+		if playedBefore {
+			recency = math.Min(1, float64(now.Sub(history.LastPlayedAt))/float64(recencyPeriod))
+		}
+		if setsPlayed := history.SetsWon + history.SetsLost; setsPlayed > 0 {
+			balance = 1 - math.Abs(float64(history.SetsWon-history.SetsLost))/float64(setsPlayed)
+		}
+
+		suggestion := Suggestion{
+			UserID:         candidate,
+			Rating:         int(math.Round(candidateRating)),
+			WinProbability: roundRatio(winProbability),
+			SetsWon:        history.SetsWon,
+			SetsLost:       history.SetsLost,
+			Fairness:       roundRatio(ratingWeight*ratingCloseness + recencyWeight*recency + balanceWeight*balance),
+		}
+		if playedBefore {
+			suggestion.LastPlayedAt = nulls.NewTime(history.LastPlayedAt)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Fairness != suggestions[j].Fairness {
+			return suggestions[i].Fairness > suggestions[j].Fairness
+		}
+		return suggestions[i].UserID < suggestions[j].UserID
+	})
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// FetchCandidates retrieves all users having played at least one score (in submitted season, or in any season if not
+// valid).
+//
+func FetchCandidates(tx *pop.Connection, seasonID nulls.UUID) (candidates []string, fetchError error) {
+	var users []struct {
+		UserID string `db:"user_id"`
+	}
+
+	seasonCondition, seasonArgs := inSeason(seasonID)
+	fetchError = tx.RawQuery("SELECT user1_id AS user_id FROM scores WHERE "+seasonCondition+" UNION SELECT user2_id AS user_id FROM scores WHERE "+seasonCondition, append(seasonArgs, seasonArgs...)...).All(&users)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+
+	for _, user := range users {
+		candidates = append(candidates, user.UserID)
+	}
+	return candidates, nil
+}
+
+// FetchPairHistories retrieves sets played by submitted user against each of their opponents (in submitted season, or
+// in all seasons if not valid), indexed by opponent.
+//
+func FetchPairHistories(tx *pop.Connection, userID string, seasonID nulls.UUID) (histories map[string]PairHistory, fetchError error) {
+	var pairHistories []PairHistory
+
+	seasonCondition, seasonArgs := inSeason(seasonID)
+	args := append([]interface{}{userID}, seasonArgs...)
+	args = append(append(args, userID), seasonArgs...)
+	fetchError = tx.RawQuery(`SELECT opponent_id, SUM(won) AS sets_won, SUM(lost) AS sets_lost, MAX(updated_at) AS last_played_at FROM (
+		SELECT user2_id AS opponent_id, user1_sets AS won, user2_sets AS lost, updated_at FROM scores WHERE user1_id = ? AND `+seasonCondition+`
+		UNION ALL
+		SELECT user1_id AS opponent_id, user2_sets AS won, user1_sets AS lost, updated_at FROM scores WHERE user2_id = ? AND `+seasonCondition+`
+	) AS user_scores GROUP BY opponent_id`, args...).All(&pairHistories)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+
+	histories = make(map[string]PairHistory)
+	for _, pairHistory := range pairHistories {
+		histories[pairHistory.OpponentID] = pairHistory
+	}
+	return histories, nil
+}
+
+// roundRatio rounds a ratio to 3 decimals.
+//
+func roundRatio(ratio float64) (roundedRatio float64) {
+	return math.Round(ratio*1000) / 1000
+}
@@ -0,0 +1,40 @@
+package matchmaking
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// TestSuggestOpponents tests SuggestOpponents function ranking candidates by fairness.
+//
+func TestSuggestOpponents(t *testing.T) {
+	assertHandler := assert.New(t)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	ratings := map[string]float64{"newcomer": 990, "veteran": 1300, "regular": 1000, "rival": 1010}
+	histories := map[string]PairHistory{
+		"veteran": {OpponentID: "veteran", SetsWon: 0, SetsLost: 6, LastPlayedAt: now.Add(-time.Hour)},
+		"rival":   {OpponentID: "rival", SetsWon: 2, SetsLost: 2, LastPlayedAt: now.Add(-7 * 24 * time.Hour)},
+	}
+	candidates := []string{"newcomer", "veteran", "regular", "rival", "stranger"}
+
+	suggestions := SuggestOpponents("newcomer", candidates, ratings, histories, now, 10)
+	assertHandler.Len(suggestions, 4, "Suggestions: all candidates but requested user should be suggested")
+
+	rankedUsers := []string{}
+	for _, suggestion := range suggestions {
+		rankedUsers = append(rankedUsers, suggestion.UserID)
+	}
+	// regular is close and never played, stranger is unrated (1000) too, rival played a week ago, veteran always wins
+	assertHandler.Equal([]string{"regular", "stranger", "rival", "veteran"}, rankedUsers, "Suggestions: candidates not ranked as expected")
+
+	assertHandler.Equal(1000, suggestions[1].Rating, "Unrated candidate: rating should be initial rating")
+	assertHandler.False(suggestions[1].LastPlayedAt.Valid, "Never played candidate: last played time should be null")
+	assertHandler.Equal(2, suggestions[2].SetsWon, "Rival: sets won against rival not returned as expected")
+	// Rival: rating closeness of 0.942, half of recency period elapsed, balanced sets
+	assertHandler.Equal(0.846, suggestions[2].Fairness, "Rival: fairness not computed as expected")
+	assertHandler.True(suggestions[3].WinProbability < 0.2, "Veteran: newcomer should be unlikely to win")
+
+	assertHandler.Len(SuggestOpponents("newcomer", candidates, ratings, histories, now, 2), 2, "Suggestions: limit should be respected")
+}
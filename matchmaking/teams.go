@@ -0,0 +1,53 @@
+package matchmaking
+
+import (
+	"math"
+)
+
+// Team represents two users playing together, rated by average rating of its users.
+//
+type Team struct {
+	UserIDs [2]string `json:"user_ids"`
+	Rating  int       `json:"rating"`
+}
+
+// TeamSplit represents a 2v2 match between two teams.
+//
+// WinProbability is the probability that first team wins a set against second team.
+//
+type TeamSplit struct {
+	Teams          [2]Team `json:"teams"`
+	WinProbability float64 `json:"win_probability"`
+}
+
+// FairTeams splits four users into the two teams of two whose ratings are the closest.
+//
+// First user always belongs to first team: among the 3 possible splits, first one is kept in case of tie.
+//
+func FairTeams(users [4]string, ratings map[string]float64) (fairestSplit TeamSplit) {
+	var smallestGap = math.Inf(1)
+
+	for _, teammate := range []int{1, 2, 3} {
+		var opponents []string
+		for index := 1; index < len(users); index++ {
+			if index != teammate {
+				opponents = append(opponents, users[index])
+			}
+		}
+
+		firstRating := (Rating(ratings, users[0]) + Rating(ratings, users[teammate])) / 2
+		secondRating := (Rating(ratings, opponents[0]) + Rating(ratings, opponents[1])) / 2
+		if gap := math.Abs(firstRating - secondRating); gap < smallestGap {
+			smallestGap = gap
+			fairestSplit = TeamSplit{
+				Teams: [2]Team{
+					{UserIDs: [2]string{users[0], users[teammate]}, Rating: int(math.Round(firstRating))},
+					{UserIDs: [2]string{opponents[0], opponents[1]}, Rating: int(math.Round(secondRating))},
+				},
+				WinProbability: roundRatio(WinProbability(firstRating, secondRating)),
+			}
+		}
+	}
+
+	return fairestSplit
+}
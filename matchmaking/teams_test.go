@@ -0,0 +1,22 @@
+package matchmaking
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// TestFairTeams tests FairTeams function pairing strongest and weakest users together.
+//
+func TestFairTeams(t *testing.T) {
+	assertHandler := assert.New(t)
+	ratings := map[string]float64{"user1": 1300, "user2": 1200, "user3": 900, "user4": 800}
+
+	split := FairTeams([4]string{"user1", "user2", "user3", "user4"}, ratings)
+	assertHandler.Equal([2]string{"user1", "user4"}, split.Teams[0].UserIDs, "Fair teams: strongest user should play with weakest one")
+	assertHandler.Equal([2]string{"user2", "user3"}, split.Teams[1].UserIDs, "Fair teams: other users should play together")
+	assertHandler.Equal(1050, split.Teams[0].Rating, "Fair teams: team rating should be average of its users ratings")
+	assertHandler.Equal(0.5, split.WinProbability, "Fair teams: both teams should be as likely to win")
+
+	split = FairTeams([4]string{"user1", "user2", "user3", "user5"}, ratings)
+	assertHandler.Equal([2]string{"user1", "user3"}, split.Teams[0].UserIDs, "Unrated user: user should be rated with initial rating")
+}
@@ -0,0 +1,403 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the content type of metrics exposed in Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// namespace is the CloudWatch namespace of metrics flushed by Lambda functions.
+const namespace = "Foosball"
+
+// maxEMFValues is the maximum number of values of a metric in one CloudWatch embedded metric format document.
+const maxEMFValues = 100
+
+// defaultBuckets are the upper bounds (in seconds) of buckets of latency histograms.
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics of scoring service.
+var (
+	GoalsTotal              = NewCounter("foosball_goals_total", "Goals stored, by kind.", "kind")
+	SetsFinishedTotal       = NewCounter("foosball_sets_finished_total", "Sets finished by a stored goal.")
+	ValidationFailuresTotal = NewCounter("foosball_validation_failures_total", "Goals refused as they break foosball rules or API constraints, by reason.", "reason")
+	DBErrorsTotal           = NewCounter("foosball_db_errors_total", "Database errors raised while storing goals, by operation.", "operation")
+	HandlerDuration         = NewHistogram("foosball_handler_duration_seconds", "Time spent handling requests, by handler.", defaultBuckets, "handler")
+)
+
+// collector is implemented by metrics written in Prometheus text format, and flushed in CloudWatch embedded metric format.
+//
+type collector interface {
+	write(output io.Writer)
+	flush(output io.Writer, function string, at time.Time)
+}
+
+// registry lists all metrics created, in order of creation.
+var registry struct {
+	mutex      sync.Mutex
+	collectors []collector
+}
+
+// Counter represents a monotonically increasing value, one per combination of label values.
+//
+type Counter struct {
+	mutex      sync.Mutex
+	name       string
+	help       string
+	labelNames []string
+	values     map[string]float64
+	flushed    map[string]float64
+	labelSets  map[string][]string
+}
+
+// Histogram represents distribution of observed values in buckets, one per combination of label values.
+//
+type Histogram struct {
+	mutex      sync.Mutex
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+	series     map[string]*histogramSeries
+}
+
+// histogramSeries represents observations of a histogram for one combination of label values.
+//
+type histogramSeries struct {
+	labelValues         []string
+	bucketCounts        []uint64
+	count               uint64
+	sum                 float64
+	flushedBucketCounts []uint64
+	flushedCount        uint64
+}
+
+// NewCounter creates and registers a counter.
+//
+func NewCounter(name string, help string, labelNames ...string) (counter *Counter) {
+	counter = &Counter{name: name, help: help, labelNames: labelNames, values: make(map[string]float64), flushed: make(map[string]float64), labelSets: make(map[string][]string)}
+	if len(labelNames) == 0 {
+		counter.values[""] = 0
+	}
+	register(counter)
+	return counter
+}
+
+// NewHistogram creates and registers a histogram with submitted buckets (sorted upper bounds).
+//
+func NewHistogram(name string, help string, buckets []float64, labelNames ...string) (histogram *Histogram) {
+	histogram = &Histogram{name: name, help: help, labelNames: labelNames, buckets: buckets, series: make(map[string]*histogramSeries)}
+	register(histogram)
+	return histogram
+}
+
+// register adds a metric to registry.
+//
+func register(metric collector) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.collectors = append(registry.collectors, metric)
+}
+
+// Inc increments counter of submitted label values (in order of label names) by 1.
+//
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments counter of submitted label values (in order of label names) by submitted value.
+//
+func (c *Counter) Add(value float64, labelValues ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	labels := formatLabels(c.labelNames, labelValues)
+	c.values[labels] += value
+	c.labelSets[labels] = labelValues
+}
+
+// Value returns current value of counter of submitted label values.
+//
+func (c *Counter) Value(labelValues ...string) (value float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.values[formatLabels(c.labelNames, labelValues)]
+}
+
+// write writes counter in Prometheus text format.
+//
+func (c *Counter) write(output io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	fmt.Fprintf(output, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, labels := range sortedKeys(c.values) {
+		fmt.Fprintf(output, "%s%s %s\n", c.name, labels, formatValue(c.values[labels]))
+	}
+}
+
+// flush writes increments of counter since last flush in CloudWatch embedded metric format.
+//
+func (c *Counter) flush(output io.Writer, function string, at time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, labels := range sortedKeys(c.values) {
+		increment := c.values[labels] - c.flushed[labels]
+		if increment == 0 {
+			continue
+		}
+		writeEMF(output, function, at, c.labelNames, c.labelSets[labels], c.name, "Count", increment)
+		c.flushed[labels] = c.values[labels]
+	}
+}
+
+// Observe records a value in histogram of submitted label values (in order of label names).
+//
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	labels := formatLabels(h.labelNames, labelValues)
+	series, seriesExists := h.series[labels]
+	if !seriesExists {
+		series = &histogramSeries{labelValues: labelValues, bucketCounts: make([]uint64, len(h.buckets)), flushedBucketCounts: make([]uint64, len(h.buckets))}
+		h.series[labels] = series
+	}
+	for index, upperBound := range h.buckets {
+		if value <= upperBound {
+			series.bucketCounts[index]++
+		}
+	}
+	series.count++
+	series.sum += value
+}
+
+// ObserveSince records time elapsed since start (in seconds) in histogram of submitted label values.
+//
+func (h *Histogram) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// Count returns number of values recorded in histogram of submitted label values.
+//
+func (h *Histogram) Count(labelValues ...string) (count uint64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if series, seriesExists := h.series[formatLabels(h.labelNames, labelValues)]; seriesExists {
+		return series.count
+	}
+	return 0
+}
+
+// write writes histogram in Prometheus text format, buckets being cumulative.
+//
+func (h *Histogram) write(output io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	fmt.Fprintf(output, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	labelsList := make([]string, 0, len(h.series))
+	for labels := range h.series {
+		labelsList = append(labelsList, labels)
+	}
+	sort.Strings(labelsList)
+
+	for _, labels := range labelsList {
+		series := h.series[labels]
+		for index, upperBound := range h.buckets {
+			fmt.Fprintf(output, "%s_bucket%s %d\n", h.name, withLabel(labels, "le", formatValue(upperBound)), series.bucketCounts[index])
+		}
+		fmt.Fprintf(output, "%s_bucket%s %d\n", h.name, withLabel(labels, "le", "+Inf"), series.count)
+		fmt.Fprintf(output, "%s_sum%s %s\n", h.name, labels, formatValue(series.sum))
+		fmt.Fprintf(output, "%s_count%s %d\n", h.name, labels, series.count)
+	}
+}
+
+// flush writes values observed in histogram since last flush in CloudWatch embedded metric format.
+//
+// Buckets do not keep observed values: each value is written as midpoint of its bucket (as lower bound of its bucket
+// when above all upper bounds), at most maxEMFValues values per document.
+//
+func (h *Histogram) flush(output io.Writer, function string, at time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	unit := "None"
+	if strings.HasSuffix(h.name, "_seconds") {
+		unit = "Seconds"
+	}
+	labelsList := make([]string, 0, len(h.series))
+	for labels := range h.series {
+		labelsList = append(labelsList, labels)
+	}
+	sort.Strings(labelsList)
+
+	for _, labels := range labelsList {
+		series := h.series[labels]
+		var values []float64
+		var previousCount, previousFlushedCount uint64
+		lowerBound := 0.0
+		for index, upperBound := range h.buckets {
+			newValues := (series.bucketCounts[index] - previousCount) - (series.flushedBucketCounts[index] - previousFlushedCount)
+			for value := uint64(0); value < newValues; value++ {
+				values = append(values, (lowerBound+upperBound)/2)
+			}
+			previousCount, previousFlushedCount = series.bucketCounts[index], series.flushedBucketCounts[index]
+			lowerBound = upperBound
+		}
+		newValues := (series.count - previousCount) - (series.flushedCount - previousFlushedCount)
+		for value := uint64(0); value < newValues; value++ {
+			values = append(values, lowerBound)
+		}
+
+		for start := 0; start < len(values); start += maxEMFValues {
+			end := start + maxEMFValues
+			if end > len(values) {
+				end = len(values)
+			}
+			writeEMF(output, function, at, h.labelNames, series.labelValues, h.name, unit, values[start:end])
+		}
+		copy(series.flushedBucketCounts, series.bucketCounts)
+		series.flushedCount = series.count
+	}
+}
+
+// WriteText writes all metrics in Prometheus text format.
+//
+func WriteText(output io.Writer) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	for _, metric := range registry.collectors {
+		metric.write(output)
+	}
+}
+
+// Handler serves all metrics in Prometheus text format, to be scraped by Prometheus.
+//
+func Handler() (metricsHandler http.Handler) {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", ContentType)
+		WriteText(writer)
+	})
+}
+
+// Instrument records time spent by submitted handler on each request in HandlerDuration histogram.
+//
+func Instrument(handlerName string, handler http.Handler) (instrumentedHandler http.Handler) {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		defer HandlerDuration.ObserveSince(time.Now(), handlerName)
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+// Flush writes increments of all metrics since last flush to standard output, in CloudWatch embedded metric format,
+// with submitted function name and labels of metrics as dimensions.
+//
+// Lambda functions cannot be scraped: they flush their metrics at the end of each invocation instead, CloudWatch
+// extracting metrics from logs asynchronously (so that no request waits for metrics to be sent) and summing them
+// across Lambda containers.
+//
+func Flush(function string) {
+	flushAll(os.Stdout, function, time.Now())
+}
+
+// flushAll writes increments of all metrics since last flush in CloudWatch embedded metric format.
+//
+func flushAll(output io.Writer, function string, at time.Time) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	for _, metric := range registry.collectors {
+		metric.flush(output, function, at)
+	}
+}
+
+// writeEMF writes one value (or a list of values) of a metric as a CloudWatch embedded metric format document,
+// on a single line.
+//
+func writeEMF(output io.Writer, function string, at time.Time, labelNames []string, labelValues []string, name string, unit string, value interface{}) {
+	dimensions := append([]string{"function"}, labelNames...)
+	document := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": at.UnixNano() / int64(time.Millisecond),
+			"CloudWatchMetrics": []map[string]interface{}{{
+				"Namespace":  namespace,
+				"Dimensions": [][]string{dimensions},
+				"Metrics":    []map[string]string{{"Name": name, "Unit": unit}},
+			}},
+		},
+		"function": function,
+		name:       value,
+	}
+	for index, labelName := range labelNames {
+		document[labelName] = ""
+		if index < len(labelValues) {
+			document[labelName] = labelValues[index]
+		}
+	}
+
+	documentInJSON, marshalError := json.Marshal(document)
+	if marshalError != nil {
+		log.Printf("Failed to flush metrics: %s", marshalError)
+		return
+	}
+	fmt.Fprintf(output, "%s\n", documentInJSON)
+}
+
+// formatLabels formats label names and values as in Prometheus text format (empty when metric has no label).
+//
+func formatLabels(labelNames []string, labelValues []string) (labels string) {
+	if len(labelNames) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(labelNames))
+	for index, labelName := range labelNames {
+		labelValue := ""
+		if index < len(labelValues) {
+			labelValue = labelValues[index]
+		}
+		pairs[index] = fmt.Sprintf("%s=\"%s\"", labelName, escapeLabelValue(labelValue))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLabel adds a label to formatted labels.
+//
+func withLabel(labels string, labelName string, labelValue string) (extendedLabels string) {
+	pair := fmt.Sprintf("%s=\"%s\"", labelName, escapeLabelValue(labelValue))
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return strings.TrimSuffix(labels, "}") + "," + pair + "}"
+}
+
+// escapeLabelValue escapes backslashes, double quotes and line feeds of label values.
+//
+func escapeLabelValue(labelValue string) (escapedValue string) {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labelValue)
+}
+
+// formatValue formats a sample value as in Prometheus text format.
+//
+func formatValue(value float64) (formattedValue string) {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// sortedKeys returns formatted labels of counter values, sorted so that output is stable.
+//
+func sortedKeys(values map[string]float64) (keys []string) {
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
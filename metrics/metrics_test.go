@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCounterWrite tests counters written in Prometheus text format.
+//
+func TestCounterWrite(t *testing.T) {
+	assertHandler := assert.New(t)
+	var output bytes.Buffer
+
+	counter := &Counter{name: "test_goals_total", help: "Goals.", labelNames: []string{"kind"}, values: make(map[string]float64), flushed: make(map[string]float64), labelSets: make(map[string][]string)}
+	counter.Inc("gamelle")
+	counter.Inc("classic")
+	counter.Add(2, "classic")
+	counter.Inc(`de"mi`)
+	counter.write(&output)
+
+	assertHandler.Equal(`# HELP test_goals_total Goals.
+# TYPE test_goals_total counter
+test_goals_total{kind="classic"} 3
+test_goals_total{kind="de\"mi"} 1
+test_goals_total{kind="gamelle"} 1
+`, output.String(), "Counter should be written with one sample per label value, sorted")
+	assertHandler.Equal(float64(3), counter.Value("classic"), "Counter value should be returned")
+}
+
+// TestCounterWithoutLabels tests counters without labels, written even before being incremented.
+//
+func TestCounterWithoutLabels(t *testing.T) {
+	var output bytes.Buffer
+
+	counter := &Counter{name: "test_sets_total", help: "Sets.", values: map[string]float64{"": 0}}
+	counter.write(&output)
+	assert.Contains(t, output.String(), "\ntest_sets_total 0\n", "Counter without labels should start at 0")
+}
+
+// TestHistogramWrite tests histograms written in Prometheus text format, with cumulative buckets.
+//
+func TestHistogramWrite(t *testing.T) {
+	assertHandler := assert.New(t)
+	var output bytes.Buffer
+
+	histogram := &Histogram{name: "test_duration_seconds", help: "Durations.", labelNames: []string{"handler"}, buckets: []float64{0.1, 1}, series: make(map[string]*histogramSeries)}
+	histogram.Observe(0.05, "StoreGoal")
+	histogram.Observe(0.5, "StoreGoal")
+	histogram.Observe(3, "StoreGoal")
+	histogram.write(&output)
+
+	assertHandler.Equal(`# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{handler="StoreGoal",le="0.1"} 1
+test_duration_seconds_bucket{handler="StoreGoal",le="1"} 2
+test_duration_seconds_bucket{handler="StoreGoal",le="+Inf"} 3
+test_duration_seconds_sum{handler="StoreGoal"} 3.55
+test_duration_seconds_count{handler="StoreGoal"} 3
+`, output.String(), "Histogram should be written with cumulative buckets, sum and count")
+	assertHandler.Equal(uint64(3), histogram.Count("StoreGoal"), "Histogram count should be returned")
+	assertHandler.Equal(uint64(0), histogram.Count("Export"), "Histogram count of unknown labels should be 0")
+}
+
+// TestHandler tests Handler function exposing registered metrics.
+//
+func TestHandler(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	GoalsTotal.Inc("pissette")
+	recorder := httptest.NewRecorder()
+	Instrument("Metrics", Handler()).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assertHandler.Equal(http.StatusOK, recorder.Code, "Metrics should be served")
+	assertHandler.Equal(ContentType, recorder.Header().Get("Content-Type"), "Metrics should be served in Prometheus text format")
+	assertHandler.Contains(recorder.Body.String(), `foosball_goals_total{kind="pissette"}`, "Registered counters should be served")
+	assertHandler.Contains(recorder.Body.String(), "# TYPE foosball_handler_duration_seconds histogram", "Registered histograms should be served")
+	assertHandler.Equal(uint64(1), HandlerDuration.Count("Metrics"), "Instrumented handler should be timed")
+}
+
+// TestFlush tests flushed metrics written in CloudWatch embedded metric format, only with increments since last flush.
+//
+func TestFlush(t *testing.T) {
+	assertHandler := assert.New(t)
+	var output bytes.Buffer
+	var documents []map[string]interface{}
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	counter := &Counter{name: "test_goals_total", help: "Goals.", labelNames: []string{"kind"}, values: make(map[string]float64), flushed: make(map[string]float64), labelSets: make(map[string][]string)}
+	histogram := &Histogram{name: "test_duration_seconds", help: "Durations.", labelNames: []string{"handler"}, buckets: []float64{0.1, 1}, series: make(map[string]*histogramSeries)}
+	counter.Add(2, "gamelle")
+	histogram.Observe(0.05, "StoreGoal")
+	histogram.Observe(3, "StoreGoal")
+	counter.flush(&output, "StoreGoal", at)
+	histogram.flush(&output, "StoreGoal", at)
+
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var document map[string]interface{}
+		assertHandler.Nil(json.Unmarshal([]byte(line), &document), "Each flushed line should be a JSON document")
+		documents = append(documents, document)
+	}
+	assertHandler.Len(documents, 2, "One document should be flushed per metric and label values")
+	assertHandler.Equal(map[string]interface{}{
+		"Timestamp": float64(at.UnixNano() / int64(time.Millisecond)),
+		"CloudWatchMetrics": []interface{}{map[string]interface{}{
+			"Namespace":  "Foosball",
+			"Dimensions": []interface{}{[]interface{}{"function", "kind"}},
+			"Metrics":    []interface{}{map[string]interface{}{"Name": "test_goals_total", "Unit": "Count"}},
+		}},
+	}, documents[0]["_aws"], "Counter should be flushed with function and labels as dimensions")
+	assertHandler.Equal("StoreGoal", documents[0]["function"], "Counter should be flushed with function name")
+	assertHandler.Equal("gamelle", documents[0]["kind"], "Counter should be flushed with label values")
+	assertHandler.Equal(float64(2), documents[0]["test_goals_total"], "Counter increment should be flushed")
+	assertHandler.Equal([]interface{}{0.05, float64(1)}, documents[1]["test_duration_seconds"], "Histogram values should be flushed as midpoints of their buckets")
+
+	output.Reset()
+	counter.flush(&output, "StoreGoal", at)
+	histogram.flush(&output, "StoreGoal", at)
+	assertHandler.Empty(output.String(), "Metrics should not be flushed again without new increments")
+
+	counter.Inc("gamelle")
+	histogram.Observe(0.5, "StoreGoal")
+	counter.flush(&output, "StoreGoal", at)
+	histogram.flush(&output, "StoreGoal", at)
+	assertHandler.Contains(output.String(), `"test_goals_total":1`, "Only counter increment since last flush should be flushed")
+	assertHandler.Contains(output.String(), `"test_duration_seconds":[0.55]`, "Only histogram values since last flush should be flushed")
+}
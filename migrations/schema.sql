@@ -34,6 +34,127 @@ SET default_tablespace = '';
 
 SET default_with_oids = false;
 
+--
+-- Name: achievements; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.achievements (
+    id uuid NOT NULL,
+    user_id character varying(255) NOT NULL,
+    code character varying(255) NOT NULL,
+    unlocked_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE public.achievements OWNER TO foosball;
+
+--
+-- Name: challenges; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.challenges (
+    id uuid NOT NULL,
+    challenger_id character varying(255) NOT NULL,
+    defender_id character varying(255) NOT NULL,
+    status character varying(255) NOT NULL,
+    deadline timestamp without time zone NOT NULL,
+    match_id uuid,
+    winner_id character varying(255) DEFAULT ''::character varying NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE public.challenges OWNER TO foosball;
+
+--
+-- Name: goals; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.goals (
+    id uuid NOT NULL,
+    score_id uuid NOT NULL,
+    scorer_id character varying(255) NOT NULL,
+    opponent_id character varying(255) NOT NULL,
+    player character varying(255) NOT NULL,
+    gamelle boolean DEFAULT false NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL,
+    kind character varying(255) DEFAULT 'classic'::character varying NOT NULL,
+    balance_cashed integer DEFAULT 0 NOT NULL,
+    set_finished boolean DEFAULT false NOT NULL,
+    season_id uuid,
+    match_id uuid,
+    session_id uuid,
+    handicapped boolean DEFAULT false NOT NULL,
+    session_seconds integer
+);
+
+
+ALTER TABLE public.goals OWNER TO foosball;
+
+--
+-- Name: ladder_ranks; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.ladder_ranks (
+    id uuid NOT NULL,
+    user_id character varying(255) NOT NULL,
+    rank integer NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE public.ladder_ranks OWNER TO foosball;
+
+--
+-- Name: matches; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.matches (
+    id uuid NOT NULL,
+    tournament_id uuid,
+    round integer DEFAULT 0 NOT NULL,
+    position integer DEFAULT 0 NOT NULL,
+    user1_id character varying(255) DEFAULT ''::character varying NOT NULL,
+    user2_id character varying(255) DEFAULT ''::character varying NOT NULL,
+    winner_id character varying(255) DEFAULT ''::character varying NOT NULL,
+    status character varying(255) NOT NULL,
+    sets_to_win integer DEFAULT 2 NOT NULL,
+    next_match_id uuid,
+    next_match_slot integer DEFAULT 0 NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL,
+    user1_points integer DEFAULT 0 NOT NULL,
+    user2_points integer DEFAULT 0 NOT NULL,
+    bracket character varying(255) DEFAULT 'main'::character varying NOT NULL,
+    group_number integer DEFAULT 0 NOT NULL,
+    loser_match_id uuid,
+    loser_match_slot integer DEFAULT 0 NOT NULL
+);
+
+
+ALTER TABLE public.matches OWNER TO foosball;
+
+--
+-- Name: queue_entries; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.queue_entries (
+    id uuid NOT NULL,
+    user_id character varying(255) NOT NULL,
+    status character varying(255) NOT NULL,
+    position integer NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE public.queue_entries OWNER TO foosball;
+
 --
 -- Name: schema_migration; Type: TABLE; Schema: public; Owner: foosball
 --
@@ -59,12 +180,216 @@ CREATE TABLE public.scores (
     updated_at timestamp without time zone NOT NULL,
     user1_sets integer DEFAULT 0 NOT NULL,
     user2_sets integer DEFAULT 0 NOT NULL,
-    goals_in_balance integer DEFAULT 0 NOT NULL
+    goals_in_balance integer DEFAULT 0 NOT NULL,
+    season_id uuid,
+    match_id uuid,
+    user1_handicap_points integer DEFAULT 0 NOT NULL,
+    user2_handicap_points integer DEFAULT 0 NOT NULL,
+    user1_goal_multiplier integer DEFAULT 0 NOT NULL,
+    user2_goal_multiplier integer DEFAULT 0 NOT NULL
 );
 
 
 ALTER TABLE public.scores OWNER TO foosball;
 
+--
+-- Name: season_standings; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.season_standings (
+    id uuid NOT NULL,
+    season_id uuid NOT NULL,
+    user_id character varying(255) NOT NULL,
+    rank integer NOT NULL,
+    sets_won integer NOT NULL,
+    sets_lost integer NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE public.season_standings OWNER TO foosball;
+
+--
+-- Name: seasons; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.seasons (
+    id uuid NOT NULL,
+    name character varying(255) NOT NULL,
+    starts_at timestamp without time zone NOT NULL,
+    ends_at timestamp without time zone NOT NULL,
+    closed_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE public.seasons OWNER TO foosball;
+
+--
+-- Name: sessions; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.sessions (
+    id uuid NOT NULL,
+    user1_id character varying(255) NOT NULL,
+    user2_id character varying(255) NOT NULL,
+    status character varying(255) NOT NULL,
+    started_at timestamp without time zone NOT NULL,
+    paused_at timestamp without time zone,
+    paused_seconds integer DEFAULT 0 NOT NULL,
+    ended_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE public.sessions OWNER TO foosball;
+
+--
+-- Name: streaks; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.streaks (
+    id uuid NOT NULL,
+    user_id character varying(255) NOT NULL,
+    current_wins integer DEFAULT 0 NOT NULL,
+    current_losses integer DEFAULT 0 NOT NULL,
+    best_wins integer DEFAULT 0 NOT NULL,
+    best_losses integer DEFAULT 0 NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE public.streaks OWNER TO foosball;
+
+--
+-- Name: tournament_participants; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.tournament_participants (
+    id uuid NOT NULL,
+    tournament_id uuid NOT NULL,
+    user_id character varying(255) NOT NULL,
+    seed integer DEFAULT 0 NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL,
+    group_number integer DEFAULT 0 NOT NULL
+);
+
+
+ALTER TABLE public.tournament_participants OWNER TO foosball;
+
+--
+-- Name: tournaments; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.tournaments (
+    id uuid NOT NULL,
+    name character varying(255) NOT NULL,
+    format character varying(255) NOT NULL,
+    status character varying(255) NOT NULL,
+    sets_to_win integer DEFAULT 2 NOT NULL,
+    winner_id character varying(255) DEFAULT ''::character varying NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL,
+    home_and_away boolean DEFAULT false NOT NULL,
+    rounds integer DEFAULT 0 NOT NULL,
+    group_size integer DEFAULT 0 NOT NULL,
+    qualifiers_per_group integer DEFAULT 0 NOT NULL
+);
+
+
+ALTER TABLE public.tournaments OWNER TO foosball;
+
+--
+-- Name: webhook_deliveries; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.webhook_deliveries (
+    id uuid NOT NULL,
+    webhook_id uuid NOT NULL,
+    event character varying(255) NOT NULL,
+    payload text NOT NULL,
+    status character varying(255) NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    next_attempt_at timestamp without time zone NOT NULL,
+    response_status integer DEFAULT 0 NOT NULL,
+    last_error text DEFAULT ''::text NOT NULL,
+    delivered_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE public.webhook_deliveries OWNER TO foosball;
+
+--
+-- Name: webhooks; Type: TABLE; Schema: public; Owner: foosball
+--
+
+CREATE TABLE public.webhooks (
+    id uuid NOT NULL,
+    url character varying(255) NOT NULL,
+    secret character varying(255) NOT NULL,
+    events character varying(255) NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE public.webhooks OWNER TO foosball;
+
+--
+-- Name: achievements achievements_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.achievements
+    ADD CONSTRAINT achievements_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: challenges challenges_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.challenges
+    ADD CONSTRAINT challenges_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: goals goals_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.goals
+    ADD CONSTRAINT goals_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: ladder_ranks ladder_ranks_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.ladder_ranks
+    ADD CONSTRAINT ladder_ranks_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: matches matches_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.matches
+    ADD CONSTRAINT matches_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: queue_entries queue_entries_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.queue_entries
+    ADD CONSTRAINT queue_entries_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: scores scores_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
 --
@@ -73,6 +398,126 @@ ALTER TABLE ONLY public.scores
     ADD CONSTRAINT scores_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: season_standings season_standings_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.season_standings
+    ADD CONSTRAINT season_standings_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: seasons seasons_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.seasons
+    ADD CONSTRAINT seasons_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: sessions sessions_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.sessions
+    ADD CONSTRAINT sessions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: streaks streaks_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.streaks
+    ADD CONSTRAINT streaks_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: tournament_participants tournament_participants_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.tournament_participants
+    ADD CONSTRAINT tournament_participants_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: tournaments tournaments_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.tournaments
+    ADD CONSTRAINT tournaments_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: webhook_deliveries webhook_deliveries_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.webhook_deliveries
+    ADD CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: webhooks webhooks_pkey; Type: CONSTRAINT; Schema: public; Owner: foosball
+--
+
+ALTER TABLE ONLY public.webhooks
+    ADD CONSTRAINT webhooks_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: achievements_user_id_code_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE UNIQUE INDEX achievements_user_id_code_idx ON public.achievements USING btree (user_id, code);
+
+
+--
+-- Name: challenges_status_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE INDEX challenges_status_idx ON public.challenges USING btree (status);
+
+
+--
+-- Name: goals_created_at_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE INDEX goals_created_at_idx ON public.goals USING btree (created_at);
+
+
+--
+-- Name: goals_scorer_id_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE INDEX goals_scorer_id_idx ON public.goals USING btree (scorer_id);
+
+
+--
+-- Name: goals_session_id_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE INDEX goals_session_id_idx ON public.goals USING btree (session_id);
+
+
+--
+-- Name: ladder_ranks_user_id_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE UNIQUE INDEX ladder_ranks_user_id_idx ON public.ladder_ranks USING btree (user_id);
+
+
+--
+-- Name: matches_tournament_id_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE INDEX matches_tournament_id_idx ON public.matches USING btree (tournament_id);
+
+
+--
+-- Name: queue_entries_user_id_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE UNIQUE INDEX queue_entries_user_id_idx ON public.queue_entries USING btree (user_id);
+
+
 --
 -- Name: schema_migration_version_idx; Type: INDEX; Schema: public; Owner: foosball
 --
@@ -80,6 +525,48 @@ ALTER TABLE ONLY public.scores
 CREATE UNIQUE INDEX schema_migration_version_idx ON public.schema_migration USING btree (version);
 
 
+--
+-- Name: season_standings_season_id_user_id_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE UNIQUE INDEX season_standings_season_id_user_id_idx ON public.season_standings USING btree (season_id, user_id);
+
+
+--
+-- Name: sessions_status_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE INDEX sessions_status_idx ON public.sessions USING btree (status);
+
+
+--
+-- Name: streaks_user_id_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE UNIQUE INDEX streaks_user_id_idx ON public.streaks USING btree (user_id);
+
+
+--
+-- Name: tournament_participants_tournament_id_user_id_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE UNIQUE INDEX tournament_participants_tournament_id_user_id_idx ON public.tournament_participants USING btree (tournament_id, user_id);
+
+
+--
+-- Name: webhook_deliveries_status_next_attempt_at_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE INDEX webhook_deliveries_status_next_attempt_at_idx ON public.webhook_deliveries USING btree (status, next_attempt_at);
+
+
+--
+-- Name: webhook_deliveries_webhook_id_idx; Type: INDEX; Schema: public; Owner: foosball
+--
+
+CREATE INDEX webhook_deliveries_webhook_id_idx ON public.webhook_deliveries USING btree (webhook_id);
+
+
 --
 -- PostgreSQL database dump complete
 --
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"log"
+	"time"
+)
+
+// Achievement represents a badge unlocked by one user.
+//
+type Achievement struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	UserId     string    `json:"user_id" db:"user_id"`
+	Code       string    `json:"code" db:"code"`
+	UnlockedAt time.Time `json:"unlocked_at" db:"unlocked_at"`
+}
+
+// String returns string representation of Achievement.
+//
+func (a Achievement) String() (achievementString string) {
+	ja, marshalError := json.Marshal(a)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(ja)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (a *Achievement) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: a.UserId, Name: "UserId"},
+		&validators.StringIsPresent{Field: a.Code, Name: "Code"},
+		&validators.TimeIsPresent{Field: a.UnlockedAt, Name: "UnlockedAt"},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+func (a *Achievement) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (a *Achievement) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
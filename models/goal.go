@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"log"
+	"time"
+)
+
+// Field positions of players, from goalkeeper to forwards.
+const (
+	PositionGoalkeeper = "goalkeeper"
+	PositionDefender   = "defender"
+	PositionDemi       = "demi"
+	PositionPissette   = "pissette"
+	PositionForward    = "forward"
+)
+
+// Kinds of goals, according to foosball rules.
+const (
+	GoalKindClassic     = "classic"
+	GoalKindGamelle     = "gamelle"
+	GoalKindDemi        = "demi"
+	GoalKindDemiGamelle = "demi_gamelle"
+	GoalKindPissette    = "pissette"
+)
+
+// GoalKindSetResult is the kind of goals recording a finished set imported from history, whose goals are unknown:
+// winner of set is recorded as scorer, without player.
+const GoalKindSetResult = "set_result"
+
+// Positions lists all field positions, from goalkeeper to forwards.
+var Positions = [...]string{PositionGoalkeeper, PositionDefender, PositionDemi, PositionPissette, PositionForward}
+
+var playerPositions = map[string]string{
+	"p1":  PositionGoalkeeper,
+	"p2":  PositionDefender,
+	"p3":  PositionDefender,
+	"p4":  PositionDemi,
+	"p5":  PositionDemi,
+	"p6":  PositionDemi,
+	"p7":  PositionDemi,
+	"p8":  PositionDemi,
+	"p9":  PositionPissette,
+	"p10": PositionForward,
+	"p11": PositionForward,
+}
+
+// Goal represents one goal stored by API, as it was submitted.
+//
+// Handicapped flags goals scored while a handicap applied to their score (see Score), so that sets they finish
+// can be told apart from even sets.
+// SessionSeconds is the time played in session of goal (pauses excluded) when it was scored, if goal has a session.
+//
+type Goal struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	ScoreID        uuid.UUID  `json:"score_id" db:"score_id"`
+	ScorerId       string     `json:"scorer_id" db:"scorer_id"`
+	OpponentId     string     `json:"opponent_id" db:"opponent_id"`
+	Player         string     `json:"player" db:"player"`
+	Gamelle        bool       `json:"gamelle" db:"gamelle"`
+	Kind           string     `json:"kind" db:"kind"`
+	BalanceCashed  int        `json:"balance_cashed" db:"balance_cashed"`
+	SetFinished    bool       `json:"set_finished" db:"set_finished"`
+	SeasonID       nulls.UUID `json:"season_id" db:"season_id"`
+	MatchID        nulls.UUID `json:"match_id" db:"match_id"`
+	SessionID      nulls.UUID `json:"session_id" db:"session_id"`
+	SessionSeconds nulls.Int  `json:"session_seconds" db:"session_seconds"`
+	Handicapped    bool       `json:"handicapped" db:"handicapped"`
+}
+
+// PlayerPosition returns field position of submitted player ("" if player does not exist).
+//
+func PlayerPosition(player string) (position string) {
+	return playerPositions[player]
+}
+
+// Position returns field position of player who scored the goal.
+//
+func (g Goal) Position() (position string) {
+	return PlayerPosition(g.Player)
+}
+
+// String returns string representation of Goal.
+//
+func (g Goal) String() (goalString string) {
+	jg, marshalError := json.Marshal(g)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(jg)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (g *Goal) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	goalValidators := []validate.Validator{
+		&validators.StringIsPresent{Field: g.ScorerId, Name: "ScorerId"},
+		&validators.StringIsPresent{Field: g.OpponentId, Name: "OpponentId"},
+		&validators.StringInclusion{Field: g.Kind, Name: "Kind", List: []string{GoalKindClassic, GoalKindGamelle, GoalKindDemi, GoalKindDemiGamelle, GoalKindPissette, GoalKindSetResult}},
+	}
+	if g.Kind != GoalKindSetResult {
+		goalValidators = append(goalValidators, &validators.StringIsPresent{Field: g.Player, Name: "Player"})
+	}
+	return validate.Validate(goalValidators...), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+func (g *Goal) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (g *Goal) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"log"
+	"time"
+)
+
+// Statuses of ladder challenges.
+const (
+	ChallengeStatusPending   = "pending"
+	ChallengeStatusAccepted  = "accepted"
+	ChallengeStatusDeclined  = "declined"
+	ChallengeStatusCompleted = "completed"
+	ChallengeStatusForfeited = "forfeited"
+)
+
+// LadderRank represents place of one user in the ladder (rank 1 being the top of the ladder).
+//
+type LadderRank struct {
+	ID        uuid.UUID `json:"-" db:"id"`
+	CreatedAt time.Time `json:"-" db:"created_at"`
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+	UserId    string    `json:"user_id" db:"user_id"`
+	Rank      int       `json:"rank" db:"rank"`
+}
+
+// Challenge represents a challenge issued by a user of the ladder to a user ranked above them.
+//
+// Deadline is the time before which defender must answer a pending challenge, or before which match of an accepted
+// challenge must be played. Challenge match is created when challenge is accepted.
+// Winner is only known once challenge is completed or forfeited.
+//
+type Challenge struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	ChallengerId string     `json:"challenger_id" db:"challenger_id"`
+	DefenderId   string     `json:"defender_id" db:"defender_id"`
+	Status       string     `json:"status" db:"status"`
+	Deadline     time.Time  `json:"deadline" db:"deadline"`
+	MatchID      nulls.UUID `json:"match_id" db:"match_id"`
+	WinnerId     string     `json:"winner_id" db:"winner_id"`
+}
+
+// IsOpen checks if challenge is still waiting for an answer or for its match to be played.
+//
+func (c Challenge) IsOpen() (openChallenge bool) {
+	return c.Status == ChallengeStatusPending || c.Status == ChallengeStatusAccepted
+}
+
+// String returns string representation of LadderRank.
+//
+func (l LadderRank) String() (ladderRankString string) {
+	jl, marshalError := json.Marshal(l)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(jl)
+}
+
+// String returns string representation of Challenge.
+//
+func (c Challenge) String() (challengeString string) {
+	jc, marshalError := json.Marshal(c)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(jc)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (l *LadderRank) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: l.UserId, Name: "UserId"},
+		&validators.IntIsGreaterThan{Field: l.Rank, Name: "Rank", Compared: 0},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+func (l *LadderRank) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (l *LadderRank) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (c *Challenge) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: c.ChallengerId, Name: "ChallengerId"},
+		&validators.StringIsPresent{Field: c.DefenderId, Name: "DefenderId"},
+		&validators.StringInclusion{Field: c.Status, Name: "Status", List: []string{ChallengeStatusPending, ChallengeStatusAccepted, ChallengeStatusDeclined, ChallengeStatusCompleted, ChallengeStatusForfeited}},
+		&validators.TimeIsPresent{Field: c.Deadline, Name: "Deadline"},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+func (c *Challenge) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (c *Challenge) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"log"
+	"time"
+)
+
+// Statuses of matches.
+const (
+	MatchStatusPending  = "pending"
+	MatchStatusReady    = "ready"
+	MatchStatusFinished = "finished"
+)
+
+// Brackets of matches.
+//
+// Main bracket holds all matches of single-stage formats, winners bracket of double-elimination tournaments
+// and knockout of group-stage-plus-knockout tournaments.
+const (
+	MatchBracketGroups = "groups"
+	MatchBracketMain   = "main"
+	MatchBracketLosers = "losers"
+)
+
+// MatchBrackets lists all available brackets of matches, in display order.
+var MatchBrackets = []string{MatchBracketGroups, MatchBracketMain, MatchBracketLosers}
+
+// Match represents a match between two users, won by first user to win a given number of sets.
+//
+// A match can belong to a tournament: in this case, its winner will play next match (in submitted slot).
+// Users are empty as long as they are not known (or when a user gets a bye).
+// Points scored by each user in finished sets are accumulated, to be used as tiebreaker in standings.
+// Rounds and positions are numbered within bracket of match. Group stage matches also hold their group (starting from 1).
+// In a double-elimination tournament, loser of a match can also play a next match (in losers bracket).
+//
+type Match struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	TournamentID   nulls.UUID `json:"tournament_id" db:"tournament_id"`
+	Round          int        `json:"round" db:"round"`
+	Position       int        `json:"position" db:"position"`
+	User1Id        string     `json:"user1_id" db:"user1_id"`
+	User2Id        string     `json:"user2_id" db:"user2_id"`
+	WinnerId       string     `json:"winner_id" db:"winner_id"`
+	Status         string     `json:"status" db:"status"`
+	SetsToWin      int        `json:"sets_to_win" db:"sets_to_win"`
+	NextMatchID    nulls.UUID `json:"next_match_id" db:"next_match_id"`
+	NextMatchSlot  int        `json:"next_match_slot" db:"next_match_slot"`
+	User1Points    int        `json:"user1_points" db:"user1_points"`
+	User2Points    int        `json:"user2_points" db:"user2_points"`
+	Bracket        string     `json:"bracket" db:"bracket"`
+	GroupNumber    int        `json:"group_number" db:"group_number"`
+	LoserMatchID   nulls.UUID `json:"loser_match_id" db:"loser_match_id"`
+	LoserMatchSlot int        `json:"loser_match_slot" db:"loser_match_slot"`
+}
+
+// PlaceUser places user in submitted slot (1 or 2) and updates match status accordingly.
+//
+func (m *Match) PlaceUser(slot int, userID string) {
+	switch slot {
+	case 1:
+		m.User1Id = userID
+	case 2:
+		m.User2Id = userID
+	}
+
+	if m.Status == MatchStatusPending && m.User1Id != "" && m.User2Id != "" {
+		m.Status = MatchStatusReady
+	}
+}
+
+// RecordSetPoints adds points scored by submitted user in a finished set.
+//
+func (m *Match) RecordSetPoints(userID string, points int) {
+	switch userID {
+	case m.User1Id:
+		m.User1Points += points
+	case m.User2Id:
+		m.User2Points += points
+	}
+}
+
+// UserPoints returns points scored by submitted user in finished sets of match.
+//
+func (m Match) UserPoints(userID string) (userPoints int) {
+	switch userID {
+	case m.User1Id:
+		return m.User1Points
+	case m.User2Id:
+		return m.User2Points
+	}
+	return 0
+}
+
+// HasUsers checks if match is played between both submitted users (in any order).
+//
+func (m Match) HasUsers(firstUserID string, secondUserID string) (sameUsers bool) {
+	return (m.User1Id == firstUserID && m.User2Id == secondUserID) || (m.User1Id == secondUserID && m.User2Id == firstUserID)
+}
+
+// Opponent returns opponent of submitted user in match.
+//
+func (m Match) Opponent(userID string) (opponentID string) {
+	if m.User1Id == userID {
+		return m.User2Id
+	}
+	return m.User1Id
+}
+
+// Winner checks if submitted score of match designates a winner (first user to win required number of sets).
+//
+func (m Match) Winner(matchScore Score) (winnerID string, finished bool) {
+	switch {
+	case matchScore.User1Sets >= m.SetsToWin:
+		return matchScore.User1Id, true
+	case matchScore.User2Sets >= m.SetsToWin:
+		return matchScore.User2Id, true
+	}
+	return "", false
+}
+
+// String returns string representation of Match.
+//
+func (m Match) String() (matchString string) {
+	jm, marshalError := json.Marshal(m)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(jm)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (m *Match) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringInclusion{Field: m.Status, Name: "Status", List: []string{MatchStatusPending, MatchStatusReady, MatchStatusFinished}},
+		&validators.StringInclusion{Field: m.Bracket, Name: "Bracket", List: MatchBrackets},
+		&validators.IntIsGreaterThan{Field: m.SetsToWin, Name: "SetsToWin", Compared: 0},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+func (m *Match) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (m *Match) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
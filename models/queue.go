@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"log"
+	"time"
+)
+
+// Statuses of queue entries.
+const (
+	QueueStatusWaiting = "waiting"
+	QueueStatusPlaying = "playing"
+)
+
+// QueueEntry represents a user in queue of the table, either playing at the table or waiting for their turn.
+//
+// Waiting users play by ascending position: users going back to the queue get a position after all other users.
+//
+type QueueEntry struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	UserId    string    `json:"user_id" db:"user_id"`
+	Status    string    `json:"status" db:"status"`
+	Position  int       `json:"position" db:"position"`
+}
+
+// String returns string representation of QueueEntry.
+//
+func (q QueueEntry) String() (queueEntryString string) {
+	jq, marshalError := json.Marshal(q)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(jq)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (q *QueueEntry) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: q.UserId, Name: "UserId"},
+		&validators.StringInclusion{Field: q.Status, Name: "Status", List: []string{QueueStatusWaiting, QueueStatusPlaying}},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+// It checks that user is not already in queue.
+//
+func (q *QueueEntry) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	validatorErrors = validate.NewErrors()
+
+	alreadyQueued, validationError := tx.Where("user_id = ?", q.UserId).Exists(QueueEntry{})
+	if validationError != nil {
+		return validatorErrors, validationError
+	}
+	if alreadyQueued {
+		validatorErrors.Add("user_id", "User is already in queue")
+	}
+
+	return validatorErrors, nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (q *QueueEntry) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"github.com/gobuffalo/nulls"
 	"github.com/gobuffalo/pop"
 	"github.com/gobuffalo/validate"
 	"github.com/gobuffalo/validate/validators"
@@ -10,19 +11,34 @@ import (
 	"time"
 )
 
+// pointsToWinSet is the number of points a user must reach to win a set.
+const pointsToWinSet int = 10
+
+// MaxGoalMultiplier is the highest goal multiplier of a handicap.
+const MaxGoalMultiplier int = 3
+
 // Score represents current status of foosball match between two users.
 //
+// An optional handicap gives each user starting points in every set and/or multiplies points of their "classic" goals
+// (a goal multiplier of 0 meaning no multiplier, as 1 does).
+//
 type Score struct {
-	ID             uuid.UUID `json:"id" db:"id"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
-	User1Id        string    `json:"user1_id" db:"user1_id"`
-	User2Id        string    `json:"user2_id" db:"user2_id"`
-	User1Points    int       `json:"user1_points" db:"user1_points"`
-	User2Points    int       `json:"user2_points" db:"user2_points"`
-	User1Sets      int       `json:"user1_sets" db:"user1_sets"`
-	User2Sets      int       `json:"user2_sets" db:"user2_sets"`
-	GoalsInBalance int       `json:"goals_in_balance" db:"goals_in_balance"`
+	ID                  uuid.UUID  `json:"id" db:"id"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+	User1Id             string     `json:"user1_id" db:"user1_id"`
+	User2Id             string     `json:"user2_id" db:"user2_id"`
+	User1Points         int        `json:"user1_points" db:"user1_points"`
+	User2Points         int        `json:"user2_points" db:"user2_points"`
+	User1Sets           int        `json:"user1_sets" db:"user1_sets"`
+	User2Sets           int        `json:"user2_sets" db:"user2_sets"`
+	GoalsInBalance      int        `json:"goals_in_balance" db:"goals_in_balance"`
+	SeasonID            nulls.UUID `json:"season_id" db:"season_id"`
+	MatchID             nulls.UUID `json:"match_id" db:"match_id"`
+	User1HandicapPoints int        `json:"user1_handicap_points" db:"user1_handicap_points"`
+	User2HandicapPoints int        `json:"user2_handicap_points" db:"user2_handicap_points"`
+	User1GoalMultiplier int        `json:"user1_goal_multiplier" db:"user1_goal_multiplier"`
+	User2GoalMultiplier int        `json:"user2_goal_multiplier" db:"user2_goal_multiplier"`
 }
 
 // ScorePoints add points to submitted scorer.
@@ -38,10 +54,73 @@ func (s *Score) ScorePoints(scorerID string, pointsToAdd int) {
 
 // IsSetFinished check if current set is finished.
 //
+// Handicap points of users are added to their points, as if each set started with them.
+//
 func (s *Score) IsSetFinished() (finishedSet bool) {
-	const pointsToWinSet int = 10
+	return s.User1Points+s.User1HandicapPoints >= pointsToWinSet || s.User2Points+s.User2HandicapPoints >= pointsToWinSet
+}
+
+// IsHandicapped checks if a handicap applies to score.
+//
+func (s *Score) IsHandicapped() (handicappedScore bool) {
+	return s.User1HandicapPoints > 0 || s.User2HandicapPoints > 0 || s.User1GoalMultiplier > 1 || s.User2GoalMultiplier > 1
+}
+
+// HandicapPoints returns points with which submitted user starts every set (0 without handicap).
+//
+func (s *Score) HandicapPoints(userID string) (handicapPoints int) {
+	switch userID {
+	case s.User1Id:
+		return s.User1HandicapPoints
+	case s.User2Id:
+		return s.User2HandicapPoints
+	}
+	return 0
+}
+
+// GoalMultiplier returns number by which points of "classic" goals of submitted user are multiplied (1 without handicap).
+//
+func (s *Score) GoalMultiplier(userID string) (multiplier int) {
+	switch userID {
+	case s.User1Id:
+		multiplier = s.User1GoalMultiplier
+	case s.User2Id:
+		multiplier = s.User2GoalMultiplier
+	}
+	if multiplier < 1 {
+		return 1
+	}
+	return multiplier
+}
 
-	return s.User1Points >= pointsToWinSet || s.User2Points >= pointsToWinSet
+// SetsPlayed returns number of sets finished between both users.
+//
+func (s *Score) SetsPlayed() (setsPlayed int) {
+	return s.User1Sets + s.User2Sets
+}
+
+// UserPoints returns number of points of submitted user in current set.
+//
+func (s *Score) UserPoints(userID string) (userPoints int) {
+	switch userID {
+	case s.User1Id:
+		return s.User1Points
+	case s.User2Id:
+		return s.User2Points
+	}
+	return 0
+}
+
+// UserSets returns number of sets won by submitted user.
+//
+func (s *Score) UserSets(userID string) (userSets int) {
+	switch userID {
+	case s.User1Id:
+		return s.User1Sets
+	case s.User2Id:
+		return s.User2Sets
+	}
+	return 0
 }
 
 // ChangeSet add 1 set to the winner and set points and balance to 0.
@@ -76,6 +155,14 @@ func (s *Score) Validate(tx *pop.Connection) (validatorErrors *validate.Errors,
 	return validate.Validate(
 		&validators.StringIsPresent{Field: s.User1Id, Name: "User1Id"},
 		&validators.StringIsPresent{Field: s.User2Id, Name: "User2Id"},
+		&validators.IntIsGreaterThan{Field: s.User1HandicapPoints, Name: "User1HandicapPoints", Compared: -1},
+		&validators.IntIsLessThan{Field: s.User1HandicapPoints, Name: "User1HandicapPoints", Compared: pointsToWinSet},
+		&validators.IntIsGreaterThan{Field: s.User2HandicapPoints, Name: "User2HandicapPoints", Compared: -1},
+		&validators.IntIsLessThan{Field: s.User2HandicapPoints, Name: "User2HandicapPoints", Compared: pointsToWinSet},
+		&validators.IntIsGreaterThan{Field: s.User1GoalMultiplier, Name: "User1GoalMultiplier", Compared: -1},
+		&validators.IntIsLessThan{Field: s.User1GoalMultiplier, Name: "User1GoalMultiplier", Compared: MaxGoalMultiplier + 1},
+		&validators.IntIsGreaterThan{Field: s.User2GoalMultiplier, Name: "User2GoalMultiplier", Compared: -1},
+		&validators.IntIsLessThan{Field: s.User2GoalMultiplier, Name: "User2GoalMultiplier", Compared: MaxGoalMultiplier + 1},
 	), nil
 }
 
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"log"
+	"sort"
+	"time"
+)
+
+// Season represents a period of time during which scores are counted together.
+//
+type Season struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	Name      string     `json:"name" db:"name"`
+	StartsAt  time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt    time.Time  `json:"ends_at" db:"ends_at"`
+	ClosedAt  nulls.Time `json:"closed_at" db:"closed_at"`
+}
+
+// SeasonStanding represents final position of one user at the end of a season.
+//
+type SeasonStanding struct {
+	ID        uuid.UUID `json:"-" db:"id"`
+	CreatedAt time.Time `json:"-" db:"created_at"`
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+	SeasonID  uuid.UUID `json:"-" db:"season_id"`
+	UserId    string    `json:"user_id" db:"user_id"`
+	Rank      int       `json:"rank" db:"rank"`
+	SetsWon   int       `json:"sets_won" db:"sets_won"`
+	SetsLost  int       `json:"sets_lost" db:"sets_lost"`
+}
+
+// FindActiveSeason retrieves season running at submitted time, if any.
+//
+// A season keeps running after its end date until it is closed, so that goals scored before anyone closed it are still
+// counted in a season (next season cannot be created before, see ValidateCreate).
+// No season is found only before first season starts, or between a closed season and next one.
+//
+func FindActiveSeason(tx *pop.Connection, at time.Time) (activeSeason Season, activeSeasonExists bool, findError error) {
+	activeSeasonQuery := tx.Where("starts_at <= ? AND closed_at IS NULL", at).Order("starts_at DESC")
+
+	activeSeasonExists, findError = activeSeasonQuery.Exists(Season{})
+	if findError != nil || !activeSeasonExists {
+		return activeSeason, false, findError
+	}
+
+	findError = activeSeasonQuery.First(&activeSeason)
+	return activeSeason, findError == nil, findError
+}
+
+// ComputeStandings ranks users according to sets won and lost in submitted scores.
+//
+// Users are ranked by set difference, then by sets won, then by user id (to keep ranking stable).
+//
+func ComputeStandings(scores []Score) (standings []SeasonStanding) {
+	var standingsByUser = make(map[string]*SeasonStanding)
+
+	for _, score := range scores {
+		for _, userID := range []string{score.User1Id, score.User2Id} {
+			if _, existingStanding := standingsByUser[userID]; !existingStanding {
+				standingsByUser[userID] = &SeasonStanding{UserId: userID}
+			}
+		}
+		standingsByUser[score.User1Id].SetsWon += score.User1Sets
+		standingsByUser[score.User1Id].SetsLost += score.User2Sets
+		standingsByUser[score.User2Id].SetsWon += score.User2Sets
+		standingsByUser[score.User2Id].SetsLost += score.User1Sets
+	}
+
+	standings = []SeasonStanding{}
+	for _, standing := range standingsByUser {
+		standings = append(standings, *standing)
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		iDifference := standings[i].SetsWon - standings[i].SetsLost
+		jDifference := standings[j].SetsWon - standings[j].SetsLost
+		if iDifference != jDifference {
+			return iDifference > jDifference
+		}
+		if standings[i].SetsWon != standings[j].SetsWon {
+			return standings[i].SetsWon > standings[j].SetsWon
+		}
+		return standings[i].UserId < standings[j].UserId
+	})
+
+	for position := range standings {
+		standings[position].Rank = position + 1
+	}
+
+	return standings
+}
+
+// IsClosed checks if season has been closed.
+//
+func (s Season) IsClosed() (closedSeason bool) {
+	return s.ClosedAt.Valid
+}
+
+// String returns string representation of Season.
+//
+func (s Season) String() (seasonString string) {
+	js, marshalError := json.Marshal(s)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(js)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (s *Season) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: s.Name, Name: "Name"},
+		&validators.TimeIsPresent{Field: s.StartsAt, Name: "StartsAt"},
+		&validators.TimeIsPresent{Field: s.EndsAt, Name: "EndsAt"},
+		&validators.TimeAfterTime{FirstTime: s.EndsAt, FirstName: "EndsAt", SecondTime: s.StartsAt, SecondName: "StartsAt"},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+// It checks that no other season is still running: seasons run until they are closed, whatever their end date, so
+// that a new season would overlap any season which is not closed yet.
+//
+func (s *Season) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	validatorErrors = validate.NewErrors()
+
+	unclosedSeasonExists, validationError := tx.Where("closed_at IS NULL").Exists(Season{})
+	if validationError != nil {
+		return validatorErrors, validationError
+	}
+	if unclosedSeasonExists {
+		validatorErrors.Add("starts_at", "Another season is not closed yet: close it first (next season can start when closing it)")
+	}
+
+	return validatorErrors, nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (s *Season) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (s *SeasonStanding) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: s.UserId, Name: "UserId"},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+func (s *SeasonStanding) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (s *SeasonStanding) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
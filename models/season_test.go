@@ -0,0 +1,31 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// TestComputeStandings tests ComputeStandings function for several scores between users.
+//
+func TestComputeStandings(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	assertHandler.Equal([]SeasonStanding{}, ComputeStandings(nil), "No score: standings should be empty")
+
+	scores := []Score{
+		{User1Id: "user1", User2Id: "user2", User1Sets: 3, User2Sets: 1},
+		{User1Id: "user3", User2Id: "user1", User1Sets: 2, User2Sets: 2},
+		{User1Id: "user2", User2Id: "user3", User1Sets: 4, User2Sets: 2},
+		{User1Id: "user4", User2Id: "user5", User1Sets: 1, User2Sets: 1},
+	}
+
+	awaitedStandings := []SeasonStanding{
+		{UserId: "user1", Rank: 1, SetsWon: 5, SetsLost: 3},
+		{UserId: "user2", Rank: 2, SetsWon: 5, SetsLost: 5},
+		{UserId: "user4", Rank: 3, SetsWon: 1, SetsLost: 1},
+		{UserId: "user5", Rank: 4, SetsWon: 1, SetsLost: 1},
+		{UserId: "user3", Rank: 5, SetsWon: 4, SetsLost: 6},
+	}
+
+	assertHandler.Equal(awaitedStandings, ComputeStandings(scores), "Several scores: standings not computed as expected")
+}
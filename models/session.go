@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"log"
+	"time"
+)
+
+// Statuses of match sessions.
+const (
+	SessionStatusPlaying = "playing"
+	SessionStatusPaused  = "paused"
+	SessionStatusEnded   = "ended"
+)
+
+// Session represents a match being played right now at the table between two users, from its start to its end.
+//
+// Time spent in pause is accumulated in PausedSeconds when session is resumed, PausedAt being set while session is paused.
+//
+type Session struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	User1Id       string     `json:"user1_id" db:"user1_id"`
+	User2Id       string     `json:"user2_id" db:"user2_id"`
+	Status        string     `json:"status" db:"status"`
+	StartedAt     time.Time  `json:"started_at" db:"started_at"`
+	PausedAt      nulls.Time `json:"paused_at" db:"paused_at"`
+	PausedSeconds int        `json:"paused_seconds" db:"paused_seconds"`
+	EndedAt       nulls.Time `json:"ended_at" db:"ended_at"`
+}
+
+// FindActiveSession retrieves session not ended yet between submitted users, if any.
+//
+func FindActiveSession(tx *pop.Connection, user1ID string, user2ID string) (activeSession Session, activeSessionExists bool, findError error) {
+	activeSessionQuery := tx.Where("(user1_id = ? AND user2_id = ? OR user1_id = ? AND user2_id = ?)", user1ID, user2ID, user2ID, user1ID)
+	activeSessionQuery = activeSessionQuery.Where("status != ?", SessionStatusEnded)
+
+	activeSessionExists, findError = activeSessionQuery.Exists(Session{})
+	if findError != nil || !activeSessionExists {
+		return activeSession, false, findError
+	}
+
+	findError = activeSessionQuery.First(&activeSession)
+	return activeSession, findError == nil, findError
+}
+
+// Elapsed returns time played in session at submitted time, pauses excluded.
+//
+// Time stops running while session is paused, and once it is ended.
+//
+func (s Session) Elapsed(at time.Time) (elapsed time.Duration) {
+	switch {
+	case s.EndedAt.Valid:
+		at = s.EndedAt.Time
+	case s.PausedAt.Valid:
+		at = s.PausedAt.Time
+	}
+
+	elapsed = at.Sub(s.StartedAt) - time.Duration(s.PausedSeconds)*time.Second
+	if elapsed < 0 {
+		return 0
+	}
+	return elapsed
+}
+
+// String returns string representation of Session.
+//
+func (s Session) String() (sessionString string) {
+	js, marshalError := json.Marshal(s)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(js)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (s *Session) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: s.User1Id, Name: "User1Id"},
+		&validators.StringIsPresent{Field: s.User2Id, Name: "User2Id"},
+		&validators.StringInclusion{Field: s.Status, Name: "Status", List: []string{SessionStatusPlaying, SessionStatusPaused, SessionStatusEnded}},
+		&validators.TimeIsPresent{Field: s.StartedAt, Name: "StartedAt"},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+// It checks that session is played between two different users, and that no other session is in progress between them.
+//
+func (s *Session) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	validatorErrors = validate.NewErrors()
+
+	if s.User1Id == s.User2Id {
+		validatorErrors.Add("user2_id", "Users of a session must be different")
+		return validatorErrors, nil
+	}
+
+	_, activeSessionExists, validationError := FindActiveSession(tx, s.User1Id, s.User2Id)
+	if validationError != nil {
+		return validatorErrors, validationError
+	}
+	if activeSessionExists {
+		validatorErrors.Add("user1_id", "A session is already in progress between both users")
+	}
+
+	return validatorErrors, nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (s *Session) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
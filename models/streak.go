@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"log"
+	"time"
+)
+
+// Streak represents current and record series of sets won and lost in a row by one user.
+//
+type Streak struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	UserId        string    `json:"user_id" db:"user_id"`
+	CurrentWins   int       `json:"current_wins" db:"current_wins"`
+	CurrentLosses int       `json:"current_losses" db:"current_losses"`
+	BestWins      int       `json:"best_wins" db:"best_wins"`
+	BestLosses    int       `json:"best_losses" db:"best_losses"`
+}
+
+// RecordSetWon extends current win streak (updating record if needed) and breaks current loss streak.
+//
+func (s *Streak) RecordSetWon() {
+	s.CurrentWins++
+	s.CurrentLosses = 0
+
+	if s.CurrentWins > s.BestWins {
+		s.BestWins = s.CurrentWins
+	}
+}
+
+// RecordSetLost extends current loss streak (updating record if needed) and breaks current win streak.
+//
+func (s *Streak) RecordSetLost() {
+	s.CurrentLosses++
+	s.CurrentWins = 0
+
+	if s.CurrentLosses > s.BestLosses {
+		s.BestLosses = s.CurrentLosses
+	}
+}
+
+// String returns string representation of Streak.
+//
+func (s Streak) String() (streakString string) {
+	js, marshalError := json.Marshal(s)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(js)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (s *Streak) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: s.UserId, Name: "UserId"},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+func (s *Streak) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (s *Streak) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// TestStreakRecordSets tests Streak updates for successive sets won and lost.
+//
+func TestStreakRecordSets(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	streak := Streak{UserId: "user1"}
+
+	streak.RecordSetWon()
+	streak.RecordSetWon()
+	assertHandler.Equal(Streak{UserId: "user1", CurrentWins: 2, BestWins: 2}, streak, "Two sets won: streak not updated as expected")
+
+	streak.RecordSetLost()
+	assertHandler.Equal(Streak{UserId: "user1", CurrentLosses: 1, BestWins: 2, BestLosses: 1}, streak, "Set lost after win streak: streak not updated as expected")
+
+	streak.RecordSetWon()
+	assertHandler.Equal(Streak{UserId: "user1", CurrentWins: 1, BestWins: 2, BestLosses: 1}, streak, "Set won after loss: record win streak should be kept")
+
+	streak.RecordSetWon()
+	streak.RecordSetWon()
+	assertHandler.Equal(Streak{UserId: "user1", CurrentWins: 3, BestWins: 3, BestLosses: 1}, streak, "Longer win streak: record win streak should be updated")
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"log"
+	"time"
+)
+
+// Formats of tournaments.
+const (
+	TournamentFormatSingleElimination = "single_elimination"
+	TournamentFormatRoundRobin        = "round_robin"
+	TournamentFormatSwiss             = "swiss"
+	TournamentFormatDoubleElimination = "double_elimination"
+	TournamentFormatGroupsKnockout    = "groups_knockout"
+)
+
+// TournamentFormats lists all available formats of tournaments.
+var TournamentFormats = []string{
+	TournamentFormatSingleElimination,
+	TournamentFormatRoundRobin,
+	TournamentFormatSwiss,
+	TournamentFormatDoubleElimination,
+	TournamentFormatGroupsKnockout,
+}
+
+// Statuses of tournaments.
+const (
+	TournamentStatusRegistration = "registration"
+	TournamentStatusRunning      = "running"
+	TournamentStatusFinished     = "finished"
+)
+
+// Tournament represents a competition between several users, played through matches.
+//
+// HomeAndAway only applies to round-robin tournaments (leagues): each participant then plays every other one twice.
+// Rounds only applies to Swiss-system tournaments, which are played in a fixed number of rounds.
+// GroupSize and QualifiersPerGroup only apply to group-stage-plus-knockout tournaments: participants are split
+// into groups of (at most) GroupSize, and the first QualifiersPerGroup of each group table play the knockout.
+//
+type Tournament struct {
+	ID                 uuid.UUID `json:"id" db:"id"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+	Name               string    `json:"name" db:"name"`
+	Format             string    `json:"format" db:"format"`
+	Status             string    `json:"status" db:"status"`
+	SetsToWin          int       `json:"sets_to_win" db:"sets_to_win"`
+	WinnerId           string    `json:"winner_id" db:"winner_id"`
+	HomeAndAway        bool      `json:"home_and_away" db:"home_and_away"`
+	Rounds             int       `json:"rounds" db:"rounds"`
+	GroupSize          int       `json:"group_size" db:"group_size"`
+	QualifiersPerGroup int       `json:"qualifiers_per_group" db:"qualifiers_per_group"`
+}
+
+// TournamentParticipant represents a user registered in a tournament.
+//
+// Seed is optional (0 for unseeded participant): seeded participants are placed first, by ascending seed.
+// GroupNumber is the group (starting from 1) of participant in group stage, once tournament is started.
+//
+type TournamentParticipant struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	TournamentID uuid.UUID `json:"tournament_id" db:"tournament_id"`
+	UserId       string    `json:"user_id" db:"user_id"`
+	Seed         int       `json:"seed" db:"seed"`
+	GroupNumber  int       `json:"group_number" db:"group_number"`
+}
+
+// String returns string representation of Tournament.
+//
+func (t Tournament) String() (tournamentString string) {
+	jt, marshalError := json.Marshal(t)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(jt)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (t *Tournament) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: t.Name, Name: "Name"},
+		&validators.StringInclusion{Field: t.Format, Name: "Format", List: TournamentFormats},
+		&validators.StringInclusion{Field: t.Status, Name: "Status", List: []string{TournamentStatusRegistration, TournamentStatusRunning, TournamentStatusFinished}},
+		&validators.IntIsGreaterThan{Field: t.SetsToWin, Name: "SetsToWin", Compared: 0},
+		&validators.IntIsGreaterThan{Field: t.Rounds, Name: "Rounds", Compared: -1},
+		&validators.IntIsGreaterThan{Field: t.GroupSize, Name: "GroupSize", Compared: -1},
+		&validators.IntIsGreaterThan{Field: t.QualifiersPerGroup, Name: "QualifiersPerGroup", Compared: -1},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+func (t *Tournament) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (t *Tournament) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (p *TournamentParticipant) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: p.UserId, Name: "UserId"},
+		&validators.IntIsGreaterThan{Field: p.Seed, Name: "Seed", Compared: -1},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+// It checks that user is not already registered in tournament.
+//
+func (p *TournamentParticipant) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	validatorErrors = validate.NewErrors()
+
+	alreadyRegistered, validationError := tx.Where("tournament_id = ? AND user_id = ?", p.TournamentID, p.UserId).Exists(TournamentParticipant{})
+	if validationError != nil {
+		return validatorErrors, validationError
+	}
+	if alreadyRegistered {
+		validatorErrors.Add("user_id", "User is already registered in tournament")
+	}
+
+	return validatorErrors, nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (p *TournamentParticipant) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"log"
+	"strings"
+	"time"
+)
+
+// Events sent to webhooks.
+const (
+	WebhookEventGoalRecorded  = "goal.recorded"
+	WebhookEventSetFinished   = "set.finished"
+	WebhookEventMatchFinished = "match.finished"
+)
+
+// Statuses of webhook deliveries.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed"
+)
+
+// WebhookEvents lists all events sent to webhooks.
+var WebhookEvents = [...]string{WebhookEventGoalRecorded, WebhookEventSetFinished, WebhookEventMatchFinished}
+
+// Webhook represents an URL registered to receive events (listed in Events, separated by commas).
+//
+// Secret is used to sign payloads sent to webhook, so that receivers can check their origin.
+//
+type Webhook struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"-" db:"secret"`
+	Events    string    `json:"events" db:"events"`
+}
+
+// WebhookDelivery represents one event sent (or to be sent) to a webhook, with result of its last attempt.
+//
+// Pending deliveries are attempted again from NextAttemptAt, until they are delivered or fail for good.
+//
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	WebhookID      uuid.UUID  `json:"webhook_id" db:"webhook_id"`
+	Event          string     `json:"event" db:"event"`
+	Payload        string     `json:"payload" db:"payload"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	ResponseStatus int        `json:"response_status" db:"response_status"`
+	LastError      string     `json:"last_error" db:"last_error"`
+	DeliveredAt    nulls.Time `json:"delivered_at" db:"delivered_at"`
+}
+
+// Subscribes checks if webhook receives submitted event.
+//
+func (w Webhook) Subscribes(event string) (subscribed bool) {
+	for _, webhookEvent := range strings.Split(w.Events, ",") {
+		if webhookEvent == event {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns string representation of Webhook.
+//
+func (w Webhook) String() (webhookString string) {
+	jw, marshalError := json.Marshal(w)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(jw)
+}
+
+// String returns string representation of WebhookDelivery.
+//
+func (d WebhookDelivery) String() (deliveryString string) {
+	jd, marshalError := json.Marshal(d)
+	if marshalError != nil {
+		log.Println(marshalError)
+		return ""
+	}
+	return string(jd)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (w *Webhook) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	validatorErrors = validate.Validate(
+		&validators.URLIsPresent{Field: w.URL, Name: "URL"},
+		&validators.StringIsPresent{Field: w.Secret, Name: "Secret"},
+		&validators.StringIsPresent{Field: w.Events, Name: "Events"},
+	)
+	for _, event := range strings.Split(w.Events, ",") {
+		validatorErrors.Append(validate.Validate(
+			&validators.StringInclusion{Field: event, Name: "Events", List: WebhookEvents[:]},
+		))
+	}
+	return validatorErrors, nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+func (w *Webhook) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (w *Webhook) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+//
+func (d *WebhookDelivery) Validate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Field: d.WebhookID, Name: "WebhookID"},
+		&validators.StringInclusion{Field: d.Event, Name: "Event", List: WebhookEvents[:]},
+		&validators.StringIsPresent{Field: d.Payload, Name: "Payload"},
+		&validators.StringInclusion{Field: d.Status, Name: "Status", List: []string{DeliveryStatusPending, DeliveryStatusDelivered, DeliveryStatusFailed}},
+		&validators.IntIsGreaterThan{Field: d.Attempts, Name: "Attempts", Compared: -1},
+	), nil
+}
+
+// ValidateCreate gets run every time you call "pop.ValidateAndCreate" method.
+//
+func (d *WebhookDelivery) ValidateCreate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
+
+// ValidateUpdate gets run every time you call "pop.ValidateAndUpdate" method.
+//
+func (d *WebhookDelivery) ValidateUpdate(tx *pop.Connection) (validatorErrors *validate.Errors, validationError error) {
+	return validate.NewErrors(), nil
+}
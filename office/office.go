@@ -0,0 +1,18 @@
+package office
+
+import (
+	"os"
+	"time"
+	// Timezone database is embedded in binaries, as Lambda runtimes do not provide it
+	_ "time/tzdata"
+)
+
+// Location returns timezone configured for office in environment variables (UTC by default).
+//
+func Location() (location *time.Location, locationError error) {
+	officeTimezone := os.Getenv("OFFICE_TIMEZONE")
+	if officeTimezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(officeTimezone)
+}
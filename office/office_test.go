@@ -0,0 +1,28 @@
+package office
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+// TestLocation tests Location function with default and configured timezones.
+//
+func TestLocation(t *testing.T) {
+	assertHandler := assert.New(t)
+	defer os.Unsetenv("OFFICE_TIMEZONE")
+
+	os.Unsetenv("OFFICE_TIMEZONE")
+	location, locationError := Location()
+	assertHandler.Nil(locationError, "Default timezone: Location function should not raise an error")
+	assertHandler.Equal("UTC", location.String(), "Default timezone: office should be in UTC")
+
+	os.Setenv("OFFICE_TIMEZONE", "Europe/Paris")
+	location, locationError = Location()
+	assertHandler.Nil(locationError, "Configured timezone: Location function should not raise an error")
+	assertHandler.Equal("Europe/Paris", location.String(), "Configured timezone: office timezone not loaded as expected")
+
+	os.Setenv("OFFICE_TIMEZONE", "Mars/Olympus")
+	_, locationError = Location()
+	assertHandler.NotNil(locationError, "Unknown timezone: Location function should raise an error")
+}
@@ -0,0 +1,279 @@
+package queue
+
+import (
+	"errors"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"os"
+	"sort"
+)
+
+// Modes of the table, deciding who leaves the table when a set is finished.
+//
+// In rotation mode, both users go back to the queue. In "king of the table" mode, winner stays at the table.
+const (
+	ModeRotation = "rotation"
+	ModeKing     = "king"
+)
+
+// tableSize is the number of users playing at the table.
+const tableSize = 2
+
+// ErrNotInQueue is raised when a user who is not in queue tries to leave it.
+var ErrNotInQueue = errors.New("user is not in queue")
+
+// Queue represents users playing at the table and users waiting for their turn (ordered by position).
+//
+// Next lists users who will play once current set is finished.
+//
+type Queue struct {
+	Mode    string              `json:"mode"`
+	Playing []models.QueueEntry `json:"playing"`
+	Waiting []models.QueueEntry `json:"waiting"`
+	Next    []string            `json:"next"`
+}
+
+// Mode returns mode of the table configured in environment variables (rotation by default).
+//
+func Mode() (mode string) {
+	if os.Getenv("QUEUE_MODE") == ModeKing {
+		return ModeKing
+	}
+	return ModeRotation
+}
+
+// BuildQueue splits queue entries between playing and waiting users, and computes who plays next according to mode.
+//
+func BuildQueue(entries []models.QueueEntry, mode string) (tableQueue Queue) {
+	tableQueue = Queue{Mode: mode, Playing: []models.QueueEntry{}, Waiting: []models.QueueEntry{}, Next: []string{}}
+
+	for _, entry := range sortedEntries(entries) {
+		if entry.Status == models.QueueStatusPlaying {
+			tableQueue.Playing = append(tableQueue.Playing, entry)
+		} else {
+			tableQueue.Waiting = append(tableQueue.Waiting, entry)
+		}
+	}
+
+	// Free places at the table are taken right away, so next users only replace users leaving the table after current set
+	nextCount := tableSize
+	if mode == ModeKing && len(tableQueue.Playing) == tableSize {
+		nextCount = 1
+	}
+	for index := 0; index < nextCount && index < len(tableQueue.Waiting); index++ {
+		tableQueue.Next = append(tableQueue.Next, tableQueue.Waiting[index].UserId)
+	}
+
+	return tableQueue
+}
+
+// RotateAfterSet sends users leaving the table after a set back to the queue, then fills the table (see FillTable).
+//
+// Nothing changes if winner and loser of set are not both playing at the table.
+// Updated entries are returned so that they can be saved.
+//
+func RotateAfterSet(entries []models.QueueEntry, winnerID string, loserID string, mode string) (updatedEntries []models.QueueEntry) {
+	var leavingUsers = []string{loserID}
+	var playingUsers = make(map[string]bool)
+
+	for _, entry := range entries {
+		if entry.Status == models.QueueStatusPlaying {
+			playingUsers[entry.UserId] = true
+		}
+	}
+	if !playingUsers[winnerID] || !playingUsers[loserID] {
+		return nil
+	}
+
+	if mode != ModeKing {
+		leavingUsers = []string{winnerID, loserID}
+	}
+
+	lastPosition := lastPosition(entries)
+	for _, leavingUser := range leavingUsers {
+		for index := range entries {
+			if entries[index].UserId == leavingUser {
+				lastPosition++
+				entries[index].Status = models.QueueStatusWaiting
+				entries[index].Position = lastPosition
+			}
+		}
+	}
+
+	updatedEntries = FillTable(entries)
+	for _, entry := range entries {
+		if entry.Status == models.QueueStatusWaiting && (entry.UserId == winnerID || entry.UserId == loserID) {
+			updatedEntries = append(updatedEntries, entry)
+		}
+	}
+	return updatedEntries
+}
+
+// FillTable lets first waiting users play until the table is full.
+//
+// Entries of users starting to play are returned so that they can be saved.
+//
+func FillTable(entries []models.QueueEntry) (updatedEntries []models.QueueEntry) {
+	var playingCount int
+
+	for _, entry := range entries {
+		if entry.Status == models.QueueStatusPlaying {
+			playingCount++
+		}
+	}
+
+	for _, entry := range sortedEntries(entries) {
+		if playingCount >= tableSize {
+			break
+		}
+		if entry.Status != models.QueueStatusWaiting {
+			continue
+		}
+		for index := range entries {
+			if entries[index].ID == entry.ID {
+				entries[index].Status = models.QueueStatusPlaying
+				updatedEntries = append(updatedEntries, entries[index])
+			}
+		}
+		playingCount++
+	}
+
+	return updatedEntries
+}
+
+// Fetch retrieves all queue entries and builds queue according to configured mode.
+//
+func Fetch(tx *pop.Connection) (tableQueue Queue, fetchError error) {
+	var entries []models.QueueEntry
+
+	fetchError = tx.Order("position").All(&entries)
+	if fetchError != nil {
+		return tableQueue, fetchError
+	}
+
+	return BuildQueue(entries, Mode()), nil
+}
+
+// Join adds user at the back of the queue, user playing right away if the table is not full.
+//
+// Validation errors (such as user already in queue) are returned separately, nothing being stored in this case.
+//
+func Join(tx *pop.Connection, userID string) (joinedEntry models.QueueEntry, validateError *validate.Errors, joinError error) {
+	var entries []models.QueueEntry
+
+	joinError = tx.All(&entries)
+	if joinError != nil {
+		return joinedEntry, nil, joinError
+	}
+
+	joinedEntry = models.QueueEntry{UserId: userID, Status: models.QueueStatusWaiting, Position: lastPosition(entries) + 1}
+	validateError, joinError = tx.ValidateAndCreate(&joinedEntry)
+	if joinError != nil || (validateError != nil && len(validateError.Errors) != 0) {
+		return joinedEntry, validateError, joinError
+	}
+
+	entries = append(entries, joinedEntry)
+	for _, updatedEntry := range FillTable(entries) {
+		joinError = saveEntry(tx, &updatedEntry)
+		if joinError != nil {
+			return joinedEntry, nil, joinError
+		}
+		if updatedEntry.ID == joinedEntry.ID {
+			joinedEntry = updatedEntry
+		}
+	}
+
+	return joinedEntry, nil, nil
+}
+
+// Leave removes user from queue, their place at the table (if any) being taken by first waiting user.
+//
+func Leave(tx *pop.Connection, userID string) (leaveError error) {
+	var entries []models.QueueEntry
+	var remainingEntries []models.QueueEntry
+	var leavingEntry *models.QueueEntry
+
+	leaveError = tx.All(&entries)
+	if leaveError != nil {
+		return leaveError
+	}
+	for index := range entries {
+		if entries[index].UserId == userID {
+			leavingEntry = &entries[index]
+		} else {
+			remainingEntries = append(remainingEntries, entries[index])
+		}
+	}
+	if leavingEntry == nil {
+		return ErrNotInQueue
+	}
+
+	leaveError = tx.Destroy(leavingEntry)
+	if leaveError != nil {
+		return leaveError
+	}
+
+	for _, updatedEntry := range FillTable(remainingEntries) {
+		leaveError = saveEntry(tx, &updatedEntry)
+		if leaveError != nil {
+			return leaveError
+		}
+	}
+	return nil
+}
+
+// RecordSet rotates users of the table once a set is finished between them (see RotateAfterSet).
+//
+func RecordSet(tx *pop.Connection, winnerID string, loserID string) (recordError error) {
+	var entries []models.QueueEntry
+
+	recordError = tx.All(&entries)
+	if recordError != nil {
+		return recordError
+	}
+
+	for _, updatedEntry := range RotateAfterSet(entries, winnerID, loserID, Mode()) {
+		recordError = saveEntry(tx, &updatedEntry)
+		if recordError != nil {
+			return recordError
+		}
+	}
+	return nil
+}
+
+// sortedEntries returns a copy of queue entries, sorted by ascending position.
+//
+func sortedEntries(entries []models.QueueEntry) (sorted []models.QueueEntry) {
+	sorted = append([]models.QueueEntry{}, entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Position < sorted[j].Position
+	})
+	return sorted
+}
+
+// lastPosition returns highest position of queue entries (0 for an empty queue).
+//
+func lastPosition(entries []models.QueueEntry) (position int) {
+	for _, entry := range entries {
+		if entry.Position > position {
+			position = entry.Position
+		}
+	}
+	return position
+}
+
+// saveEntry validates and saves a queue entry, validation errors being returned as an error.
+//
+func saveEntry(tx *pop.Connection, entry *models.QueueEntry) (saveError error) {
+	var validateError *validate.Errors
+
+	validateError, saveError = tx.ValidateAndSave(entry)
+	if saveError != nil {
+		return saveError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return validateError
+	}
+	return nil
+}
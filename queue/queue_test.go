@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+)
+
+// newEntries creates queue entries of submitted users, first two users playing and others waiting in submitted order.
+//
+func newEntries(users ...string) (entries []models.QueueEntry) {
+	for index, user := range users {
+		entry := models.QueueEntry{ID: uuid.Must(uuid.NewV4()), UserId: user, Status: models.QueueStatusWaiting, Position: index + 1}
+		if index < tableSize {
+			entry.Status = models.QueueStatusPlaying
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// usersOf returns users of queue entries, in the same order.
+//
+func usersOf(entries []models.QueueEntry) (users []string) {
+	users = []string{}
+	for _, entry := range entries {
+		users = append(users, entry.UserId)
+	}
+	return users
+}
+
+// TestBuildQueue tests BuildQueue function computing who plays next in each mode.
+//
+func TestBuildQueue(t *testing.T) {
+	assertHandler := assert.New(t)
+	entries := newEntries("user1", "user2", "user3", "user4", "user5")
+
+	tableQueue := BuildQueue(entries, ModeRotation)
+	assertHandler.Equal([]string{"user1", "user2"}, usersOf(tableQueue.Playing), "Queue: first users should be playing")
+	assertHandler.Equal([]string{"user3", "user4", "user5"}, usersOf(tableQueue.Waiting), "Queue: other users should be waiting")
+	assertHandler.Equal([]string{"user3", "user4"}, tableQueue.Next, "Rotation mode: first two waiting users should play next")
+
+	tableQueue = BuildQueue(entries, ModeKing)
+	assertHandler.Equal([]string{"user3"}, tableQueue.Next, "King mode: only first waiting user should play next")
+
+	tableQueue = BuildQueue(newEntries("user1"), ModeKing)
+	assertHandler.Equal([]string{}, tableQueue.Next, "Nobody waiting: nobody should play next")
+}
+
+// TestRotateAfterSet tests RotateAfterSet function sending users back to the queue after a set.
+//
+func TestRotateAfterSet(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	entries := newEntries("user1", "user2", "user3", "user4")
+	updatedEntries := RotateAfterSet(entries, "user1", "user2", ModeRotation)
+	tableQueue := BuildQueue(entries, ModeRotation)
+	assertHandler.Equal([]string{"user3", "user4"}, usersOf(tableQueue.Playing), "Rotation mode: next users should play")
+	assertHandler.Equal([]string{"user1", "user2"}, usersOf(tableQueue.Waiting), "Rotation mode: winner then loser should go back to the queue")
+	assertHandler.Len(updatedEntries, 4, "Rotation mode: all entries should be updated")
+
+	entries = newEntries("user1", "user2", "user3", "user4")
+	updatedEntries = RotateAfterSet(entries, "user1", "user2", ModeKing)
+	tableQueue = BuildQueue(entries, ModeKing)
+	assertHandler.Equal([]string{"user1", "user3"}, usersOf(tableQueue.Playing), "King mode: winner should stay and first waiting user should play")
+	assertHandler.Equal([]string{"user4", "user2"}, usersOf(tableQueue.Waiting), "King mode: loser should go to the back of the queue")
+	assertHandler.Equal([]string{"user3", "user2"}, usersOf(updatedEntries), "King mode: only entries of loser and next user should be updated")
+
+	entries = newEntries("user1", "user2")
+	RotateAfterSet(entries, "user2", "user1", ModeKing)
+	tableQueue = BuildQueue(entries, ModeKing)
+	assertHandler.Equal([]string{"user2", "user1"}, usersOf(tableQueue.Playing), "Nobody waiting: loser should play again")
+
+	entries = newEntries("user1", "user2", "user3")
+	updatedEntries = RotateAfterSet(entries, "user1", "user3", ModeKing)
+	assertHandler.Empty(updatedEntries, "Set out of queue: queue should not change")
+}
+
+// TestFillTable tests FillTable function letting first waiting users play.
+//
+func TestFillTable(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	entries := newEntries("user1", "user2", "user3", "user4")
+	entries[0].Status = models.QueueStatusWaiting
+	entries[0].Position = 5
+	entries[1].Status = models.QueueStatusWaiting
+
+	updatedEntries := FillTable(entries)
+	assertHandler.Equal([]string{"user2", "user3"}, usersOf(updatedEntries), "Free table: users with lowest positions should play")
+	assertHandler.Empty(FillTable(entries), "Full table: nobody should start playing")
+}
@@ -0,0 +1,239 @@
+package scoreboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/scores"
+	"github.com/vlarrat-theodo/lbc-foosball/stream"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Types of messages exchanged with scoreboards, besides events of match (see stream package).
+const (
+	MessageGoal  = "goal"
+	MessageAck   = "ack"
+	MessageError = "error"
+)
+
+// Limits of connections: time given to write a message, time without any message from scoreboard before connection is
+// considered lost (scoreboards answering pings sent in between), and maximum size of messages sent by scoreboards.
+const (
+	writeTimeout   = 5 * time.Second
+	pongTimeout    = 60 * time.Second
+	pingInterval   = 30 * time.Second
+	maxMessageSize = 4096
+)
+
+// ClientMessage represents a message sent by a scoreboard: a goal scored in match, identified so that it can be acked.
+//
+type ClientMessage struct {
+	ID   string      `json:"id"`
+	Type string      `json:"type"`
+	Goal scores.Goal `json:"goal"`
+}
+
+// ServerMessage represents a message sent to a scoreboard.
+//
+// Events of match carry ID of event, acks and errors carry ID of message they answer (errors also carrying HTTP status
+// of error, as API does).
+//
+type ServerMessage struct {
+	Type    string      `json:"type"`
+	ID      string      `json:"id,omitempty"`
+	EventID uint64      `json:"event_id,omitempty"`
+	Status  int         `json:"status,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Handler serves scoreboards of matches (see ServeHTTP).
+//
+// FindMatch retrieves match followed by scoreboard, Snapshot its current score and Submit stores a goal scored in
+// match, returning updated score (refused goals raising a scores.GoalError).
+//
+// As scoreboards can submit goals, browsers can only connect from pages served by AllowedOrigins (so that other sites
+// cannot connect on behalf of their visitors). Scoreboards not running in a browser send no origin, and are accepted.
+//
+type Handler struct {
+	Hub            *stream.Hub
+	AllowedOrigins []string
+	FindMatch      func(matchID uuid.UUID) (scoreMatch models.Match, findError error)
+	Snapshot       func(scoreMatch models.Match) (score interface{}, snapshotError error)
+	Submit         func(submittedGoal scores.Goal) (score interface{}, submitError error)
+}
+
+// AllowedOrigins returns origins of pages allowed to connect scoreboards, configured in environment variables.
+//
+// Origins are a comma separated list of "<scheme>://<host>[:<port>]" entries.
+//
+func AllowedOrigins() (origins []string) {
+	return ParseOrigins(os.Getenv("SCOREBOARD_ALLOWED_ORIGINS"))
+}
+
+// ParseOrigins parses a comma separated list of origins, ignoring empty entries.
+//
+func ParseOrigins(rawOrigins string) (origins []string) {
+	for _, origin := range strings.Split(rawOrigins, ",") {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// checkOrigin checks that request is sent by a scoreboard without origin, or from a page of an allowed origin.
+//
+func (s Handler) checkOrigin(request *http.Request) (allowed bool) {
+	origin := request.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowedOrigin := range s.AllowedOrigins {
+		if strings.EqualFold(origin, allowedOrigin) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP connects a scoreboard to match submitted in query string, through a WebSocket.
+//
+// Scoreboard first gets current score of match, then each event of match. It can submit goals of match on the same
+// connection, each of them being answered by an ack (with updated score) or an error.
+//
+func (s Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if !s.checkOrigin(request) {
+		writeError(writer, fmt.Sprintf("Origin '%s' not allowed", request.Header.Get("Origin")), http.StatusForbidden)
+		return
+	}
+	matchID, requestError := uuid.FromString(request.URL.Query().Get("match_id"))
+	if requestError != nil {
+		writeError(writer, "Bad request: you must provide a valid match id", http.StatusBadRequest)
+		return
+	}
+	scoreMatch, requestError := s.FindMatch(matchID)
+	if requestError != nil {
+		writeError(writer, fmt.Sprintf("Match '%s' not found", matchID), http.StatusNotFound)
+		return
+	}
+
+	topic := stream.MatchKey(scoreMatch.ID.String())
+	events, lastID, _, _ := s.Hub.Subscribe(topic, 0)
+	defer s.Hub.Unsubscribe(topic, events)
+
+	score, requestError := s.Snapshot(scoreMatch)
+	if requestError != nil {
+		writeError(writer, fmt.Sprintf("Failed to retrieve score: %s", requestError), http.StatusInternalServerError)
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	connection, upgradeError := upgrader.Upgrade(writer, request, nil)
+	if upgradeError != nil {
+		// Upgrader already answered request
+		return
+	}
+	defer connection.Close()
+
+	replies := make(chan ServerMessage)
+	closed, stopped := make(chan struct{}), make(chan struct{})
+	defer close(stopped)
+	go s.read(connection, scoreMatch, replies, closed, stopped)
+
+	sendError := write(connection, ServerMessage{Type: stream.EventScore, EventID: lastID, Data: score})
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for sendError == nil {
+		select {
+		case <-closed:
+			return
+		case <-ping.C:
+			sendError = connection.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+		case reply := <-replies:
+			sendError = write(connection, reply)
+		case event, open := <-events:
+			if !open {
+				// Scoreboard was too slow to get its events: it reconnects and gets current score
+				connection.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"), time.Now().Add(writeTimeout))
+				return
+			}
+			sendError = write(connection, ServerMessage{Type: event.Name, EventID: event.ID, Data: event.Data})
+		}
+	}
+}
+
+// read handles messages sent by scoreboard one after another (so that goals are stored in order), until connection is
+// lost or scoreboard is not served anymore.
+//
+func (s Handler) read(connection *websocket.Conn, scoreMatch models.Match, replies chan<- ServerMessage, closed chan<- struct{}, stopped <-chan struct{}) {
+	defer close(closed)
+
+	connection.SetReadLimit(maxMessageSize)
+	connection.SetReadDeadline(time.Now().Add(pongTimeout))
+	connection.SetPongHandler(func(string) error {
+		return connection.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	for {
+		_, rawMessage, readError := connection.ReadMessage()
+		if readError != nil {
+			return
+		}
+		connection.SetReadDeadline(time.Now().Add(pongTimeout))
+
+		var clientMessage ClientMessage
+		reply := ServerMessage{Type: MessageError, Status: http.StatusBadRequest}
+		if unmarshalError := json.Unmarshal(rawMessage, &clientMessage); unmarshalError != nil {
+			reply.Error = fmt.Sprintf("Bad request body: %s", unmarshalError)
+		} else {
+			reply = s.handle(clientMessage, scoreMatch)
+		}
+
+		select {
+		case replies <- reply:
+		case <-stopped:
+			return
+		}
+	}
+}
+
+// handle stores a goal sent by scoreboard in its match, returning ack or error to be sent back.
+//
+func (s Handler) handle(clientMessage ClientMessage, scoreMatch models.Match) (reply ServerMessage) {
+	if clientMessage.Type != MessageGoal {
+		return ServerMessage{Type: MessageError, ID: clientMessage.ID, Status: http.StatusBadRequest, Error: fmt.Sprintf("Bad request: unknown message type '%s'", clientMessage.Type)}
+	}
+
+	clientMessage.Goal.MatchID = scoreMatch.ID.String()
+	score, submitError := s.Submit(clientMessage.Goal)
+	if goalError, refusedGoal := submitError.(scores.GoalError); refusedGoal {
+		return ServerMessage{Type: MessageError, ID: clientMessage.ID, Status: goalError.StatusCode, Error: goalError.Message}
+	}
+	if submitError != nil {
+		return ServerMessage{Type: MessageError, ID: clientMessage.ID, Status: http.StatusInternalServerError, Error: fmt.Sprintf("Failed to create/update score: %s", submitError)}
+	}
+	return ServerMessage{Type: MessageAck, ID: clientMessage.ID, Data: score}
+}
+
+// write sends a message to scoreboard.
+//
+func write(connection *websocket.Conn, message ServerMessage) (writeError error) {
+	connection.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return connection.WriteJSON(message)
+}
+
+// writeError formats HTTP responses sent when an error occurs before connection is upgraded, as API does.
+//
+func writeError(writer http.ResponseWriter, errorMessage string, errorStatusCode int) {
+	errorInJSON, _ := json.Marshal(map[string]string{"error": errorMessage})
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(errorStatusCode)
+	writer.Write(errorInJSON)
+}
@@ -0,0 +1,134 @@
+package scoreboard
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/scores"
+	"github.com/vlarrat-theodo/lbc-foosball/stream"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readMessage reads next message sent to scoreboard, with its data left as raw JSON.
+//
+func readMessage(t *testing.T, connection *websocket.Conn) (message ServerMessage, data string) {
+	var rawMessage struct {
+		ServerMessage
+		Data json.RawMessage `json:"data"`
+	}
+	if readError := connection.ReadJSON(&rawMessage); readError != nil {
+		t.Fatal(readError)
+	}
+	rawMessage.ServerMessage.Data = nil
+	return rawMessage.ServerMessage, string(rawMessage.Data)
+}
+
+// TestHandler tests ServeHTTP function sending score of match, acking goals and forwarding events of match.
+//
+func TestHandler(t *testing.T) {
+	var submittedGoals []scores.Goal
+
+	assertHandler := assert.New(t)
+	hub := stream.NewHub()
+	scoreMatch := models.Match{ID: uuid.Must(uuid.NewV4()), User1Id: "user1", User2Id: "user2"}
+	handler := Handler{
+		Hub: hub,
+		FindMatch: func(matchID uuid.UUID) (foundMatch models.Match, findError error) {
+			if matchID != scoreMatch.ID {
+				return foundMatch, errors.New("not found")
+			}
+			return scoreMatch, nil
+		},
+		Snapshot: func(models.Match) (score interface{}, snapshotError error) {
+			return map[string]int{"goals_in_balance": 0}, nil
+		},
+		Submit: func(submittedGoal scores.Goal) (score interface{}, submitError error) {
+			if submittedGoal.Player == "p12" {
+				return nil, scores.GoalError{StatusCode: http.StatusInternalServerError, Message: `Failed to create/update score: submitted goal player "p12" does not exist`}
+			}
+			submittedGoals = append(submittedGoals, submittedGoal)
+			return map[string]int{"goals_in_balance": 2}, nil
+		},
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	serverURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	_, response, dialError := websocket.DefaultDialer.Dial(serverURL+"?match_id="+uuid.Must(uuid.NewV4()).String(), nil)
+	assertHandler.NotNil(dialError, "Unknown match: connection should be refused")
+	assertHandler.Equal(http.StatusNotFound, response.StatusCode, "Unknown match: match should not be found")
+
+	connection, _, dialError := websocket.DefaultDialer.Dial(serverURL+"?match_id="+scoreMatch.ID.String(), nil)
+	if dialError != nil {
+		t.Fatal(dialError)
+	}
+	defer connection.Close()
+
+	message, data := readMessage(t, connection)
+	assertHandler.Equal(stream.EventScore, message.Type, "Scoreboard should start with current score")
+	assertHandler.Equal(`{"goals_in_balance":0}`, data, "Current score not sent as expected")
+
+	connection.WriteJSON(ClientMessage{ID: "1", Type: MessageGoal, Goal: scores.Goal{Scorer: "user1", Opponent: "user2", Player: "p5"}})
+	message, data = readMessage(t, connection)
+	assertHandler.Equal(ServerMessage{Type: MessageAck, ID: "1"}, message, "Stored goal should be acked")
+	assertHandler.Equal(`{"goals_in_balance":2}`, data, "Ack should contain updated score")
+	assertHandler.Equal([]scores.Goal{{Scorer: "user1", Opponent: "user2", Player: "p5", MatchID: scoreMatch.ID.String()}}, submittedGoals, "Goal should be submitted in match of scoreboard")
+
+	connection.WriteJSON(ClientMessage{ID: "2", Type: MessageGoal, Goal: scores.Goal{Scorer: "user1", Opponent: "user2", Player: "p12"}})
+	message, _ = readMessage(t, connection)
+	assertHandler.Equal(ServerMessage{Type: MessageError, ID: "2", Status: http.StatusInternalServerError, Error: `Failed to create/update score: submitted goal player "p12" does not exist`}, message, "Refused goal should be answered by an error")
+
+	connection.WriteJSON(ClientMessage{ID: "3", Type: "undo"})
+	message, _ = readMessage(t, connection)
+	assertHandler.Equal(http.StatusBadRequest, message.Status, "Unknown message type: message should be answered by an error")
+	connection.WriteMessage(websocket.TextMessage, []byte("not JSON"))
+	message, _ = readMessage(t, connection)
+	assertHandler.Equal(http.StatusBadRequest, message.Status, "Malformed message: message should be answered by an error")
+
+	published := hub.Publish(stream.EventSet, stream.MatchKey(scoreMatch.ID.String()), json.RawMessage(`{"winner_id":"user1"}`))
+	message, data = readMessage(t, connection)
+	assertHandler.Equal(ServerMessage{Type: stream.EventSet, EventID: published.ID}, message, "Events of match should be forwarded")
+	assertHandler.Equal(`{"winner_id":"user1"}`, data, "Event data not forwarded as expected")
+}
+
+// TestOrigins tests scoreboards being refused when connecting from pages of origins not allowed.
+//
+func TestOrigins(t *testing.T) {
+	assertHandler := assert.New(t)
+	scoreMatch := models.Match{ID: uuid.Must(uuid.NewV4()), User1Id: "user1", User2Id: "user2"}
+	handler := Handler{
+		Hub:            stream.NewHub(),
+		AllowedOrigins: ParseOrigins(" https://scoreboard.example.com/, ,http://localhost:3000"),
+		FindMatch: func(uuid.UUID) (foundMatch models.Match, findError error) {
+			return scoreMatch, nil
+		},
+		Snapshot: func(models.Match) (score interface{}, snapshotError error) {
+			return map[string]int{"goals_in_balance": 0}, nil
+		},
+	}
+	assertHandler.Equal([]string{"https://scoreboard.example.com", "http://localhost:3000"}, handler.AllowedOrigins, "Origins not parsed as expected")
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	matchURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?match_id=" + scoreMatch.ID.String()
+
+	_, response, dialError := websocket.DefaultDialer.Dial(matchURL, http.Header{"Origin": {"https://evil.example.com"}})
+	assertHandler.NotNil(dialError, "Origin not allowed: connection should be refused")
+	assertHandler.Equal(http.StatusForbidden, response.StatusCode, "Origin not allowed: connection should be forbidden")
+
+	for _, header := range []http.Header{nil, {"Origin": {"https://scoreboard.example.com"}}} {
+		connection, _, dialError := websocket.DefaultDialer.Dial(matchURL, header)
+		if !assertHandler.Nil(dialError, "Allowed origin or no origin: connection should be accepted (%v)", header) {
+			continue
+		}
+		message, _ := readMessage(t, connection)
+		assertHandler.Equal(stream.EventScore, message.Type, "Scoreboard should start with current score")
+		connection.Close()
+	}
+}
@@ -0,0 +1,663 @@
+package scores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/achievements"
+	"github.com/vlarrat-theodo/lbc-foosball/ladder"
+	"github.com/vlarrat-theodo/lbc-foosball/logging"
+	"github.com/vlarrat-theodo/lbc-foosball/metrics"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/queue"
+	"github.com/vlarrat-theodo/lbc-foosball/tournaments"
+	"github.com/vlarrat-theodo/lbc-foosball/tracing"
+	"github.com/vlarrat-theodo/lbc-foosball/webhooks"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"time"
+)
+
+// Goal represents goal information submitted to API.
+//
+// Match is optional: when submitted, goal is counted in score of this match instead of usual score between users.
+//
+type Goal struct {
+	Scorer   string `json:"scorer"`
+	Opponent string `json:"opponent"`
+	Player   string `json:"player"`
+	Gamelle  bool   `json:"gamelle"`
+	MatchID  string `json:"match_id"`
+}
+
+// userScore represents score information specific to one user.
+//
+// Handicap information is only present when a handicap applies to user.
+//
+type userScore struct {
+	Sets           int `json:"sets"`
+	Points         int `json:"points"`
+	HandicapPoints int `json:"handicap_points,omitempty"`
+	GoalMultiplier int `json:"goal_multiplier,omitempty"`
+}
+
+// goalRecordedEvent represents data sent to webhooks when a goal is recorded.
+//
+type goalRecordedEvent struct {
+	Goal  models.Goal            `json:"goal"`
+	Score map[string]interface{} `json:"score"`
+}
+
+// setFinishedEvent represents data sent to webhooks when a goal finishes a set.
+//
+// Points are those of both users when set was won, score being already reset for next set.
+//
+type setFinishedEvent struct {
+	ScoreID      uuid.UUID              `json:"score_id"`
+	MatchID      nulls.UUID             `json:"match_id"`
+	WinnerID     string                 `json:"winner_id"`
+	LoserID      string                 `json:"loser_id"`
+	WinnerPoints int                    `json:"winner_points"`
+	LoserPoints  int                    `json:"loser_points"`
+	Score        map[string]interface{} `json:"score"`
+}
+
+// GoalError is raised when submitted goal cannot be stored, with HTTP status code to be sent in API response.
+//
+type GoalError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error returns message of GoalError.
+//
+func (e GoalError) Error() (errorMessage string) {
+	return e.Message
+}
+
+// isPissette checks if goal has been scored by "pissette" player.
+//
+func (g Goal) isPissette() (pissetteGoal bool) {
+	return g.Player == "p9"
+}
+
+// Kind classifies goal according to foosball rules.
+//
+// "pissette" takes precedence over everything, then "gamelle" (which has no effect when scored by a "demi"),
+// then "demi", any other goal being a "classic" one.
+//
+func (g Goal) Kind() (goalKind string) {
+	switch {
+	case g.isPissette():
+		return models.GoalKindPissette
+	case g.Gamelle && isPlayerDemi(g.Player):
+		return models.GoalKindDemiGamelle
+	case g.Gamelle:
+		return models.GoalKindGamelle
+	case isPlayerDemi(g.Player):
+		return models.GoalKindDemi
+	default:
+		return models.GoalKindClassic
+	}
+}
+
+var authorizedPlayers = [...]string{"p1", "p2", "p3", "p4", "p5", "p6", "p7", "p8", "p9", "p10", "p11"}
+var demiPlayers = [...]string{"p4", "p5", "p6", "p7", "p8"}
+
+// checkPlayerExists checks if submitted player really exists.
+//
+func checkPlayerExists(playerToCheck string) (existingPlayer bool) {
+	for _, authorizedPlayer := range authorizedPlayers {
+		if playerToCheck == authorizedPlayer {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlayerDemi checks if submitted player is a midfielder.
+//
+func isPlayerDemi(playerToCheck string) (playerDemi bool) {
+	for _, demiPlayer := range demiPlayers {
+		if playerToCheck == demiPlayer {
+			return true
+		}
+	}
+	return false
+}
+
+// updateScore updates current score according to submitted goal.
+//
+// It will first check that submitted goal is legitimate.
+// Then it will handle all specified cases:
+//     - "pissette"
+//     - "gamelle"
+//     - "demi"
+//     - "classic"
+//     - handicap (goal multiplier)
+//     - winning set
+//
+func updateScore(scoreToUpdate *models.Score, newGoal Goal) (updateScoreError error) {
+	// Check that submitted goal and score correspond to same users
+	if !((newGoal.Scorer == scoreToUpdate.User1Id && newGoal.Opponent == scoreToUpdate.User2Id) || (newGoal.Scorer == scoreToUpdate.User2Id && newGoal.Opponent == scoreToUpdate.User1Id)) {
+		return errors.New("goal and score do not correspond to same users")
+	}
+
+	// Check that submitted goal player belongs to authorized values
+	if !checkPlayerExists(newGoal.Player) {
+		return fmt.Errorf(`submitted goal player "%s" does not exist`, newGoal.Player)
+	}
+
+	switch newGoal.Kind() {
+	// Handle "pissette" case: nothing happens when goal is scored by player "p9"
+	case models.GoalKindPissette:
+		return nil
+
+	// Handle "gamelle" case: opponent loses 1 point and scorer scores no point
+	case models.GoalKindGamelle:
+		scoreToUpdate.ScorePoints(newGoal.Opponent, -1)
+		return nil
+
+	// "gamelle" case has no effect when scored from "demi" player
+	case models.GoalKindDemiGamelle:
+		return nil
+
+	// Handle "demi" case: add 2 points in balance when goal scored by midfielder
+	case models.GoalKindDemi:
+		scoreToUpdate.GoalsInBalance += 2
+		return nil
+	}
+
+	// Handle "goals_in_balance" case: add points in balance to scorer instead of only 1 point
+	goalPoints := 1
+	if scoreToUpdate.GoalsInBalance > 0 {
+		goalPoints = scoreToUpdate.GoalsInBalance
+		scoreToUpdate.GoalsInBalance = 0
+	}
+	// Handle handicap case: points of scorer may be multiplied
+	scoreToUpdate.ScorePoints(newGoal.Scorer, goalPoints*scoreToUpdate.GoalMultiplier(newGoal.Scorer))
+
+	// Handle end of sets (when one user turns 10 points, handicap points included)
+	if scoreToUpdate.IsSetFinished() {
+		scoreToUpdate.ChangeSet(newGoal.Scorer)
+	}
+
+	return nil
+}
+
+// recordGoal stores updated score and submitted goal in database, returning stored goal.
+//
+// Goal is linked to its score (and to session in progress between users, if any) and stored with its classification,
+// so that it can be used afterwards to compute statistics.
+// Goal is flagged as handicapped when a handicap applies to score.
+// Points in balance are considered as cashed when a "classic" goal is scored while some points were in balance.
+// When goal finishes a set, streaks of both users are updated.
+// Validation and storage of score and goal are traced in spans (children of span of submitted context).
+//
+func recordGoal(ctx context.Context, tx *pop.Connection, scoreToSave *models.Score, submittedGoal Goal, scoreBeforeGoal models.Score, goalSession *models.Session) (goalToSave models.Goal, setFinished bool, recordError error) {
+	var validateError *validate.Errors
+
+	_, span := tracing.Start(ctx, "validate_and_save", attribute.String("db.sql.table", "scores"))
+	validateError, recordError = tx.ValidateAndSave(scoreToSave)
+	tracing.End(span, validationFailure(validateError, recordError))
+	if recordError != nil {
+		return goalToSave, false, recordError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return goalToSave, false, validateError
+	}
+
+	setFinished = scoreToSave.SetsPlayed() > scoreBeforeGoal.SetsPlayed()
+
+	goalToSave = models.Goal{
+		ScoreID:     scoreToSave.ID,
+		ScorerId:    submittedGoal.Scorer,
+		OpponentId:  submittedGoal.Opponent,
+		Player:      submittedGoal.Player,
+		Gamelle:     submittedGoal.Gamelle,
+		Kind:        submittedGoal.Kind(),
+		SetFinished: setFinished,
+		Handicapped: scoreBeforeGoal.IsHandicapped(),
+		SeasonID:    scoreToSave.SeasonID,
+		MatchID:     scoreToSave.MatchID,
+	}
+	if goalSession != nil {
+		goalToSave.SessionID = nulls.NewUUID(goalSession.ID)
+		goalToSave.SessionSeconds = nulls.NewInt(int(goalSession.Elapsed(time.Now()).Seconds()))
+	}
+	if goalToSave.Kind == models.GoalKindClassic {
+		goalToSave.BalanceCashed = scoreBeforeGoal.GoalsInBalance
+	}
+	_, span = tracing.Start(ctx, "validate_and_save", attribute.String("db.sql.table", "goals"))
+	validateError, recordError = tx.ValidateAndCreate(&goalToSave)
+	tracing.End(span, validationFailure(validateError, recordError))
+	if recordError != nil {
+		return goalToSave, setFinished, recordError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return goalToSave, setFinished, validateError
+	}
+
+	if setFinished {
+		recordError = updateStreak(tx, submittedGoal.Scorer, true)
+		if recordError != nil {
+			return goalToSave, setFinished, recordError
+		}
+		recordError = updateStreak(tx, submittedGoal.Opponent, false)
+		if recordError != nil {
+			return goalToSave, setFinished, recordError
+		}
+	}
+
+	return goalToSave, setFinished, nil
+}
+
+// validationFailure returns error raised when validating and storing a model (database error first), nil if none.
+//
+func validationFailure(validateError *validate.Errors, dbError error) (failure error) {
+	if dbError != nil {
+		return dbError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return validateError
+	}
+	return nil
+}
+
+// saveGoal stores updated score and submitted goal in database (see recordGoal).
+//
+// Goals of a match whose ladder challenge has expired at submitted time are refused (see ladder.CheckMatchChallenge).
+// When goal finishes a set, set is recorded in match of goal (if any),
+// match being finished when its winner is known (completing ladder challenge of match, if any),
+// and both users are rotated in queue of the table (if playing at it).
+// Finally, achievements unlocked by this goal are stored for both users, and events of goal (and of finished set)
+// are queued for webhooks.
+//
+func saveGoal(ctx context.Context, tx *pop.Connection, scoreToSave *models.Score, submittedGoal Goal, scoreBeforeGoal models.Score, goalMatch *models.Match, goalSession *models.Session, at time.Time) (saveError error) {
+	if goalMatch != nil {
+		saveError = ladder.CheckMatchChallenge(tx, *goalMatch, at)
+		if saveError != nil {
+			return saveError
+		}
+	}
+
+	goalToSave, setFinished, saveError := recordGoal(ctx, tx, scoreToSave, submittedGoal, scoreBeforeGoal, goalSession)
+	if saveError != nil {
+		return saveError
+	}
+
+	if setFinished {
+		// A set is always finished by a "classic" goal, scoring 1 point or cashing points in balance
+		goalPoints := 1
+		if goalToSave.BalanceCashed > 0 {
+			goalPoints = goalToSave.BalanceCashed
+		}
+		goalPoints *= scoreBeforeGoal.GoalMultiplier(submittedGoal.Scorer)
+		winnerPoints := scoreBeforeGoal.UserPoints(submittedGoal.Scorer) + goalPoints
+		loserPoints := scoreBeforeGoal.UserPoints(submittedGoal.Opponent)
+
+		saveError = webhooks.Enqueue(tx, models.WebhookEventSetFinished, setFinishedEvent{
+			ScoreID:      scoreToSave.ID,
+			MatchID:      scoreToSave.MatchID,
+			WinnerID:     submittedGoal.Scorer,
+			LoserID:      submittedGoal.Opponent,
+			WinnerPoints: winnerPoints,
+			LoserPoints:  loserPoints,
+			Score:        NormalizeScore(*scoreToSave),
+		}, goalToSave.CreatedAt)
+		if saveError != nil {
+			return saveError
+		}
+
+		if goalMatch != nil {
+			saveError = tournaments.RecordSet(tx, goalMatch, *scoreToSave, submittedGoal.Scorer, winnerPoints, loserPoints)
+			if saveError != nil {
+				return saveError
+			}
+			saveError = ladder.RecordMatch(tx, *goalMatch)
+			if saveError != nil {
+				return saveError
+			}
+		}
+
+		saveError = queue.RecordSet(tx, submittedGoal.Scorer, submittedGoal.Opponent)
+		if saveError != nil {
+			return saveError
+		}
+	}
+
+	_, saveError = achievements.Unlock(tx, achievements.Event{Goal: goalToSave, ScoreBefore: scoreBeforeGoal, ScoreAfter: *scoreToSave})
+	if saveError != nil {
+		return saveError
+	}
+
+	return webhooks.Enqueue(tx, models.WebhookEventGoalRecorded, goalRecordedEvent{Goal: goalToSave, Score: NormalizeScore(*scoreToSave)}, goalToSave.CreatedAt)
+}
+
+// findStreak retrieves streaks of submitted user (new empty streaks if user has none yet).
+//
+func findStreak(tx *pop.Connection, userID string) (userStreak models.Streak, findError error) {
+	streakQuery := tx.Where("user_id = ?", userID)
+	streakAlreadyExists, findError := streakQuery.Exists(models.Streak{})
+	if findError != nil {
+		return userStreak, findError
+	}
+
+	if streakAlreadyExists {
+		findError = streakQuery.First(&userStreak)
+		return userStreak, findError
+	}
+	userStreak.UserId = userID
+	return userStreak, nil
+}
+
+// updateStreak records a finished set in streak of submitted user (creating streak if needed).
+//
+func updateStreak(tx *pop.Connection, userID string, wonSet bool) (updateStreakError error) {
+	var validateError *validate.Errors
+
+	userStreak, updateStreakError := findStreak(tx, userID)
+	if updateStreakError != nil {
+		return updateStreakError
+	}
+
+	if wonSet {
+		userStreak.RecordSetWon()
+	} else {
+		userStreak.RecordSetLost()
+	}
+
+	validateError, updateStreakError = tx.ValidateAndSave(&userStreak)
+	if updateStreakError != nil {
+		return updateStreakError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return validateError
+	}
+
+	return nil
+}
+
+// rebuildStreak recomputes streaks of submitted user from all sets they finished, in chronological order
+// (creating streak if needed).
+//
+func rebuildStreak(tx *pop.Connection, userID string) (rebuildError error) {
+	var validateError *validate.Errors
+	var userSets []models.Goal
+
+	rebuildError = tx.Where("set_finished AND (scorer_id = ? OR opponent_id = ?)", userID, userID).Order("created_at").All(&userSets)
+	if rebuildError != nil {
+		return rebuildError
+	}
+	userStreak, rebuildError := findStreak(tx, userID)
+	if rebuildError != nil {
+		return rebuildError
+	}
+
+	userStreak.CurrentWins, userStreak.CurrentLosses, userStreak.BestWins, userStreak.BestLosses = 0, 0, 0, 0
+	for _, userSet := range userSets {
+		if userSet.ScorerId == userID {
+			userStreak.RecordSetWon()
+		} else {
+			userStreak.RecordSetLost()
+		}
+	}
+
+	validateError, rebuildError = tx.ValidateAndSave(&userStreak)
+	if rebuildError != nil {
+		return rebuildError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return validateError
+	}
+	return nil
+}
+
+// pairScoreQuery builds query retrieving usual score between users (out of any match) in submitted season.
+//
+// Scores are counted separately for each season (scores out of any season having no season).
+//
+func pairScoreQuery(tx *pop.Connection, firstUserID string, secondUserID string, activeSeason models.Season, activeSeasonExists bool) (scoreQuery *pop.Query) {
+	scoreQuery = tx.Where("(user1_id = ? AND user2_id = ? OR user1_id = ? AND user2_id = ?)", firstUserID, secondUserID, secondUserID, firstUserID)
+	scoreQuery = scoreQuery.Where("match_id IS NULL")
+	if activeSeasonExists {
+		return scoreQuery.Where("season_id = ?", activeSeason.ID)
+	}
+	return scoreQuery.Where("season_id IS NULL")
+}
+
+// FetchPairScore retrieves usual score between users in season active at submitted time.
+//
+// An empty score between users (in active season) is returned when they have not played together yet.
+//
+func FetchPairScore(tx *pop.Connection, firstUserID string, secondUserID string, at time.Time) (pairScore models.Score, fetchError error) {
+	activeSeason, activeSeasonExists, fetchError := models.FindActiveSeason(tx, at)
+	if fetchError != nil {
+		return pairScore, fetchError
+	}
+
+	scoreQuery := pairScoreQuery(tx, firstUserID, secondUserID, activeSeason, activeSeasonExists)
+	scoreExists, fetchError := scoreQuery.Exists(models.Score{})
+	if fetchError != nil || !scoreExists {
+		pairScore = models.Score{User1Id: firstUserID, User2Id: secondUserID}
+		if activeSeasonExists {
+			pairScore.SeasonID = nulls.NewUUID(activeSeason.ID)
+		}
+		return pairScore, fetchError
+	}
+	fetchError = scoreQuery.First(&pairScore)
+	return pairScore, fetchError
+}
+
+// FetchMatchScore retrieves score of submitted match.
+//
+// An empty score between users of match is returned when no goal has been scored in match yet.
+//
+func FetchMatchScore(tx *pop.Connection, scoreMatch models.Match) (matchScore models.Score, fetchError error) {
+	scoreQuery := tx.Where("match_id = ?", scoreMatch.ID)
+	scoreExists, fetchError := scoreQuery.Exists(models.Score{})
+	if fetchError != nil || !scoreExists {
+		return models.Score{User1Id: scoreMatch.User1Id, User2Id: scoreMatch.User2Id, MatchID: nulls.NewUUID(scoreMatch.ID)}, fetchError
+	}
+	fetchError = scoreQuery.First(&matchScore)
+	return matchScore, fetchError
+}
+
+// NormalizeScore generates dynamic score representation according to input score.
+//
+func NormalizeScore(scoreToNormalize models.Score) (normalizedScoreForAPI map[string]interface{}) {
+	var normalizedScore = make(map[string]interface{})
+
+	for _, userID := range []string{scoreToNormalize.User1Id, scoreToNormalize.User2Id} {
+		normalizedUserScore := userScore{Sets: scoreToNormalize.UserSets(userID), Points: scoreToNormalize.UserPoints(userID)}
+		if multiplier := scoreToNormalize.GoalMultiplier(userID); multiplier > 1 {
+			normalizedUserScore.GoalMultiplier = multiplier
+		}
+		normalizedUserScore.HandicapPoints = scoreToNormalize.HandicapPoints(userID)
+		normalizedScore[userID] = normalizedUserScore
+	}
+	normalizedScore["goals_in_balance"] = scoreToNormalize.GoalsInBalance
+
+	return normalizedScore
+}
+
+// StoreGoal stores submitted goal, then returns updated score between users (or of submitted match).
+//
+// Score of submitted match, or score between users in season active at submitted time, is created if needed.
+// Goals are refused while session in progress between users is paused.
+// Refused goals raise a GoalError, holding HTTP status code to be sent in API response.
+// Stored goals, finished sets, refused goals and database errors are counted in metrics, and logged with
+// classification of goal, score before and after goal and timings of database operations.
+// Storage is traced in a span (child of span of submitted context), with children spans for lookup of score, update of
+// score and storage of score and goal.
+//
+func StoreGoal(ctx context.Context, databaseConnection *pop.Connection, submittedGoal Goal, at time.Time, logger *logging.Logger) (goalScore models.Score, storeError error) {
+	var requestError, dbError error
+	var scoreBeforeGoal models.Score
+	var goalMatch *models.Match
+	var goalSession *models.Session
+	var timings = logging.Timings{}
+
+	ctx, span := tracing.Start(ctx, "StoreGoal",
+		attribute.String("goal.scorer", submittedGoal.Scorer),
+		attribute.String("goal.opponent", submittedGoal.Opponent),
+		attribute.String("goal.player", submittedGoal.Player),
+		attribute.String("goal.kind", submittedGoal.Kind()),
+	)
+	defer func() {
+		logGoal(logger, submittedGoal, scoreBeforeGoal, goalScore, timings, storeError)
+		tracing.End(span, storeError)
+	}()
+
+	dbStart := time.Now()
+	activeSeason, activeSeasonExists, dbError := models.FindActiveSeason(databaseConnection, at)
+	timings.Since("find_season", dbStart)
+	if dbError != nil {
+		metrics.DBErrorsTotal.Inc("find_season")
+		return goalScore, GoalError{StatusCode: http.StatusInternalServerError, Message: fmt.Sprintf("Failed to retrieve active season: %s", dbError)}
+	}
+
+	dbStart = time.Now()
+	activeSession, activeSessionExists, dbError := models.FindActiveSession(databaseConnection, submittedGoal.Scorer, submittedGoal.Opponent)
+	timings.Since("find_session", dbStart)
+	if dbError != nil {
+		metrics.DBErrorsTotal.Inc("find_session")
+		return goalScore, GoalError{StatusCode: http.StatusInternalServerError, Message: fmt.Sprintf("Failed to retrieve session in progress: %s", dbError)}
+	}
+	if activeSessionExists {
+		if activeSession.Status == models.SessionStatusPaused {
+			metrics.ValidationFailuresTotal.Inc("session_paused")
+			return goalScore, GoalError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("Bad request: session '%s' between submitted users is paused", activeSession.ID)}
+		}
+		goalSession = &activeSession
+	}
+
+	var existingScoreQuery *pop.Query
+	if submittedGoal.MatchID != "" {
+		// Goals of a match are counted in a dedicated score
+		var matchID uuid.UUID
+		matchID, requestError = uuid.FromString(submittedGoal.MatchID)
+		if requestError != nil {
+			metrics.ValidationFailuresTotal.Inc("invalid_match")
+			return goalScore, GoalError{StatusCode: http.StatusBadRequest, Message: "Bad request: you must provide a valid match id"}
+		}
+		goalMatch = &models.Match{}
+		dbStart = time.Now()
+		dbError = databaseConnection.Find(goalMatch, matchID)
+		timings.Since("find_match", dbStart)
+		if dbError != nil {
+			metrics.ValidationFailuresTotal.Inc("match_not_found")
+			return goalScore, GoalError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("Match '%s' not found", matchID)}
+		}
+		if goalMatch.Status != models.MatchStatusReady {
+			metrics.ValidationFailuresTotal.Inc("match_not_ready")
+			return goalScore, GoalError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("Bad request: match '%s' is %s", matchID, goalMatch.Status)}
+		}
+		if !goalMatch.HasUsers(submittedGoal.Scorer, submittedGoal.Opponent) {
+			metrics.ValidationFailuresTotal.Inc("match_users")
+			return goalScore, GoalError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("Bad request: match '%s' is not played between submitted users", matchID)}
+		}
+		existingScoreQuery = databaseConnection.Where("match_id = ?", goalMatch.ID)
+	} else {
+		existingScoreQuery = pairScoreQuery(databaseConnection, submittedGoal.Scorer, submittedGoal.Opponent, activeSeason, activeSeasonExists)
+	}
+	_, findScoreSpan := tracing.Start(ctx, "find_score")
+	dbStart = time.Now()
+	scoreAlreadyExists, dbError := existingScoreQuery.Exists(models.Score{})
+	timings.Since("find_score", dbStart)
+
+	if dbError != nil {
+		tracing.End(findScoreSpan, dbError)
+		metrics.DBErrorsTotal.Inc("find_score")
+		return goalScore, GoalError{StatusCode: http.StatusInternalServerError, Message: fmt.Sprintf("Failed to connect to database: %s", dbError)}
+	}
+
+	if scoreAlreadyExists {
+		dbStart = time.Now()
+		dbError = existingScoreQuery.First(&goalScore)
+		timings.Since("find_score", dbStart)
+		if dbError != nil {
+			tracing.End(findScoreSpan, dbError)
+			metrics.DBErrorsTotal.Inc("find_score")
+			return goalScore, GoalError{StatusCode: http.StatusInternalServerError, Message: fmt.Sprintf("Failed to retrieve existing score: %s", dbError)}
+		}
+	} else {
+		goalScore.User1Id = submittedGoal.Scorer
+		goalScore.User2Id = submittedGoal.Opponent
+		if activeSeasonExists {
+			goalScore.SeasonID = nulls.NewUUID(activeSeason.ID)
+		}
+		if goalMatch != nil {
+			goalScore.MatchID = nulls.NewUUID(goalMatch.ID)
+		}
+	}
+	findScoreSpan.SetAttributes(attribute.Bool("score.exists", scoreAlreadyExists))
+	tracing.End(findScoreSpan, nil)
+
+	scoreBeforeGoal = goalScore
+	_, updateScoreSpan := tracing.Start(ctx, "update_score")
+	updateScoreError := updateScore(&goalScore, submittedGoal)
+	tracing.End(updateScoreSpan, updateScoreError)
+	if updateScoreError != nil {
+		metrics.ValidationFailuresTotal.Inc("invalid_goal")
+		return goalScore, GoalError{StatusCode: http.StatusInternalServerError, Message: fmt.Sprintf("Failed to create/update score: %s", updateScoreError)}
+	}
+
+	saveGoalContext, saveGoalSpan := tracing.Start(ctx, "save_goal")
+	dbStart = time.Now()
+	dbError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		return saveGoal(saveGoalContext, tx, &goalScore, submittedGoal, scoreBeforeGoal, goalMatch, goalSession, at)
+	})
+	timings.Since("save_goal", dbStart)
+	tracing.End(saveGoalSpan, dbError)
+	if dbError == ladder.ErrChallengeExpired {
+		metrics.ValidationFailuresTotal.Inc("challenge_expired")
+		return goalScore, GoalError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("Bad request: %s", dbError)}
+	}
+	if dbError != nil {
+		metrics.DBErrorsTotal.Inc("save_goal")
+		return goalScore, GoalError{StatusCode: http.StatusInternalServerError, Message: fmt.Sprintf("Failed to create/update score: %s", dbError)}
+	}
+	metrics.GoalsTotal.Inc(submittedGoal.Kind())
+	if goalScore.SetsPlayed() > scoreBeforeGoal.SetsPlayed() {
+		metrics.SetsFinishedTotal.Inc()
+	}
+
+	return goalScore, nil
+}
+
+// logGoal logs outcome of a submitted goal: refused goals as warnings, failures as errors, and stored goals with
+// score before and after goal.
+//
+func logGoal(logger *logging.Logger, submittedGoal Goal, scoreBeforeGoal models.Score, goalScore models.Score, timings logging.Timings, storeError error) {
+	fields := logging.Fields{
+		"scorer_id":     submittedGoal.Scorer,
+		"opponent_id":   submittedGoal.Opponent,
+		"player":        submittedGoal.Player,
+		"gamelle":       submittedGoal.Gamelle,
+		"kind":          submittedGoal.Kind(),
+		"match_id":      submittedGoal.MatchID,
+		"db_timings_ms": timings,
+	}
+
+	if goalError, refusedGoal := storeError.(GoalError); refusedGoal && goalError.StatusCode < http.StatusInternalServerError {
+		fields["status"], fields["error"] = goalError.StatusCode, goalError.Message
+		logger.Warn("Goal refused", fields)
+		return
+	}
+	if storeError != nil {
+		fields["error"] = storeError.Error()
+		logger.Error("Failed to store goal", fields)
+		return
+	}
+
+	fields["score_id"] = goalScore.ID
+	fields["score_before"] = NormalizeScore(scoreBeforeGoal)
+	fields["score_after"] = NormalizeScore(goalScore)
+	fields["set_finished"] = goalScore.SetsPlayed() > scoreBeforeGoal.SetsPlayed()
+	logger.Info("Goal stored", fields)
+}
@@ -1,4 +1,4 @@
-package main
+package scores
 
 import (
 	"github.com/gobuffalo/uuid"
@@ -31,7 +31,7 @@ func TestUpdateScoreUsersIncoherence(t *testing.T) {
 		GoalsInBalance: 0,
 	}
 
-	bothDifferentGoal := goal{
+	bothDifferentGoal := Goal{
 		Scorer:   "user3",
 		Opponent: "user4",
 		Player:   "p1",
@@ -43,7 +43,7 @@ func TestUpdateScoreUsersIncoherence(t *testing.T) {
 	assertHandler.NotNil(updateScoreError, "Both users different between goal and score: updateScore function should raise an error")
 	assertHandler.Equal(initialScore, score, "Both users different between goal and score: updateScore function should not modify score")
 
-	firstDifferentCase1Goal := goal{
+	firstDifferentCase1Goal := Goal{
 		Scorer:   "user2",
 		Opponent: "user3",
 		Player:   "p1",
@@ -55,7 +55,7 @@ func TestUpdateScoreUsersIncoherence(t *testing.T) {
 	assertHandler.NotNil(updateScoreError, "First user different between goal and score (case 1): updateScore function should raise an error")
 	assertHandler.Equal(initialScore, score, "First user different between goal and score (case 1): updateScore function should not modify score")
 
-	firstDifferentCase2Goal := goal{
+	firstDifferentCase2Goal := Goal{
 		Scorer:   "user3",
 		Opponent: "user2",
 		Player:   "p1",
@@ -67,7 +67,7 @@ func TestUpdateScoreUsersIncoherence(t *testing.T) {
 	assertHandler.NotNil(updateScoreError, "First user different between goal and score (case 2): updateScore function should raise an error")
 	assertHandler.Equal(initialScore, score, "First user different between goal and score (case 2): updateScore function should not modify score")
 
-	secondDifferentCase1Goal := goal{
+	secondDifferentCase1Goal := Goal{
 		Scorer:   "user1",
 		Opponent: "user3",
 		Player:   "p1",
@@ -79,7 +79,7 @@ func TestUpdateScoreUsersIncoherence(t *testing.T) {
 	assertHandler.NotNil(updateScoreError, "Second user different between goal and score (case 1): updateScore function should raise an error")
 	assertHandler.Equal(initialScore, score, "Second user different between goal and score (case 1): updateScore function should not modify score")
 
-	secondDifferentCase2Goal := goal{
+	secondDifferentCase2Goal := Goal{
 		Scorer:   "user3",
 		Opponent: "user1",
 		Player:   "p1",
@@ -91,7 +91,7 @@ func TestUpdateScoreUsersIncoherence(t *testing.T) {
 	assertHandler.NotNil(updateScoreError, "Second user different between goal and score (case 2): updateScore function should raise an error")
 	assertHandler.Equal(initialScore, score, "Second user different between goal and score (case 2): updateScore function should not modify score")
 
-	bothSameCase1Goal := goal{
+	bothSameCase1Goal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p1",
@@ -103,7 +103,7 @@ func TestUpdateScoreUsersIncoherence(t *testing.T) {
 	assertHandler.Nil(updateScoreError, "Both users same between goal and score (case 1): updateScore function should not raise an error")
 	assertHandler.NotEqual(initialScore, score, "Both users same between goal and score (case 1): updateScore function should modify score")
 
-	bothSameCase2Goal := goal{
+	bothSameCase2Goal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p1",
@@ -140,7 +140,7 @@ func TestUpdateScoreExistingPlayers(t *testing.T) {
 		GoalsInBalance: 0,
 	}
 
-	notExistingPlayerGoal := goal{
+	notExistingPlayerGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "zizou",
@@ -152,7 +152,7 @@ func TestUpdateScoreExistingPlayers(t *testing.T) {
 	assertHandler.NotNil(updateScoreError, "Goal from not existing player: updateScore function should raise an error")
 	assertHandler.Equal(initialScore, score, "Goal from not existing player: updateScore function should not modify score")
 
-	existingPlayerGoal := goal{
+	existingPlayerGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p1",
@@ -188,7 +188,7 @@ func TestUpdateScoreRegularGoal(t *testing.T) {
 		GoalsInBalance: 0,
 	}
 
-	firstUserGoal := goal{
+	firstUserGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p1",
@@ -212,7 +212,7 @@ func TestUpdateScoreRegularGoal(t *testing.T) {
 	_ = updateScore(&score, firstUserGoal)
 	assertHandler.Equal(awaitedFirstGoalScore, score, "Regular goal from user1 (not winning set): score not updated as expected")
 
-	secondUserGoal := goal{
+	secondUserGoal := Goal{
 		Scorer:   "user2",
 		Opponent: "user1",
 		Player:   "p1",
@@ -247,14 +247,14 @@ func TestUpdateScoreWinningSet(t *testing.T) {
 	now := time.Now()
 	initialUUID, _ := uuid.NewV4()
 
-	firstUserGoal := goal{
+	firstUserGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p1",
 		Gamelle:  false,
 	}
 
-	secondUserGoal := goal{
+	secondUserGoal := Goal{
 		Scorer:   "user2",
 		Opponent: "user1",
 		Player:   "p1",
@@ -439,7 +439,7 @@ func TestUpdateScorePissetteCase(t *testing.T) {
 		GoalsInBalance: 0,
 	}
 
-	classicPlayerGoal := goal{
+	classicPlayerGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p1",
@@ -450,7 +450,7 @@ func TestUpdateScorePissetteCase(t *testing.T) {
 	_ = updateScore(&score, classicPlayerGoal)
 	assertHandler.NotEqual(initialScore, score, "Classic player goal: score should be modified")
 
-	pissettePlayerGoal := goal{
+	pissettePlayerGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p9",
@@ -461,7 +461,7 @@ func TestUpdateScorePissetteCase(t *testing.T) {
 	_ = updateScore(&score, pissettePlayerGoal)
 	assertHandler.Equal(initialScore, score, "Pissette player goal without gamelle: score should not be modified")
 
-	pissettePlayerGamelleGoal := goal{
+	pissettePlayerGamelleGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p9",
@@ -483,14 +483,14 @@ func TestUpdateScoreGamelleCase(t *testing.T) {
 	now := time.Now()
 	initialUUID, _ := uuid.NewV4()
 
-	classicGoal := goal{
+	classicGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p1",
 		Gamelle:  false,
 	}
 
-	gamelleGoal := goal{
+	gamelleGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p1",
@@ -585,7 +585,7 @@ func TestUpdateScoreDemiCase(t *testing.T) {
 	now := time.Now()
 	initialUUID, _ := uuid.NewV4()
 
-	demiGoal := goal{
+	demiGoal := Goal{
 		Scorer:   "user2",
 		Opponent: "user1",
 		Player:   "p4",
@@ -622,7 +622,7 @@ func TestUpdateScoreDemiCase(t *testing.T) {
 	_ = updateScore(&score, demiGoal)
 	assertHandler.Equal(awaitedAfterDemiGoalScore, score, "Demi goal: score not updated as expected")
 
-	classicGoal := goal{
+	classicGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p1",
@@ -663,7 +663,7 @@ func TestUpdateScoreDemiCase(t *testing.T) {
 	_ = updateScore(&score, demiGoal)
 	assertHandler.Equal(awaitedAfterDemiThenDemiGoalScore, score, "Demi goal after demi goal: score not updated as expected")
 
-	gamelleGoal := goal{
+	gamelleGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p1",
@@ -687,7 +687,7 @@ func TestUpdateScoreDemiCase(t *testing.T) {
 	_ = updateScore(&score, gamelleGoal)
 	assertHandler.Equal(awaitedAfterDemiThenGamelleGoalScore, score, "Gamelle goal after demi goal: score not updated as expected")
 
-	demiGamelleGoal := goal{
+	demiGamelleGoal := Goal{
 		Scorer:   "user1",
 		Opponent: "user2",
 		Player:   "p4",
@@ -699,3 +699,65 @@ func TestUpdateScoreDemiCase(t *testing.T) {
 	assertHandler.Equal(awaitedAfterDemiGoalScore, score, "Gamelle by demi goal: score should not be modified")
 
 }
+
+// TestUpdateScoreHandicapCase tests updateScore function when a handicap applies to score.
+//
+func TestUpdateScoreHandicapCase(t *testing.T) {
+	var score models.Score
+
+	assertHandler := assert.New(t)
+
+	handicappedScore := models.Score{
+		User1Id:             "user1",
+		User2Id:             "user2",
+		User1Points:         5,
+		User2Points:         5,
+		User2HandicapPoints: 3,
+		User2GoalMultiplier: 2,
+	}
+
+	firstUserGoal := Goal{
+		Scorer:   "user1",
+		Opponent: "user2",
+		Player:   "p1",
+		Gamelle:  false,
+	}
+
+	secondUserGoal := Goal{
+		Scorer:   "user2",
+		Opponent: "user1",
+		Player:   "p1",
+		Gamelle:  false,
+	}
+
+	score = handicappedScore
+	_ = updateScore(&score, firstUserGoal)
+	assertHandler.Equal(6, score.User1Points, "Goal without multiplier: scorer should score 1 point")
+
+	score = handicappedScore
+	_ = updateScore(&score, secondUserGoal)
+	assertHandler.Equal(0, score.User2Points, "Goal with multiplier and handicap points: set should be finished (5 + 2 + 3 points)")
+	assertHandler.Equal(1, score.User2Sets, "Goal with multiplier and handicap points: scorer should win set")
+	assertHandler.Equal(3, score.User2HandicapPoints, "Finished set: handicap should still apply to next set")
+
+	score = handicappedScore
+	score.GoalsInBalance = 2
+	_ = updateScore(&score, firstUserGoal)
+	assertHandler.Equal(7, score.User1Points, "Balance without multiplier: scorer should cash points in balance")
+
+}
+
+// TestGoalKind tests goal classification according to foosball rules.
+//
+func TestGoalKind(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	assertHandler.Equal(models.GoalKindClassic, Goal{Player: "p1", Gamelle: false}.Kind(), "Goal by goalkeeper: goal should be classic")
+	assertHandler.Equal(models.GoalKindClassic, Goal{Player: "p11", Gamelle: false}.Kind(), "Goal by forward: goal should be classic")
+	assertHandler.Equal(models.GoalKindGamelle, Goal{Player: "p3", Gamelle: true}.Kind(), "Gamelle by defender: goal should be gamelle")
+	assertHandler.Equal(models.GoalKindDemi, Goal{Player: "p6", Gamelle: false}.Kind(), "Goal by midfielder: goal should be demi")
+	assertHandler.Equal(models.GoalKindDemiGamelle, Goal{Player: "p6", Gamelle: true}.Kind(), "Gamelle by midfielder: goal should be demi gamelle")
+	assertHandler.Equal(models.GoalKindPissette, Goal{Player: "p9", Gamelle: false}.Kind(), "Goal by p9: goal should be pissette")
+	assertHandler.Equal(models.GoalKindPissette, Goal{Player: "p9", Gamelle: true}.Kind(), "Gamelle by p9: goal should be pissette")
+
+}
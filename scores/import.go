@@ -0,0 +1,405 @@
+package scores
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/achievements"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/office"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of historical results: a goal, or a finished set whose goals are unknown.
+const (
+	ResultGoal = "goal"
+	ResultSet  = "set"
+)
+
+// Formats of imported files: CSV (with a header line) or a JSON array of results.
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+)
+
+// playedAtLayouts are the layouts accepted for dates of historical results (in office timezone, unless specified).
+var playedAtLayouts = [...]string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05"}
+
+// requiredImportColumns are the columns that imported CSV files must contain (in any order, other columns being ignored).
+var requiredImportColumns = [...]string{"played_at", "type", "scorer", "opponent"}
+
+// errImportRolledBack is raised to roll back an import which is a dry run or which has conflicts.
+var errImportRolledBack = errors.New("import rolled back")
+
+// HistoricalResult represents a past result to be imported, played before results were recorded through API.
+//
+// For a finished set, Scorer is the winner of set and Opponent its loser (Player and Gamelle being ignored).
+// Line is the line of result in imported CSV file (or its position in imported JSON array), used to report conflicts.
+//
+type HistoricalResult struct {
+	Line     int    `json:"-"`
+	PlayedAt string `json:"played_at"`
+	Type     string `json:"type"`
+	Scorer   string `json:"scorer"`
+	Opponent string `json:"opponent"`
+	Player   string `json:"player"`
+	Gamelle  bool   `json:"gamelle"`
+}
+
+// ImportConflict represents a historical result which cannot be imported, with the reason why.
+//
+type ImportConflict struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// ImportReport represents outcome of an import: numbers of goals and sets replayed, and conflicts found.
+//
+// Results are only imported when import is not a dry run and no conflict is found (Imported being then set).
+//
+type ImportReport struct {
+	DryRun    bool             `json:"dry_run"`
+	Imported  bool             `json:"imported"`
+	Goals     int              `json:"goals"`
+	Sets      int              `json:"sets"`
+	Conflicts []ImportConflict `json:"conflicts"`
+}
+
+// replayedResult represents a historical result checked and ready to be replayed.
+//
+type replayedResult struct {
+	HistoricalResult
+	playedAt time.Time
+}
+
+// ParseResults reads historical results from a CSV or JSON file.
+//
+// Malformed files are refused as a whole, whereas results breaking foosball rules are reported by ImportResults.
+//
+func ParseResults(reader io.Reader, format string) (results []HistoricalResult, parseError error) {
+	switch format {
+	case ImportFormatJSON:
+		parseError = json.NewDecoder(reader).Decode(&results)
+		for index := range results {
+			results[index].Line = index + 1
+		}
+		return results, parseError
+	case ImportFormatCSV:
+		return parseCSVResults(reader)
+	default:
+		return nil, fmt.Errorf("format must be one of '%s' or '%s'", ImportFormatCSV, ImportFormatJSON)
+	}
+}
+
+// parseCSVResults reads historical results from a CSV file, columns being identified by its header line.
+//
+func parseCSVResults(reader io.Reader) (results []HistoricalResult, parseError error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, parseError := csvReader.Read()
+	if parseError != nil {
+		return nil, fmt.Errorf("failed to read header line: %s", parseError)
+	}
+	columns := make(map[string]int)
+	for index, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = index
+	}
+	for _, requiredColumn := range requiredImportColumns {
+		if _, columnExists := columns[requiredColumn]; !columnExists {
+			return nil, fmt.Errorf("missing '%s' column", requiredColumn)
+		}
+	}
+
+	for line := 2; ; line++ {
+		record, readError := csvReader.Read()
+		if readError == io.EOF {
+			return results, nil
+		}
+		if readError != nil {
+			return nil, readError
+		}
+		value := func(column string) (columnValue string) {
+			if index, columnExists := columns[column]; columnExists && index < len(record) {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+
+		result := HistoricalResult{
+			Line:     line,
+			PlayedAt: value("played_at"),
+			Type:     strings.ToLower(value("type")),
+			Scorer:   value("scorer"),
+			Opponent: value("opponent"),
+			Player:   value("player"),
+		}
+		if gamelle := value("gamelle"); gamelle != "" {
+			result.Gamelle, parseError = strconv.ParseBool(gamelle)
+			if parseError != nil {
+				return nil, fmt.Errorf("line %d: 'gamelle' column must be true or false", line)
+			}
+		}
+		results = append(results, result)
+	}
+}
+
+// checkResult checks that a historical result follows foosball rules, returning time at which it was played.
+//
+func checkResult(result HistoricalResult, location *time.Location, now time.Time) (playedAt time.Time, checkError error) {
+	for _, playedAtLayout := range playedAtLayouts {
+		playedAt, checkError = time.ParseInLocation(playedAtLayout, result.PlayedAt, location)
+		if checkError == nil {
+			break
+		}
+	}
+	if checkError != nil {
+		return playedAt, fmt.Errorf("played_at '%s' must be formatted as YYYY-MM-DD HH:MM[:SS] or RFC 3339", result.PlayedAt)
+	}
+	if playedAt.After(now) {
+		return playedAt, fmt.Errorf("played_at '%s' is in the future", result.PlayedAt)
+	}
+	if result.Scorer == "" || result.Opponent == "" || result.Scorer == result.Opponent {
+		return playedAt, errors.New("result must be played between two different users")
+	}
+
+	switch result.Type {
+	case ResultGoal:
+		if !checkPlayerExists(result.Player) {
+			return playedAt, fmt.Errorf(`submitted goal player "%s" does not exist`, result.Player)
+		}
+	case ResultSet:
+	default:
+		return playedAt, fmt.Errorf("type '%s' must be one of '%s' or '%s'", result.Type, ResultGoal, ResultSet)
+	}
+	return playedAt, nil
+}
+
+// ImportResults checks historical results, then replays them in chronological order into scores (results played at
+// the same time keeping their order in file).
+//
+// Goals are replayed as if they were submitted to API when they were played ("pissette", "gamelle" and "demi" rules
+// included), in score between users of season active at that time, and may unlock achievements.
+// Finished sets are added to sets of their winner and recorded as goals of GoalKindSetResult kind (so that they count
+// wherever finished sets are read from goals), and are refused while a set is in progress between users.
+// Results older than last result already recorded between their users are conflicts, as they cannot be replayed in
+// order (this also prevents importing a file twice). Imported results are not sent to webhooks, nor to live sessions,
+// matches and queue.
+// Imported sets are older than sets already recorded for their users: streaks of users of imported results are thus
+// rebuilt from all their sets in chronological order, once results are replayed.
+//
+// All results are imported in a single transaction, which is rolled back on dry runs and as soon as a conflict is found
+// (all conflicts being still reported).
+//
+func ImportResults(databaseConnection *pop.Connection, results []HistoricalResult, dryRun bool, now time.Time) (report ImportReport, importError error) {
+	var replayedResults []replayedResult
+
+	report = ImportReport{DryRun: dryRun, Conflicts: []ImportConflict{}}
+	location, importError := office.Location()
+	if importError != nil {
+		return report, importError
+	}
+
+	for _, result := range results {
+		playedAt, checkError := checkResult(result, location, now)
+		if checkError != nil {
+			report.Conflicts = append(report.Conflicts, ImportConflict{Line: result.Line, Message: checkError.Error()})
+			continue
+		}
+		replayedResults = append(replayedResults, replayedResult{HistoricalResult: result, playedAt: playedAt})
+	}
+	sort.SliceStable(replayedResults, func(i, j int) bool {
+		return replayedResults[i].playedAt.Before(replayedResults[j].playedAt)
+	})
+
+	importError = databaseConnection.Transaction(func(tx *pop.Connection) (transactionError error) {
+		replayedScores := make(map[string]bool)
+		for _, result := range replayedResults {
+			replayError, transactionError := replayResult(tx, result, replayedScores)
+			if transactionError != nil {
+				return transactionError
+			}
+			switch {
+			case replayError != nil:
+				report.Conflicts = append(report.Conflicts, ImportConflict{Line: result.Line, Message: replayError.Error()})
+			case result.Type == ResultGoal:
+				report.Goals++
+			default:
+				report.Sets++
+			}
+		}
+
+		for _, userID := range resultsUsers(replayedResults) {
+			transactionError = rebuildStreak(tx, userID)
+			if transactionError != nil {
+				return transactionError
+			}
+		}
+
+		if dryRun || len(report.Conflicts) != 0 {
+			return errImportRolledBack
+		}
+		return nil
+	})
+	if importError != nil && importError != errImportRolledBack {
+		return report, importError
+	}
+
+	sort.SliceStable(report.Conflicts, func(i, j int) bool {
+		return report.Conflicts[i].Line < report.Conflicts[j].Line
+	})
+	report.Imported = importError == nil
+	return report, nil
+}
+
+// resultsUsers returns users of submitted results, sorted by ID.
+//
+func resultsUsers(results []replayedResult) (userIDs []string) {
+	users := make(map[string]bool)
+	for _, result := range results {
+		users[result.Scorer], users[result.Opponent] = true, true
+	}
+	for userID := range users {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	return userIDs
+}
+
+// replayResult replays a historical result into score between its users, dating changes of time result was played.
+//
+// Results which cannot be replayed raise a replayError, whereas database failures raise a replayFailure.
+// Scores already replayed in this import are listed, so that they are only checked against results recorded before.
+//
+func replayResult(tx *pop.Connection, result replayedResult, replayedScores map[string]bool) (replayError error, replayFailure error) {
+	var resultScore models.Score
+
+	activeSeason, activeSeasonExists, replayFailure := models.FindActiveSeason(tx, result.playedAt)
+	if replayFailure != nil {
+		return nil, replayFailure
+	}
+	scoreQuery := pairScoreQuery(tx, result.Scorer, result.Opponent, activeSeason, activeSeasonExists)
+	scoreAlreadyExists, replayFailure := scoreQuery.Exists(models.Score{})
+	if replayFailure != nil {
+		return nil, replayFailure
+	}
+
+	if scoreAlreadyExists {
+		replayFailure = scoreQuery.First(&resultScore)
+		if replayFailure != nil {
+			return nil, replayFailure
+		}
+		scoreInProgress := resultScore.SetsPlayed() > 0 || resultScore.User1Points != 0 || resultScore.User2Points != 0 || resultScore.GoalsInBalance != 0
+		if !replayedScores[resultScore.ID.String()] && scoreInProgress && !resultScore.UpdatedAt.Before(result.playedAt) {
+			return fmt.Errorf("results between '%s' and '%s' have already been recorded after %s", result.Scorer, result.Opponent, result.PlayedAt), nil
+		}
+	} else {
+		resultScore.User1Id = result.Scorer
+		resultScore.User2Id = result.Opponent
+		if activeSeasonExists {
+			resultScore.SeasonID = nulls.NewUUID(activeSeason.ID)
+		}
+	}
+
+	if result.Type == ResultSet {
+		replayError, replayFailure = replaySet(tx, &resultScore, result)
+	} else {
+		replayError, replayFailure = replayGoal(tx, &resultScore, result)
+	}
+	if replayError != nil || replayFailure != nil {
+		return replayError, replayFailure
+	}
+
+	replayedScores[resultScore.ID.String()] = true
+	if !scoreAlreadyExists {
+		replayFailure = tx.RawQuery("UPDATE scores SET created_at = ? WHERE id = ?", result.playedAt, resultScore.ID).Exec()
+		if replayFailure != nil {
+			return nil, replayFailure
+		}
+	}
+	return nil, tx.RawQuery("UPDATE scores SET updated_at = ? WHERE id = ?", result.playedAt, resultScore.ID).Exec()
+}
+
+// replayGoal replays a historical goal into its score, as StoreGoal would have done when goal was played.
+//
+func replayGoal(tx *pop.Connection, resultScore *models.Score, result replayedResult) (replayError error, replayFailure error) {
+	replayedGoal := Goal{Scorer: result.Scorer, Opponent: result.Opponent, Player: result.Player, Gamelle: result.Gamelle}
+
+	scoreBeforeGoal := *resultScore
+	replayError = updateScore(resultScore, replayedGoal)
+	if replayError != nil {
+		return replayError, nil
+	}
+
+	storedGoal, _, replayFailure := recordGoal(context.Background(), tx, resultScore, replayedGoal, scoreBeforeGoal, nil)
+	if replayFailure != nil {
+		return nil, replayFailure
+	}
+	replayFailure = tx.RawQuery("UPDATE goals SET created_at = ?, updated_at = ? WHERE id = ?", result.playedAt, result.playedAt, storedGoal.ID).Exec()
+	if replayFailure != nil {
+		return nil, replayFailure
+	}
+	storedGoal.CreatedAt, storedGoal.UpdatedAt = result.playedAt, result.playedAt
+
+	_, replayFailure = achievements.Unlock(tx, achievements.Event{Goal: storedGoal, ScoreBefore: scoreBeforeGoal, ScoreAfter: *resultScore})
+	return nil, replayFailure
+}
+
+// replaySet replays a historical finished set into its score, adding it to sets of its winner, and records it as a goal
+// of its winner finishing set (see models.GoalKindSetResult), dated of time set was played.
+//
+// Streaks are not updated, as they are rebuilt once all results are imported.
+//
+func replaySet(tx *pop.Connection, resultScore *models.Score, result replayedResult) (replayError error, replayFailure error) {
+	var validateError *validate.Errors
+
+	if resultScore.User1Points != 0 || resultScore.User2Points != 0 || resultScore.GoalsInBalance != 0 {
+		return fmt.Errorf("a set is in progress between '%s' and '%s' at %s", result.Scorer, result.Opponent, result.PlayedAt), nil
+	}
+	scoreBeforeSet := *resultScore
+	resultScore.ChangeSet(result.Scorer)
+
+	validateError, replayFailure = tx.ValidateAndSave(resultScore)
+	if replayFailure != nil {
+		return nil, replayFailure
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return validateError, nil
+	}
+
+	setGoal := models.Goal{
+		ScoreID:     resultScore.ID,
+		ScorerId:    result.Scorer,
+		OpponentId:  result.Opponent,
+		Kind:        models.GoalKindSetResult,
+		SetFinished: true,
+		Handicapped: scoreBeforeSet.IsHandicapped(),
+		SeasonID:    resultScore.SeasonID,
+		MatchID:     resultScore.MatchID,
+	}
+	validateError, replayFailure = tx.ValidateAndCreate(&setGoal)
+	if replayFailure != nil {
+		return nil, replayFailure
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return validateError, nil
+	}
+	replayFailure = tx.RawQuery("UPDATE goals SET created_at = ?, updated_at = ? WHERE id = ?", result.playedAt, result.playedAt, setGoal.ID).Exec()
+	if replayFailure != nil {
+		return nil, replayFailure
+	}
+	setGoal.CreatedAt, setGoal.UpdatedAt = result.playedAt, result.playedAt
+
+	_, replayFailure = achievements.Unlock(tx, achievements.Event{Goal: setGoal, ScoreBefore: scoreBeforeSet, ScoreAfter: *resultScore})
+	return nil, replayFailure
+}
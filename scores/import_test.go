@@ -0,0 +1,99 @@
+package scores
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseResultsCSV tests ParseResults function reading CSV files, whatever the order of their columns.
+//
+func TestParseResultsCSV(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	results, parseError := ParseResults(strings.NewReader(`Type,Played_at,Scorer,Opponent,Player,Gamelle,Comment
+goal,2018-03-12 12:31,user1,user2,p3,false,nice one
+Goal, 2018-03-12 12:32,user2,user1,p5,TRUE,
+set,2018-03-12 12:45,user1,user2,,,
+`), ImportFormatCSV)
+	assertHandler.Nil(parseError, "CSV file should be parsed")
+	assertHandler.Equal([]HistoricalResult{
+		{Line: 2, PlayedAt: "2018-03-12 12:31", Type: ResultGoal, Scorer: "user1", Opponent: "user2", Player: "p3"},
+		{Line: 3, PlayedAt: "2018-03-12 12:32", Type: ResultGoal, Scorer: "user2", Opponent: "user1", Player: "p5", Gamelle: true},
+		{Line: 4, PlayedAt: "2018-03-12 12:45", Type: ResultSet, Scorer: "user1", Opponent: "user2"},
+	}, results, "Results should be read with their line in file")
+
+	_, parseError = ParseResults(strings.NewReader("played_at,type,scorer\n"), ImportFormatCSV)
+	assertHandler.NotNil(parseError, "CSV file without opponent column should be refused")
+
+	_, parseError = ParseResults(strings.NewReader("played_at,type,scorer,opponent,player,gamelle\n2018-03-12 12:31,goal,user1,user2,p3,maybe\n"), ImportFormatCSV)
+	assertHandler.NotNil(parseError, "CSV file with invalid gamelle should be refused")
+}
+
+// TestParseResultsJSON tests ParseResults function reading JSON files.
+//
+func TestParseResultsJSON(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	results, parseError := ParseResults(strings.NewReader(`[
+		{"played_at": "2018-03-12T12:31:00+01:00", "type": "goal", "scorer": "user1", "opponent": "user2", "player": "p9"},
+		{"played_at": "2018-03-12 12:45", "type": "set", "scorer": "user2", "opponent": "user1"}
+	]`), ImportFormatJSON)
+	assertHandler.Nil(parseError, "JSON file should be parsed")
+	assertHandler.Len(results, 2, "All results should be read")
+	assertHandler.Equal(1, results[0].Line, "Results should be numbered by their position in file")
+	assertHandler.Equal(2, results[1].Line, "Results should be numbered by their position in file")
+	assertHandler.Equal("p9", results[0].Player, "Results should be read with their JSON fields")
+
+	_, parseError = ParseResults(strings.NewReader(`{"type": "goal"}`), ImportFormatJSON)
+	assertHandler.NotNil(parseError, "JSON file which is not an array should be refused")
+
+	_, parseError = ParseResults(strings.NewReader(`[]`), "xlsx")
+	assertHandler.NotNil(parseError, "Unknown format should be refused")
+}
+
+// TestCheckResult tests checkResult function checking historical results against foosball rules.
+//
+func TestCheckResult(t *testing.T) {
+	assertHandler := assert.New(t)
+	paris, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, paris)
+
+	playedAt, checkError := checkResult(HistoricalResult{PlayedAt: "2018-03-12 12:31", Type: ResultGoal, Scorer: "user1", Opponent: "user2", Player: "p3"}, paris, now)
+	assertHandler.Nil(checkError, "Classic goal should be accepted")
+	assertHandler.Equal(time.Date(2018, 3, 12, 12, 31, 0, 0, paris), playedAt, "Date should be read in office timezone")
+
+	playedAt, checkError = checkResult(HistoricalResult{PlayedAt: "2018-03-12T11:31:00Z", Type: ResultGoal, Scorer: "user1", Opponent: "user2", Player: "p9"}, paris, now)
+	assertHandler.Nil(checkError, "Pissette goal should be accepted")
+	assertHandler.True(time.Date(2018, 3, 12, 12, 31, 0, 0, paris).Equal(playedAt), "RFC 3339 date should keep its timezone")
+
+	_, checkError = checkResult(HistoricalResult{PlayedAt: "2018-03-12 12:31", Type: ResultGoal, Scorer: "user1", Opponent: "user2", Player: "p6", Gamelle: true}, paris, now)
+	assertHandler.Nil(checkError, "Gamelle scored by demi should be accepted")
+
+	_, checkError = checkResult(HistoricalResult{PlayedAt: "2018-03-12 12:45", Type: ResultSet, Scorer: "user1", Opponent: "user2"}, paris, now)
+	assertHandler.Nil(checkError, "Finished set should be accepted without player")
+
+	for description, result := range map[string]HistoricalResult{
+		"unknown player":   {PlayedAt: "2018-03-12 12:31", Type: ResultGoal, Scorer: "user1", Opponent: "user2", Player: "p12"},
+		"missing player":   {PlayedAt: "2018-03-12 12:31", Type: ResultGoal, Scorer: "user1", Opponent: "user2"},
+		"same users":       {PlayedAt: "2018-03-12 12:31", Type: ResultGoal, Scorer: "user1", Opponent: "user1", Player: "p3"},
+		"missing opponent": {PlayedAt: "2018-03-12 12:45", Type: ResultSet, Scorer: "user1"},
+		"unknown type":     {PlayedAt: "2018-03-12 12:31", Type: "match", Scorer: "user1", Opponent: "user2"},
+		"invalid date":     {PlayedAt: "12/03/2018", Type: ResultGoal, Scorer: "user1", Opponent: "user2", Player: "p3"},
+		"future date":      {PlayedAt: "2026-10-20 12:00", Type: ResultGoal, Scorer: "user1", Opponent: "user2", Player: "p3"},
+	} {
+		_, checkError = checkResult(result, paris, now)
+		assertHandler.NotNil(checkError, "Result should be refused: %s", description)
+	}
+}
+
+// TestResultsUsers tests users whose streaks are rebuilt after an import being listed once each.
+//
+func TestResultsUsers(t *testing.T) {
+	results := []replayedResult{
+		{HistoricalResult: HistoricalResult{Scorer: "user2", Opponent: "user1"}},
+		{HistoricalResult: HistoricalResult{Scorer: "user3", Opponent: "user2"}},
+	}
+	assert.Equal(t, []string{"user1", "user2", "user3"}, resultsUsers(results), "Users of results should be listed once, sorted")
+}
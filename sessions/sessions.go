@@ -0,0 +1,209 @@
+package sessions
+
+import (
+	"errors"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"time"
+)
+
+// Errors raised when a session cannot change its status.
+var (
+	ErrSessionNotPlaying = errors.New("session must be playing")
+	ErrSessionNotPaused  = errors.New("session must be paused")
+	ErrSessionEnded      = errors.New("session is already ended")
+)
+
+// LiveSession represents a session in progress, with time played so far and durations of its finished sets.
+//
+// IdleSeconds is the time since last goal of session (or since its start), helping to spot abandoned sessions.
+//
+type LiveSession struct {
+	models.Session
+	ElapsedSeconds int   `json:"elapsed_seconds"`
+	IdleSeconds    int   `json:"idle_seconds"`
+	SetDurations   []int `json:"set_durations"`
+}
+
+// DurationStats represents statistics of durations of finished sets (in seconds).
+//
+type DurationStats struct {
+	SetsCount       int     `json:"sets_count" db:"sets_count"`
+	AverageSeconds  float64 `json:"average_seconds" db:"average_seconds"`
+	ShortestSeconds int     `json:"shortest_seconds" db:"shortest_seconds"`
+	LongestSeconds  int     `json:"longest_seconds" db:"longest_seconds"`
+}
+
+// Pause stops time of a playing session.
+//
+func Pause(session *models.Session, at time.Time) (pauseError error) {
+	if session.Status != models.SessionStatusPlaying {
+		return ErrSessionNotPlaying
+	}
+
+	session.Status = models.SessionStatusPaused
+	session.PausedAt = nulls.NewTime(at)
+	return nil
+}
+
+// Resume restarts time of a paused session, time spent in pause being added to paused time of session.
+//
+func Resume(session *models.Session, at time.Time) (resumeError error) {
+	if session.Status != models.SessionStatusPaused {
+		return ErrSessionNotPaused
+	}
+
+	session.PausedSeconds += int(at.Sub(session.PausedAt.Time).Seconds())
+	session.Status = models.SessionStatusPlaying
+	session.PausedAt = nulls.Time{}
+	return nil
+}
+
+// End finishes a session, resuming it first if it is paused (so that time spent in final pause is not counted).
+//
+func End(session *models.Session, at time.Time) (endError error) {
+	if session.Status == models.SessionStatusEnded {
+		return ErrSessionEnded
+	}
+	if session.Status == models.SessionStatusPaused {
+		endError = Resume(session, at)
+		if endError != nil {
+			return endError
+		}
+	}
+
+	session.Status = models.SessionStatusEnded
+	session.EndedAt = nulls.NewTime(at)
+	return nil
+}
+
+// SetDurations computes durations of sets finished during session, from time played in session when goals finishing
+// them were scored.
+//
+// First set starts with session, next ones right after goal finishing previous set: time spent in pause is not
+// counted in durations of sets.
+//
+func SetDurations(session models.Session, sessionGoals []models.Goal) (durations []time.Duration) {
+	var setStart time.Duration
+
+	durations = []time.Duration{}
+	for _, sessionGoal := range sessionGoals {
+		if !sessionGoal.SetFinished {
+			continue
+		}
+		setEnd := playedTime(session, sessionGoal)
+		durations = append(durations, setEnd-setStart)
+		setStart = setEnd
+	}
+	return durations
+}
+
+// playedTime returns time played in session when submitted goal was scored.
+//
+// Goals without stored time played (scored before it was stored) fall back to time since start of session, pauses
+// included.
+//
+func playedTime(session models.Session, sessionGoal models.Goal) (played time.Duration) {
+	if sessionGoal.SessionSeconds.Valid {
+		return time.Duration(sessionGoal.SessionSeconds.Int) * time.Second
+	}
+	return sessionGoal.CreatedAt.Sub(session.StartedAt)
+}
+
+// BuildLiveSession computes time played in session and durations of its sets at submitted time.
+//
+// Goals of session must be sorted by ascending creation time.
+//
+func BuildLiveSession(session models.Session, sessionGoals []models.Goal, at time.Time) (liveSession LiveSession) {
+	lastActivity := session.StartedAt
+	if len(sessionGoals) > 0 {
+		lastActivity = sessionGoals[len(sessionGoals)-1].CreatedAt
+	}
+
+	liveSession = LiveSession{
+		Session:        session,
+		ElapsedSeconds: int(session.Elapsed(at).Seconds()),
+		IdleSeconds:    int(at.Sub(lastActivity).Seconds()),
+		SetDurations:   []int{},
+	}
+	for _, duration := range SetDurations(session, sessionGoals) {
+		liveSession.SetDurations = append(liveSession.SetDurations, int(duration.Seconds()))
+	}
+	return liveSession
+}
+
+// FetchLiveSessions retrieves sessions in progress (playing or paused), from oldest to newest.
+//
+func FetchLiveSessions(tx *pop.Connection, at time.Time) (liveSessions []LiveSession, fetchError error) {
+	var activeSessions []models.Session
+
+	fetchError = tx.Where("status != ?", models.SessionStatusEnded).Order("started_at").All(&activeSessions)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+
+	goalsBySession, fetchError := fetchSessionGoals(tx, activeSessions)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+
+	liveSessions = []LiveSession{}
+	for _, activeSession := range activeSessions {
+		liveSessions = append(liveSessions, BuildLiveSession(activeSession, goalsBySession[activeSession.ID.String()], at))
+	}
+	return liveSessions, nil
+}
+
+// FetchSetDurations computes statistics of durations of all sets finished during sessions (optionally only sessions
+// of submitted user).
+//
+// Durations are computed and aggregated by database as SetDurations does (goals without stored time played falling
+// back to time since start of session), so that sessions are not loaded in memory.
+//
+func FetchSetDurations(tx *pop.Connection, userID string) (stats DurationStats, fetchError error) {
+	var userCondition = "TRUE"
+	var args []interface{}
+
+	if userID != "" {
+		userCondition = "(sessions.user1_id = ? OR sessions.user2_id = ?)"
+		args = append(args, userID, userID)
+	}
+
+	fetchError = tx.RawQuery(`SELECT COUNT(seconds) AS sets_count, COALESCE(ROUND(AVG(seconds), 1), 0) AS average_seconds,
+		COALESCE(MIN(seconds), 0) AS shortest_seconds, COALESCE(MAX(seconds), 0) AS longest_seconds FROM (
+		SELECT played_seconds - COALESCE(LAG(played_seconds) OVER (PARTITION BY session_id ORDER BY created_at), 0) AS seconds FROM (
+			SELECT goals.session_id, goals.created_at,
+				COALESCE(goals.session_seconds, FLOOR(EXTRACT(EPOCH FROM goals.created_at - sessions.started_at))) AS played_seconds
+			FROM goals JOIN sessions ON sessions.id = goals.session_id
+			WHERE goals.set_finished AND `+userCondition+`
+		) AS played_times
+	) AS set_durations`, args...).First(&stats)
+	return stats, fetchError
+}
+
+// fetchSessionGoals retrieves goals of submitted sessions, sorted by ascending creation time and indexed by session id.
+//
+func fetchSessionGoals(tx *pop.Connection, sessionsToFetch []models.Session) (goalsBySession map[string][]models.Goal, fetchError error) {
+	var sessionGoals []models.Goal
+	var sessionIDs []interface{}
+
+	goalsBySession = make(map[string][]models.Goal)
+	if len(sessionsToFetch) == 0 {
+		return goalsBySession, nil
+	}
+
+	for _, sessionToFetch := range sessionsToFetch {
+		sessionIDs = append(sessionIDs, sessionToFetch.ID)
+	}
+	fetchError = tx.Where("session_id IN (?)", sessionIDs...).Order("created_at").All(&sessionGoals)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+
+	for _, sessionGoal := range sessionGoals {
+		sessionID := sessionGoal.SessionID.UUID.String()
+		goalsBySession[sessionID] = append(goalsBySession[sessionID], sessionGoal)
+	}
+	return goalsBySession, nil
+}
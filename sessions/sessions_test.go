@@ -0,0 +1,71 @@
+package sessions
+
+import (
+	"github.com/gobuffalo/nulls"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+	"time"
+)
+
+// TestSessionLifecycle tests Pause, Resume and End functions changing status of a session.
+//
+func TestSessionLifecycle(t *testing.T) {
+	assertHandler := assert.New(t)
+	startedAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	session := models.Session{User1Id: "user1", User2Id: "user2", Status: models.SessionStatusPlaying, StartedAt: startedAt}
+
+	assertHandler.Equal(ErrSessionNotPaused, Resume(&session, startedAt), "Playing session: session should not be resumed")
+	assertHandler.NoError(Pause(&session, startedAt.Add(5*time.Minute)), "Playing session: session should be paused")
+	assertHandler.Equal(ErrSessionNotPlaying, Pause(&session, startedAt.Add(6*time.Minute)), "Paused session: session should not be paused again")
+	assertHandler.Equal(5*time.Minute, session.Elapsed(startedAt.Add(8*time.Minute)), "Paused session: time should stop running")
+
+	assertHandler.NoError(Resume(&session, startedAt.Add(10*time.Minute)), "Paused session: session should be resumed")
+	assertHandler.Equal(300, session.PausedSeconds, "Resumed session: time spent in pause should be accumulated")
+	assertHandler.Equal(7*time.Minute, session.Elapsed(startedAt.Add(12*time.Minute)), "Resumed session: pause should not be counted")
+
+	assertHandler.NoError(Pause(&session, startedAt.Add(15*time.Minute)), "Playing session: session should be paused")
+	assertHandler.NoError(End(&session, startedAt.Add(20*time.Minute)), "Paused session: session should be ended")
+	assertHandler.Equal(models.SessionStatusEnded, session.Status, "Ended session: status should be ended")
+	assertHandler.Equal(10*time.Minute, session.Elapsed(startedAt.Add(time.Hour)), "Ended session: time should stop at end, final pause excluded")
+	assertHandler.Equal(ErrSessionEnded, End(&session, startedAt.Add(time.Hour)), "Ended session: session should not be ended again")
+}
+
+// TestBuildLiveSession tests BuildLiveSession function computing set durations from goal timestamps.
+//
+func TestBuildLiveSession(t *testing.T) {
+	assertHandler := assert.New(t)
+	startedAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	session := models.Session{User1Id: "user1", User2Id: "user2", Status: models.SessionStatusPlaying, StartedAt: startedAt}
+
+	liveSession := BuildLiveSession(session, nil, startedAt.Add(time.Minute))
+	assertHandler.Equal([]int{}, liveSession.SetDurations, "No goal: no set should be finished")
+	assertHandler.Equal(60, liveSession.IdleSeconds, "No goal: idle time should run since start")
+
+	sessionGoals := []models.Goal{
+		{CreatedAt: startedAt.Add(2 * time.Minute)},
+		{CreatedAt: startedAt.Add(4 * time.Minute), SetFinished: true},
+		{CreatedAt: startedAt.Add(5 * time.Minute)},
+		{CreatedAt: startedAt.Add(7 * time.Minute), SetFinished: true},
+		{CreatedAt: startedAt.Add(8 * time.Minute)},
+	}
+	liveSession = BuildLiveSession(session, sessionGoals, startedAt.Add(10*time.Minute))
+	assertHandler.Equal([]int{240, 180}, liveSession.SetDurations, "Finished sets: sets should last until goal finishing them")
+	assertHandler.Equal(600, liveSession.ElapsedSeconds, "Playing session: elapsed time should run since start")
+	assertHandler.Equal(120, liveSession.IdleSeconds, "Goals: idle time should run since last goal")
+}
+
+// TestSetDurations tests SetDurations function not counting time spent in pause in durations of sets.
+//
+func TestSetDurations(t *testing.T) {
+	startedAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	session := models.Session{User1Id: "user1", User2Id: "user2", Status: models.SessionStatusPlaying, StartedAt: startedAt}
+
+	// Session was paused 10 minutes during second set
+	sessionGoals := []models.Goal{
+		{CreatedAt: startedAt.Add(4 * time.Minute), SessionSeconds: nulls.NewInt(240), SetFinished: true},
+		{CreatedAt: startedAt.Add(6 * time.Minute), SessionSeconds: nulls.NewInt(360)},
+		{CreatedAt: startedAt.Add(18 * time.Minute), SessionSeconds: nulls.NewInt(480), SetFinished: true},
+	}
+	assert.Equal(t, []time.Duration{4 * time.Minute, 4 * time.Minute}, SetDurations(session, sessionGoals), "Paused set: pause should not be counted in set duration")
+}
@@ -0,0 +1,202 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/scores"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signatureVersion is the version of Slack request signatures, prefixing both signed content and signature.
+const signatureVersion = "v0"
+
+// maxRequestAge is the maximum age of a Slack request, older requests being refused to prevent replay attacks.
+const maxRequestAge = 5 * time.Minute
+
+// Response types of slash command replies: only visible by user who typed command, or posted in channel.
+const (
+	ResponseTypeEphemeral = "ephemeral"
+	ResponseTypeInChannel = "in_channel"
+)
+
+// Errors raised when a Slack request or command cannot be handled.
+var (
+	ErrMissingSigningSecret = errors.New("signing secret is not configured")
+	ErrExpiredRequest       = errors.New("request timestamp is missing or too old")
+	ErrInvalidSignature     = errors.New("request signature does not match")
+	ErrUnknownCommand       = errors.New("unknown command, usage: goal @scorer vs @opponent p<1-11> [gamelle] [match <id>]")
+	ErrUnknownUser          = errors.New("Slack user is not linked to any foosball user")
+)
+
+// Command represents a command typed by a user after "/foosball".
+//
+type Command struct {
+	Action      string
+	Goal        scores.Goal
+	slackScorer string
+}
+
+// Reply represents a message replied to a slash command.
+//
+type Reply struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// SigningSecret returns secret shared with Slack to sign requests, configured in environment variables.
+//
+func SigningSecret() (signingSecret string) {
+	return os.Getenv("SLACK_SIGNING_SECRET")
+}
+
+// UserMapping returns foosball users of Slack users, configured in environment variables.
+//
+// Mapping is a comma separated list of "<Slack user ID or name>=<foosball user ID>" entries.
+//
+func UserMapping() (mapping map[string]string) {
+	return ParseUserMapping(os.Getenv("SLACK_USERS"))
+}
+
+// ParseUserMapping parses a comma separated list of "<Slack user ID or name>=<foosball user ID>" entries.
+//
+func ParseUserMapping(rawMapping string) (mapping map[string]string) {
+	mapping = make(map[string]string)
+	for _, entry := range strings.Split(rawMapping, ",") {
+		entryParts := strings.SplitN(entry, "=", 2)
+		if len(entryParts) != 2 {
+			continue
+		}
+		slackUser, foosballUser := strings.TrimSpace(entryParts[0]), strings.TrimSpace(entryParts[1])
+		if slackUser != "" && foosballUser != "" {
+			mapping[slackUser] = foosballUser
+		}
+	}
+	return mapping
+}
+
+// Sign computes signature of a Slack request body, as sent by Slack in "X-Slack-Signature" header.
+//
+func Sign(signingSecret string, timestamp string, body string) (signature string) {
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte(fmt.Sprintf("%s:%s:%s", signatureVersion, timestamp, body)))
+	return fmt.Sprintf("%s=%s", signatureVersion, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Verify checks that a request has been sent by Slack, from its timestamp and signature headers.
+//
+// Requests older than maxRequestAge are refused, even when properly signed.
+//
+func Verify(signingSecret string, timestamp string, signature string, body string, at time.Time) (verifyError error) {
+	if signingSecret == "" {
+		return ErrMissingSigningSecret
+	}
+
+	requestTime, conversionError := strconv.ParseInt(timestamp, 10, 64)
+	if conversionError != nil || math.Abs(at.Sub(time.Unix(requestTime, 0)).Seconds()) > maxRequestAge.Seconds() {
+		return ErrExpiredRequest
+	}
+
+	if !hmac.Equal([]byte(Sign(signingSecret, timestamp, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParseCommand parses text of a slash command, such as "goal @alice vs @bob p3 gamelle".
+//
+// Users can be mentioned by name ("@alice") or as escaped by Slack ("<@U0ALICE|alice>"), and are converted to
+// foosball users with submitted mapping. Users absent from mapping raise an ErrUnknownUser.
+//
+func ParseCommand(text string, mapping map[string]string) (command Command, parseError error) {
+	var users []string
+
+	words := strings.Fields(text)
+	if len(words) == 0 || strings.ToLower(words[0]) != "goal" {
+		return command, ErrUnknownCommand
+	}
+	command.Action = "goal"
+
+	for index := 1; index < len(words); index++ {
+		word := words[index]
+		switch lowerWord := strings.ToLower(word); {
+		case strings.HasPrefix(word, "@") || strings.HasPrefix(word, "<@"):
+			user, userError := resolveUser(word, mapping)
+			if userError != nil {
+				return command, userError
+			}
+			users = append(users, user)
+			if len(users) == 1 {
+				command.slackScorer = word
+			}
+		case lowerWord == "vs":
+		case lowerWord == "gamelle":
+			command.Goal.Gamelle = true
+		case lowerWord == "match" && index+1 < len(words):
+			index++
+			command.Goal.MatchID = words[index]
+		case strings.HasPrefix(lowerWord, "p") && models.PlayerPosition(lowerWord) != "":
+			command.Goal.Player = lowerWord
+		default:
+			return command, ErrUnknownCommand
+		}
+	}
+
+	if len(users) != 2 || command.Goal.Player == "" {
+		return command, ErrUnknownCommand
+	}
+	command.Goal.Scorer, command.Goal.Opponent = users[0], users[1]
+	return command, nil
+}
+
+// FormatScoreboard formats score between users after a goal, as a Slack message posted in channel.
+//
+func FormatScoreboard(command Command, goalScore models.Score) (reply Reply) {
+	var lines []string
+
+	lines = append(lines, fmt.Sprintf("%s scored a %s goal with %s", command.slackScorer, strings.Replace(command.Goal.Kind(), "_", " ", -1), command.Goal.Player))
+	for _, userID := range []string{command.Goal.Scorer, command.Goal.Opponent} {
+		lines = append(lines, fmt.Sprintf("*%s*: %d set(s), %d point(s)", userID, goalScore.UserSets(userID), goalScore.UserPoints(userID)))
+	}
+	if goalScore.GoalsInBalance > 0 {
+		lines = append(lines, fmt.Sprintf("_%d goal(s) in balance_", goalScore.GoalsInBalance))
+	}
+
+	return Reply{ResponseType: ResponseTypeInChannel, Text: strings.Join(lines, "\n")}
+}
+
+// FormatError formats an error as a Slack message only visible by user who typed command.
+//
+func FormatError(replyError error) (reply Reply) {
+	return Reply{ResponseType: ResponseTypeEphemeral, Text: fmt.Sprintf("Goal not recorded: %s", replyError)}
+}
+
+// resolveUser converts a Slack user mention to a foosball user.
+//
+func resolveUser(mention string, mapping map[string]string) (user string, resolveError error) {
+	if strings.HasPrefix(mention, "<@") {
+		slackUser := strings.TrimSuffix(strings.TrimPrefix(mention, "<@"), ">")
+		slackUserParts := strings.SplitN(slackUser, "|", 2)
+		if foosballUser, linked := mapping[slackUserParts[0]]; linked {
+			return foosballUser, nil
+		}
+		if len(slackUserParts) == 2 {
+			if foosballUser, linked := mapping[slackUserParts[1]]; linked {
+				return foosballUser, nil
+			}
+		}
+		return "", ErrUnknownUser
+	}
+
+	if foosballUser, linked := mapping[strings.TrimPrefix(mention, "@")]; linked {
+		return foosballUser, nil
+	}
+	return "", ErrUnknownUser
+}
@@ -0,0 +1,102 @@
+package slack
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testSigningSecret is the signing secret used to sign recorded requests of testdata directory.
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+// loadRecordedRequest loads a slash command request recorded in testdata directory, as received by Lambda.
+//
+func loadRecordedRequest(t *testing.T, fileName string) (recordedRequest events.APIGatewayProxyRequest) {
+	recordedPayload, readError := ioutil.ReadFile(filepath.Join("testdata", fileName))
+	if readError != nil {
+		t.Fatal(readError)
+	}
+	if unmarshalError := json.Unmarshal(recordedPayload, &recordedRequest); unmarshalError != nil {
+		t.Fatal(unmarshalError)
+	}
+	return recordedRequest
+}
+
+// TestVerify tests Verify function against recorded signed, forged and replayed requests.
+//
+func TestVerify(t *testing.T) {
+	assertHandler := assert.New(t)
+	signedAt := time.Unix(1792414800, 0)
+
+	recordedRequest := loadRecordedRequest(t, "goal_command.json")
+	timestamp, signature := recordedRequest.Headers["X-Slack-Request-Timestamp"], recordedRequest.Headers["X-Slack-Signature"]
+	assertHandler.Nil(Verify(testSigningSecret, timestamp, signature, recordedRequest.Body, signedAt.Add(time.Minute)), "Signed request: request should be verified")
+	assertHandler.Equal(ErrInvalidSignature, Verify("anotherSecret", timestamp, signature, recordedRequest.Body, signedAt), "Other secret: signature should not match")
+	assertHandler.Equal(ErrInvalidSignature, Verify(testSigningSecret, timestamp, signature, recordedRequest.Body+"&text=x", signedAt), "Altered body: signature should not match")
+	assertHandler.Equal(ErrExpiredRequest, Verify(testSigningSecret, timestamp, signature, recordedRequest.Body, signedAt.Add(10*time.Minute)), "Replayed request: request should be too old")
+	assertHandler.Equal(ErrExpiredRequest, Verify(testSigningSecret, "", signature, recordedRequest.Body, signedAt), "Missing timestamp: request should be refused")
+	assertHandler.Equal(ErrMissingSigningSecret, Verify("", timestamp, signature, recordedRequest.Body, signedAt), "Missing secret: request should be refused")
+
+	forgedRequest := loadRecordedRequest(t, "forged_command.json")
+	timestamp, signature = forgedRequest.Headers["X-Slack-Request-Timestamp"], forgedRequest.Headers["X-Slack-Signature"]
+	assertHandler.Equal(ErrInvalidSignature, Verify(testSigningSecret, timestamp, signature, forgedRequest.Body, signedAt), "Forged request: signature should not match")
+}
+
+// TestParseCommand tests ParseCommand function for recorded and typed commands.
+//
+func TestParseCommand(t *testing.T) {
+	var command Command
+	var parseError error
+
+	assertHandler := assert.New(t)
+	mapping := ParseUserMapping("U0ALICE=alice.martin, U0BOB=bob.durand,carol=carol.petit, broken")
+	assertHandler.Equal(map[string]string{"U0ALICE": "alice.martin", "U0BOB": "bob.durand", "carol": "carol.petit"}, mapping, "User mapping: malformed entries should be ignored")
+
+	form, _ := url.ParseQuery(loadRecordedRequest(t, "goal_command.json").Body)
+	command, parseError = ParseCommand(form.Get("text"), mapping)
+	assertHandler.Nil(parseError, "Recorded command: ParseCommand function should not raise an error")
+	assertHandler.Equal("alice.martin", command.Goal.Scorer, "Recorded command: scorer should be mapped from Slack user ID")
+	assertHandler.Equal("bob.durand", command.Goal.Opponent, "Recorded command: opponent should be mapped from Slack user ID")
+	assertHandler.Equal("p3", command.Goal.Player, "Recorded command: player not parsed as expected")
+	assertHandler.True(command.Goal.Gamelle, "Recorded command: goal should be a gamelle")
+
+	command, parseError = ParseCommand("Goal @carol vs <@U0BOB|bob> P10 match 2b4d6b1c-1f0a-4f3e-9a53-3c1b5d3a6f10", mapping)
+	assertHandler.Nil(parseError, "Typed command: ParseCommand function should not raise an error")
+	assertHandler.Equal("carol.petit", command.Goal.Scorer, "Typed command: scorer should be mapped from Slack name")
+	assertHandler.Equal("bob.durand", command.Goal.Opponent, "Typed command: opponent should be mapped from Slack user ID")
+	assertHandler.Equal("p10", command.Goal.Player, "Typed command: player not parsed as expected")
+	assertHandler.Equal("2b4d6b1c-1f0a-4f3e-9a53-3c1b5d3a6f10", command.Goal.MatchID, "Typed command: match not parsed as expected")
+
+	_, parseError = ParseCommand("goal <@U0ALICE|alice> vs <@U0EVE|eve> p3", mapping)
+	assertHandler.Equal(ErrUnknownUser, parseError, "Unlinked Slack user: ParseCommand function should raise an error")
+	_, parseError = ParseCommand("goal @carol vs @dave p3", mapping)
+	assertHandler.Equal(ErrUnknownUser, parseError, "Unlinked Slack name: ParseCommand function should raise an error")
+
+	mapping = map[string]string{"alice": "alice.martin", "bob": "bob.durand"}
+
+	for _, text := range []string{"", "score", "goal @alice vs @bob", "goal @alice p3", "goal @alice vs @bob p12", "goal @alice vs @bob p3 lob"} {
+		_, parseError = ParseCommand(text, mapping)
+		assertHandler.Equal(ErrUnknownCommand, parseError, "Invalid command \"%s\": ParseCommand function should raise an error", text)
+	}
+}
+
+// TestFormatScoreboard tests FormatScoreboard function formatting score after a goal.
+//
+func TestFormatScoreboard(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	command, _ := ParseCommand("goal <@U0ALICE|alice> vs <@U0BOB|bob> p5 gamelle", map[string]string{"U0ALICE": "alice", "U0BOB": "bob"})
+	goalScore := models.Score{User1Id: "bob", User2Id: "alice", User1Points: 3, User2Points: 7, User1Sets: 1, GoalsInBalance: 2}
+
+	reply := FormatScoreboard(command, goalScore)
+	assertHandler.Equal(ResponseTypeInChannel, reply.ResponseType, "Scoreboard should be posted in channel")
+	assertHandler.Equal("<@U0ALICE|alice> scored a demi gamelle goal with p5\n*alice*: 0 set(s), 7 point(s)\n*bob*: 1 set(s), 3 point(s)\n_2 goal(s) in balance_", reply.Text, "Scoreboard not formatted as expected")
+
+	assertHandler.Equal(ResponseTypeEphemeral, FormatError(ErrUnknownCommand).ResponseType, "Errors should only be visible by user")
+}
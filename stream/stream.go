@@ -0,0 +1,297 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gobuffalo/nulls"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of events sent to streams: updated score after each goal, finished set and finished match.
+const (
+	EventScore = "score"
+	EventSet   = "set"
+	EventMatch = "match"
+)
+
+// historySize is the number of last events kept to be replayed to reconnecting streams.
+const historySize = 256
+
+// subscriberBufferSize is the number of events waiting for a stream, a slower stream being closed (it then reconnects).
+const subscriberBufferSize = 16
+
+// heartbeatInterval is the delay between comments sent to idle streams, so that proxies do not close them.
+const heartbeatInterval = 15 * time.Second
+
+// Event represents an event sent to streams of a topic (a pair of users or a match).
+//
+type Event struct {
+	ID    uint64
+	Name  string
+	Topic string
+	Data  json.RawMessage
+}
+
+// Hub dispatches events to streams of each topic, keeping last events so that reconnecting streams can get
+// events they missed.
+//
+// Event IDs start from creation time of hub, so that IDs of a previous hub (before a restart) are never replayed.
+//
+type Hub struct {
+	mutex       sync.Mutex
+	lastID      uint64
+	oldestID    uint64
+	history     []Event
+	subscribers map[string]map[chan Event]bool
+}
+
+// Handler serves streams of score between two users (see ServeHTTP).
+//
+// Snapshot retrieves current score between users, sent when a stream starts or cannot replay missed events.
+//
+type Handler struct {
+	Hub      *Hub
+	Snapshot func(firstUserID string, secondUserID string) (score interface{}, snapshotError error)
+}
+
+// PairKey returns topic of a pair of users, whatever their order.
+//
+func PairKey(firstUserID string, secondUserID string) (pairKey string) {
+	users := []string{firstUserID, secondUserID}
+	sort.Strings(users)
+	return strings.Join(users, "|")
+}
+
+// MatchKey returns topic of a match.
+//
+func MatchKey(matchID string) (matchKey string) {
+	return "match:" + matchID
+}
+
+// NewHub creates a hub without any event nor stream.
+//
+func NewHub() (hub *Hub) {
+	startID := uint64(time.Now().UnixNano())
+	return &Hub{
+		lastID:      startID,
+		oldestID:    startID + 1,
+		subscribers: make(map[string]map[chan Event]bool),
+	}
+}
+
+// Publish sends an event to all streams of topic, and keeps it in history.
+//
+func (h *Hub) Publish(name string, topic string, data json.RawMessage) (published Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.lastID++
+	published = Event{ID: h.lastID, Name: name, Topic: topic, Data: data}
+	h.history = append(h.history, published)
+	if len(h.history) > historySize {
+		h.history = h.history[len(h.history)-historySize:]
+		h.oldestID = h.history[0].ID
+	}
+
+	for subscriber := range h.subscribers[topic] {
+		select {
+		case subscriber <- published:
+		default:
+			delete(h.subscribers[topic], subscriber)
+			close(subscriber)
+		}
+	}
+	return published
+}
+
+// Subscribe registers a stream of topic, returning channel receiving its next events and ID of last event published.
+//
+// When lastEventID is submitted (by a reconnecting stream), events of topic published since then are returned, unless
+// some of them are not in history anymore (replayed being then false, and current state having to be sent instead).
+//
+func (h *Hub) Subscribe(topic string, lastEventID uint64) (events chan Event, lastID uint64, missed []Event, replayed bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	events = make(chan Event, subscriberBufferSize)
+	if h.subscribers[topic] == nil {
+		h.subscribers[topic] = make(map[chan Event]bool)
+	}
+	h.subscribers[topic][events] = true
+
+	if lastEventID == 0 || lastEventID+1 < h.oldestID || lastEventID > h.lastID {
+		return events, h.lastID, nil, false
+	}
+	for _, event := range h.history {
+		if event.ID > lastEventID && event.Topic == topic {
+			missed = append(missed, event)
+		}
+	}
+	return events, h.lastID, missed, true
+}
+
+// Unsubscribe unregisters a stream of topic.
+//
+func (h *Hub) Unsubscribe(topic string, events chan Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.subscribers[topic][events] {
+		delete(h.subscribers[topic], events)
+		close(events)
+	}
+	if len(h.subscribers[topic]) == 0 {
+		delete(h.subscribers, topic)
+	}
+}
+
+// Dispatch publishes an event notified by database (see webhooks.Enqueue) to streams of its pair of users, and to
+// streams of its match (if any).
+//
+// Streams get normalized score when a goal is recorded, and complete event data when a set or a match is finished.
+//
+func (h *Hub) Dispatch(payload []byte) (dispatchError error) {
+	var notifiedEvent struct {
+		Type string          `json:"event"`
+		Data json.RawMessage `json:"data"`
+	}
+	var eventData struct {
+		Goal struct {
+			ScorerId   string     `json:"scorer_id"`
+			OpponentId string     `json:"opponent_id"`
+			MatchID    nulls.UUID `json:"match_id"`
+		} `json:"goal"`
+		Score    json.RawMessage `json:"score"`
+		WinnerID string          `json:"winner_id"`
+		LoserID  string          `json:"loser_id"`
+		MatchID  nulls.UUID      `json:"match_id"`
+		ID       string          `json:"id"`
+		User1Id  string          `json:"user1_id"`
+		User2Id  string          `json:"user2_id"`
+	}
+
+	dispatchError = json.Unmarshal(payload, &notifiedEvent)
+	if dispatchError != nil {
+		return dispatchError
+	}
+	dispatchError = json.Unmarshal(notifiedEvent.Data, &eventData)
+	if dispatchError != nil {
+		return dispatchError
+	}
+
+	switch notifiedEvent.Type {
+	case models.WebhookEventGoalRecorded:
+		h.Publish(EventScore, PairKey(eventData.Goal.ScorerId, eventData.Goal.OpponentId), eventData.Score)
+		if eventData.Goal.MatchID.Valid {
+			h.Publish(EventScore, MatchKey(eventData.Goal.MatchID.UUID.String()), eventData.Score)
+		}
+	case models.WebhookEventSetFinished:
+		h.Publish(EventSet, PairKey(eventData.WinnerID, eventData.LoserID), notifiedEvent.Data)
+		if eventData.MatchID.Valid {
+			h.Publish(EventSet, MatchKey(eventData.MatchID.UUID.String()), notifiedEvent.Data)
+		}
+	case models.WebhookEventMatchFinished:
+		h.Publish(EventMatch, PairKey(eventData.User1Id, eventData.User2Id), notifiedEvent.Data)
+		h.Publish(EventMatch, MatchKey(eventData.ID), notifiedEvent.Data)
+	}
+	return nil
+}
+
+// ServeHTTP streams score between users submitted in query string, as Server-Sent Events.
+//
+// Stream starts with current score, then gets each event of both users. A reconnecting stream (sending
+// "Last-Event-ID" header) gets events it missed instead, or current score if they cannot be replayed.
+//
+func (s Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		writeError(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	firstUserID, secondUserID := request.URL.Query().Get("user1"), request.URL.Query().Get("user2")
+	if firstUserID == "" || secondUserID == "" || firstUserID == secondUserID {
+		writeError(writer, "Bad request: you must provide two different users", http.StatusBadRequest)
+		return
+	}
+	flusher, streamable := writer.(http.Flusher)
+	if !streamable {
+		writeError(writer, "Failed to stream score: streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID, conversionError := strconv.ParseUint(request.Header.Get("Last-Event-ID"), 10, 64)
+	if conversionError != nil {
+		lastEventID = 0
+	}
+
+	pair := PairKey(firstUserID, secondUserID)
+	events, lastID, missed, replayed := s.Hub.Subscribe(pair, lastEventID)
+	defer s.Hub.Unsubscribe(pair, events)
+
+	if !replayed {
+		score, snapshotError := s.Snapshot(firstUserID, secondUserID)
+		if snapshotError != nil {
+			writeError(writer, fmt.Sprintf("Failed to retrieve score: %s", snapshotError), http.StatusInternalServerError)
+			return
+		}
+		scoreInJSON, marshalError := json.Marshal(score)
+		if marshalError != nil {
+			writeError(writer, fmt.Sprintf("Failed to JSONify score: %s", marshalError), http.StatusInternalServerError)
+			return
+		}
+		missed = []Event{{ID: lastID, Name: EventScore, Topic: pair, Data: scoreInJSON}}
+	}
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.Header().Set("Access-Control-Allow-Origin", "*")
+	writer.WriteHeader(http.StatusOK)
+	for _, event := range missed {
+		writeEvent(writer, event)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(writer, ": heartbeat\n\n")
+			flusher.Flush()
+		case event, open := <-events:
+			if !open {
+				// Stream was too slow to get its events: it reconnects and gets events it missed
+				return
+			}
+			writeEvent(writer, event)
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes an event in Server-Sent Events format.
+//
+func writeEvent(writer http.ResponseWriter, event Event) {
+	_, writeError := fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Name, event.Data)
+	if writeError != nil {
+		log.Println(writeError)
+	}
+}
+
+// writeError formats HTTP responses sent when an error occurs, as API does.
+//
+func writeError(writer http.ResponseWriter, errorMessage string, errorStatusCode int) {
+	errorInJSON, _ := json.Marshal(map[string]string{"error": errorMessage})
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(errorStatusCode)
+	writer.Write(errorInJSON)
+}
@@ -0,0 +1,146 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readEvent reads next event of a stream, skipping heartbeats.
+//
+func readEvent(reader *bufio.Reader) (eventLines []string) {
+	for {
+		line, readError := reader.ReadString('\n')
+		if readError != nil {
+			return eventLines
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" && len(eventLines) > 0 {
+			return eventLines
+		}
+		if line != "" && !strings.HasPrefix(line, ":") {
+			eventLines = append(eventLines, line)
+		}
+	}
+}
+
+// TestPairKey tests PairKey function identifying pairs whatever the order of users.
+//
+func TestPairKey(t *testing.T) {
+	assert.Equal(t, PairKey("user1", "user2"), PairKey("user2", "user1"), "Pair key should not depend on order of users")
+	assert.NotEqual(t, PairKey("user1", "user2"), PairKey("user1", "user3"), "Pair keys of different pairs should differ")
+}
+
+// TestHubReplay tests Subscribe function replaying events missed by reconnecting streams.
+//
+func TestHubReplay(t *testing.T) {
+	assertHandler := assert.New(t)
+	hub := NewHub()
+	pair := PairKey("user1", "user2")
+
+	_, _, _, replayed := hub.Subscribe(pair, 0)
+	assertHandler.False(replayed, "New stream: nothing should be replayed")
+
+	first := hub.Publish(EventScore, pair, json.RawMessage(`{"goals_in_balance":0}`))
+	hub.Publish(EventScore, PairKey("user3", "user4"), json.RawMessage(`{"goals_in_balance":2}`))
+	third := hub.Publish(EventSet, pair, json.RawMessage(`{"winner_id":"user1"}`))
+
+	_, lastID, missed, replayed := hub.Subscribe(pair, first.ID)
+	assertHandler.True(replayed, "Reconnecting stream: missed events should be replayed")
+	assertHandler.Equal([]Event{third}, missed, "Reconnecting stream: only missed events of pair should be replayed")
+	assertHandler.Equal(third.ID, lastID, "Reconnecting stream: ID of last event not returned as expected")
+
+	_, _, _, replayed = hub.Subscribe(pair, 42)
+	assertHandler.False(replayed, "Stream of previous hub: events should not be replayed")
+
+	for index := 0; index < historySize; index++ {
+		hub.Publish(EventScore, pair, json.RawMessage(`{}`))
+	}
+	_, _, _, replayed = hub.Subscribe(pair, first.ID)
+	assertHandler.False(replayed, "Too old stream: events out of history should not be replayed")
+}
+
+// TestHubPublish tests Publish function sending events to streams of pair, closing too slow streams.
+//
+func TestHubPublish(t *testing.T) {
+	assertHandler := assert.New(t)
+	hub := NewHub()
+	pair := PairKey("user1", "user2")
+
+	events, _, _, _ := hub.Subscribe(pair, 0)
+	otherEvents, _, _, _ := hub.Subscribe(PairKey("user1", "user3"), 0)
+	published := hub.Publish(EventScore, pair, json.RawMessage(`{}`))
+	assertHandler.Equal(published, <-events, "Stream of pair should get event")
+	assertHandler.Len(otherEvents, 0, "Stream of another pair should not get event")
+
+	for index := 0; index <= subscriberBufferSize; index++ {
+		hub.Publish(EventScore, pair, json.RawMessage(`{}`))
+	}
+	for range events {
+	}
+	hub.Unsubscribe(pair, events)
+	assertHandler.Empty(hub.subscribers[pair], "Too slow stream: stream should be closed")
+}
+
+// TestHubDispatch tests Dispatch function publishing database notifications to streams of their pair and match.
+//
+func TestHubDispatch(t *testing.T) {
+	assertHandler := assert.New(t)
+	hub := NewHub()
+	matchID := "2b4d6b1c-1f0a-4f3e-9a53-3c1b5d3a6f10"
+
+	assertHandler.Nil(hub.Dispatch([]byte(`{"event":"goal.recorded","data":{"goal":{"scorer_id":"user2","opponent_id":"user1","match_id":null},"score":{"user1":{"sets":0,"points":3}}}}`)))
+	assertHandler.Nil(hub.Dispatch([]byte(`{"event":"set.finished","data":{"winner_id":"user1","loser_id":"user3","match_id":"` + matchID + `"}}`)))
+	assertHandler.Nil(hub.Dispatch([]byte(`{"event":"match.finished","data":{"id":"` + matchID + `","user1_id":"user1","user2_id":"user3"}}`)))
+	assertHandler.NotNil(hub.Dispatch([]byte(`not JSON`)), "Invalid notification: Dispatch function should raise an error")
+
+	assertHandler.Len(hub.history, 5, "All events should be published")
+	assertHandler.Equal(Event{ID: hub.history[0].ID, Name: EventScore, Topic: PairKey("user1", "user2"), Data: json.RawMessage(`{"user1":{"sets":0,"points":3}}`)}, hub.history[0], "Goal recorded: normalized score should be published")
+	assertHandler.Equal([]string{EventSet, EventSet}, []string{hub.history[1].Name, hub.history[2].Name}, "Set finished: set event should be published")
+	assertHandler.Equal(MatchKey(matchID), hub.history[2].Topic, "Set of a match: event should be published to streams of match")
+	assertHandler.Equal([]string{PairKey("user1", "user3"), MatchKey(matchID)}, []string{hub.history[3].Topic, hub.history[4].Topic}, "Match finished: event should be published to users and to match")
+}
+
+// TestHandler tests ServeHTTP function streaming current score, then events of pair.
+//
+func TestHandler(t *testing.T) {
+	assertHandler := assert.New(t)
+	hub := NewHub()
+	handler := Handler{Hub: hub, Snapshot: func(firstUserID string, secondUserID string) (score interface{}, snapshotError error) {
+		if firstUserID == "unknown" {
+			return nil, errors.New("database is down")
+		}
+		return map[string]int{"goals_in_balance": 0}, nil
+	}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	response, _ := http.Get(server.URL + "?user1=user1")
+	assertHandler.Equal(http.StatusBadRequest, response.StatusCode, "Missing user: request should be refused")
+	response, _ = http.Get(server.URL + "?user1=unknown&user2=user2")
+	assertHandler.Equal(http.StatusInternalServerError, response.StatusCode, "Failing snapshot: request should fail")
+
+	response, requestError := http.Get(server.URL + "?user1=user1&user2=user2")
+	assertHandler.Nil(requestError, "Stream should be opened")
+	defer response.Body.Close()
+	assertHandler.Equal("text/event-stream", response.Header.Get("Content-Type"), "Stream should be sent as Server-Sent Events")
+	reader := bufio.NewReader(response.Body)
+	snapshot := readEvent(reader)
+	assertHandler.Equal([]string{"event: score", `data: {"goals_in_balance":0}`}, snapshot[1:], "Stream should start with current score")
+
+	published := hub.Publish(EventSet, PairKey("user2", "user1"), json.RawMessage(`{"winner_id":"user2"}`))
+	assertHandler.Equal([]string{fmt.Sprintf("id: %d", published.ID), "event: set", `data: {"winner_id":"user2"}`}, readEvent(reader), "Stream should get events of pair")
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"?user1=user2&user2=user1", nil)
+	request.Header.Set("Last-Event-ID", strings.TrimPrefix(snapshot[0], "id: "))
+	reconnection, requestError := http.DefaultClient.Do(request)
+	assertHandler.Nil(requestError, "Stream should be reopened")
+	defer reconnection.Body.Close()
+	assertHandler.Equal([]string{fmt.Sprintf("id: %d", published.ID), "event: set", `data: {"winner_id":"user2"}`}, readEvent(bufio.NewReader(reconnection.Body)), "Reconnecting stream should get missed events instead of current score")
+}
@@ -0,0 +1,94 @@
+package tournaments
+
+import (
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+)
+
+// BracketMatch represents a match of bracket, with sets won by each user so far.
+//
+type BracketMatch struct {
+	models.Match
+	User1Sets int `json:"user1_sets"`
+	User2Sets int `json:"user2_sets"`
+}
+
+// BracketRound represents all matches of one round of a bracket (see models.MatchBrackets), ordered by position.
+//
+type BracketRound struct {
+	Bracket string         `json:"bracket"`
+	Round   int            `json:"round"`
+	Matches []BracketMatch `json:"matches"`
+}
+
+// Bracket represents a tournament with all its matches, grouped by round.
+//
+type Bracket struct {
+	Tournament models.Tournament `json:"tournament"`
+	Rounds     []BracketRound    `json:"rounds"`
+}
+
+// BuildBracket groups matches of tournament by bracket and round, adding sets of their scores.
+//
+// Matches must be ordered by bracket, round then position.
+//
+func BuildBracket(tournament models.Tournament, matches []models.Match, matchScores []models.Score) (bracket Bracket) {
+	var scoresByMatch = scoresByMatchID(matchScores)
+
+	bracket = Bracket{Tournament: tournament, Rounds: []BracketRound{}}
+	for _, match := range matches {
+		bracketMatch := BracketMatch{Match: match}
+		if matchScore, scoreExists := scoresByMatch[match.ID.String()]; scoreExists {
+			bracketMatch.User1Sets = matchScore.UserSets(match.User1Id)
+			bracketMatch.User2Sets = matchScore.UserSets(match.User2Id)
+		}
+
+		if lastRound := len(bracket.Rounds) - 1; lastRound < 0 || bracket.Rounds[lastRound].Bracket != match.Bracket || bracket.Rounds[lastRound].Round != match.Round {
+			bracket.Rounds = append(bracket.Rounds, BracketRound{Bracket: match.Bracket, Round: match.Round, Matches: []BracketMatch{}})
+		}
+		lastRound := &bracket.Rounds[len(bracket.Rounds)-1]
+		lastRound.Matches = append(lastRound.Matches, bracketMatch)
+	}
+
+	return bracket
+}
+
+// FetchBracket retrieves all matches of tournament and their scores, then builds its bracket.
+//
+func FetchBracket(tx *pop.Connection, tournament models.Tournament) (bracket Bracket, fetchError error) {
+	matches, matchScores, fetchError := fetchMatches(tx, tournament)
+	if fetchError != nil {
+		return bracket, fetchError
+	}
+
+	return BuildBracket(tournament, matches, matchScores), nil
+}
+
+// fetchMatches retrieves all matches of tournament (ordered by bracket, round then position) and their scores.
+//
+func fetchMatches(tx *pop.Connection, tournament models.Tournament) (matches []models.Match, matchScores []models.Score, fetchError error) {
+	fetchError = tx.Where("tournament_id = ?", tournament.ID).Order("round, position").All(&matches)
+	if fetchError != nil {
+		return nil, nil, fetchError
+	}
+	sortMatches(matches)
+
+	fetchError = tx.Where("match_id IN (SELECT id FROM matches WHERE tournament_id = ?)", tournament.ID).All(&matchScores)
+	if fetchError != nil {
+		return nil, nil, fetchError
+	}
+
+	return matches, matchScores, nil
+}
+
+// scoresByMatchID indexes scores by id of their match (scores without match being ignored).
+//
+func scoresByMatchID(matchScores []models.Score) (scoresByMatch map[string]models.Score) {
+	scoresByMatch = make(map[string]models.Score)
+	for _, matchScore := range matchScores {
+		if matchScore.MatchID.Valid {
+			scoresByMatch[matchScore.MatchID.UUID.String()] = matchScore
+		}
+	}
+	return scoresByMatch
+}
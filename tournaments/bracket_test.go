@@ -0,0 +1,36 @@
+package tournaments
+
+import (
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+)
+
+// TestBuildBracket tests BuildBracket function grouping matches by round with sets of their scores.
+//
+func TestBuildBracket(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournament := models.Tournament{Name: "Cup", SetsToWin: 2}
+
+	matches, _ := SingleEliminationMatches(tournament, []string{"user1", "user2", "user3", "user4"})
+	firstSemiFinal := findMatch(matches, 1, 1)
+	// Scorer of first goal of match is stored as first user of score
+	matchScores := []models.Score{
+		{User1Id: "user4", User2Id: "user1", User1Sets: 1, User2Sets: 0, MatchID: nulls.NewUUID(firstSemiFinal.ID)},
+	}
+	otherMatchID, _ := uuid.NewV4()
+	matchScores = append(matchScores, models.Score{User1Id: "user2", User2Id: "user3", User1Sets: 2, MatchID: nulls.NewUUID(otherMatchID)})
+
+	bracket := BuildBracket(tournament, matches, matchScores)
+	assertHandler.Equal("Cup", bracket.Tournament.Name, "Bracket of 4: tournament not returned as expected")
+	assertHandler.Len(bracket.Rounds, 2, "Bracket of 4: bracket should have 2 rounds")
+	assertHandler.Equal(1, bracket.Rounds[0].Round, "Bracket of 4: first round not numbered as expected")
+	assertHandler.Len(bracket.Rounds[0].Matches, 2, "Bracket of 4: first round should have 2 matches")
+	assertHandler.Len(bracket.Rounds[1].Matches, 1, "Bracket of 4: second round should have 1 match")
+
+	assertHandler.Equal(0, bracket.Rounds[0].Matches[0].User1Sets, "Bracket of 4: sets of first user not returned as expected")
+	assertHandler.Equal(1, bracket.Rounds[0].Matches[0].User2Sets, "Bracket of 4: sets of second user not returned as expected")
+	assertHandler.Equal(0, bracket.Rounds[0].Matches[1].User1Sets, "Bracket of 4: sets of match from another tournament should not be returned")
+}
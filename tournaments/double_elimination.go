@@ -0,0 +1,110 @@
+package tournaments
+
+import (
+	"github.com/gobuffalo/nulls"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+)
+
+// DoubleEliminationMatches generates all matches of a double-elimination tournament for submitted seeded users.
+//
+// Main bracket is seeded like a single-elimination bracket (see SingleEliminationMatches), and its losers drop into
+// losers bracket: users are only eliminated after losing two matches. Losers bracket alternates rounds between
+// its own winners, and rounds where they meet losers of next main bracket round (in reverse order, to delay rematches).
+// Winners of both brackets finally meet in grand final, played as last round of main bracket, without bracket reset.
+//
+func DoubleEliminationMatches(tournament models.Tournament, seededUsers []string) (matches []models.Match, generationError error) {
+	matches, generationError = eliminationBracket(tournament, seededUsers)
+	if generationError != nil {
+		return nil, generationError
+	}
+	mainRoundsCount := matches[len(matches)-1].Round
+
+	losersMatches, generationError := losersBracket(tournament, mainRoundsCount)
+	if generationError != nil {
+		return nil, generationError
+	}
+	matches = append(matches, losersMatches...)
+
+	grandFinal, generationError := newTournamentMatch(tournament, mainRoundsCount+1, 1)
+	if generationError != nil {
+		return nil, generationError
+	}
+	matches = append(matches, grandFinal)
+
+	linkLosers(matches, mainRoundsCount)
+
+	resolveByes(matches)
+	sortMatches(matches)
+
+	return matches, nil
+}
+
+// losersBracket generates matches of losers bracket of a double-elimination tournament whose main bracket
+// (without grand final) has submitted number of rounds.
+//
+// First round gathers losers of first main round two by two, then each main round after the first one
+// feeds one losers round, followed by a round halving remaining users (except for losers final).
+//
+func losersBracket(tournament models.Tournament, mainRoundsCount int) (matches []models.Match, generationError error) {
+	roundMatches := 1 << uint(mainRoundsCount) / 4
+
+	for round := 1; round <= 2*(mainRoundsCount-1); round++ {
+		if round > 1 && round%2 != 0 {
+			roundMatches /= 2
+		}
+		for position := 1; position <= roundMatches; position++ {
+			newMatch, generationError := newTournamentMatch(tournament, round, position)
+			if generationError != nil {
+				return nil, generationError
+			}
+			newMatch.Bracket = models.MatchBracketLosers
+			matches = append(matches, newMatch)
+		}
+	}
+
+	return matches, nil
+}
+
+// linkLosers links matches of main bracket to losers bracket matches that their losers will play,
+// and links losers bracket matches together up to grand final.
+//
+func linkLosers(matches []models.Match, mainRoundsCount int) {
+	grandFinal := findMatch(matches, mainRoundsCount+1, 1)
+	losersRoundsCount := 2 * (mainRoundsCount - 1)
+
+	for index := range matches {
+		match := &matches[index]
+		switch {
+		case match.Bracket == models.MatchBracketMain && match.Round == mainRoundsCount:
+			// Main bracket final sends its winner to grand final, and its loser to losers final
+			match.NextMatchID, match.NextMatchSlot = nulls.NewUUID(grandFinal.ID), 1
+			if losersRoundsCount == 0 {
+				linkLoser(match, grandFinal, 2)
+			} else {
+				linkLoser(match, findBracketMatch(matches, models.MatchBracketLosers, losersRoundsCount, 1), 2)
+			}
+		case match.Bracket == models.MatchBracketMain && match.Round == 1:
+			linkLoser(match, findBracketMatch(matches, models.MatchBracketLosers, 1, (match.Position+1)/2), 2-match.Position%2)
+		case match.Bracket == models.MatchBracketMain && match.Round <= mainRoundsCount:
+			losersRound := 2 * (match.Round - 1)
+			roundMatches := 1 << uint(mainRoundsCount-match.Round)
+			linkLoser(match, findBracketMatch(matches, models.MatchBracketLosers, losersRound, roundMatches+1-match.Position), 2)
+		case match.Bracket == models.MatchBracketLosers && match.Round == losersRoundsCount:
+			match.NextMatchID, match.NextMatchSlot = nulls.NewUUID(grandFinal.ID), 2
+		case match.Bracket == models.MatchBracketLosers && match.Round%2 != 0:
+			// Winners of odd losers rounds meet losers of main bracket in next round
+			nextMatch := findBracketMatch(matches, models.MatchBracketLosers, match.Round+1, match.Position)
+			match.NextMatchID, match.NextMatchSlot = nulls.NewUUID(nextMatch.ID), 1
+		case match.Bracket == models.MatchBracketLosers:
+			nextMatch := findBracketMatch(matches, models.MatchBracketLosers, match.Round+1, (match.Position+1)/2)
+			match.NextMatchID, match.NextMatchSlot = nulls.NewUUID(nextMatch.ID), 2-match.Position%2
+		}
+	}
+}
+
+// linkLoser links match to match that its loser will play (in submitted slot).
+//
+func linkLoser(match *models.Match, loserMatch *models.Match, slot int) {
+	match.LoserMatchID = nulls.NewUUID(loserMatch.ID)
+	match.LoserMatchSlot = slot
+}
@@ -0,0 +1,73 @@
+package tournaments
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+)
+
+// TestDoubleEliminationMatchesFullBracket tests DoubleEliminationMatches function for a bracket without byes.
+//
+func TestDoubleEliminationMatchesFullBracket(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournament := models.Tournament{SetsToWin: 1}
+
+	matches, generationError := DoubleEliminationMatches(tournament, []string{"user1", "user2", "user3", "user4"})
+	assertHandler.Nil(generationError, "Bracket of 4: DoubleEliminationMatches function should not raise an error")
+	assertHandler.Len(matches, 6, "Bracket of 4: bracket should have 3 main matches, 2 losers matches and a grand final")
+
+	firstSemiFinal := findMatch(matches, 1, 1)
+	secondSemiFinal := findMatch(matches, 1, 2)
+	final := findMatch(matches, 2, 1)
+	grandFinal := findMatch(matches, 3, 1)
+	losersFirstRound := findBracketMatch(matches, models.MatchBracketLosers, 1, 1)
+	losersFinal := findBracketMatch(matches, models.MatchBracketLosers, 2, 1)
+
+	assertHandler.Equal(losersFirstRound.ID, firstSemiFinal.LoserMatchID.UUID, "Bracket of 4: first semi-final loser should drop into losers bracket")
+	assertHandler.Equal(1, firstSemiFinal.LoserMatchSlot, "Bracket of 4: first semi-final loser should be first user of losers match")
+	assertHandler.Equal(losersFirstRound.ID, secondSemiFinal.LoserMatchID.UUID, "Bracket of 4: second semi-final loser should drop into losers bracket")
+	assertHandler.Equal(2, secondSemiFinal.LoserMatchSlot, "Bracket of 4: second semi-final loser should be second user of losers match")
+
+	assertHandler.Equal(losersFinal.ID, losersFirstRound.NextMatchID.UUID, "Bracket of 4: losers first round winner should play losers final")
+	assertHandler.Equal(1, losersFirstRound.NextMatchSlot, "Bracket of 4: losers first round winner should be first user of losers final")
+	assertHandler.Equal(losersFinal.ID, final.LoserMatchID.UUID, "Bracket of 4: main final loser should play losers final")
+	assertHandler.Equal(2, final.LoserMatchSlot, "Bracket of 4: main final loser should be second user of losers final")
+
+	assertHandler.Equal(grandFinal.ID, final.NextMatchID.UUID, "Bracket of 4: main final winner should play grand final")
+	assertHandler.Equal(1, final.NextMatchSlot, "Bracket of 4: main final winner should be first user of grand final")
+	assertHandler.Equal(grandFinal.ID, losersFinal.NextMatchID.UUID, "Bracket of 4: losers final winner should play grand final")
+	assertHandler.Equal(2, losersFinal.NextMatchSlot, "Bracket of 4: losers final winner should be second user of grand final")
+	assertHandler.False(grandFinal.NextMatchID.Valid || grandFinal.LoserMatchID.Valid, "Bracket of 4: grand final should not have next match")
+
+	assertHandler.Equal(models.MatchBracketMain, matches[3].Bracket, "Bracket of 4: grand final should be ordered with main bracket")
+	assertHandler.Equal(models.MatchBracketLosers, matches[5].Bracket, "Bracket of 4: losers bracket should be ordered after main bracket")
+}
+
+// TestDoubleEliminationMatchesWithByes tests DoubleEliminationMatches function for a bracket with byes.
+//
+func TestDoubleEliminationMatchesWithByes(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournament := models.Tournament{SetsToWin: 1}
+
+	matches, _ := DoubleEliminationMatches(tournament, []string{"user1", "user2", "user3", "user4", "user5"})
+	assertHandler.Len(matches, 14, "Bracket of 5: bracket should have 7 main matches, 6 losers matches and a grand final")
+
+	// Seeds 4 and 5 play the only first round match: its loser has no opponent in losers first round
+	playedMatch := findMatch(matches, 1, 2)
+	losersFirstRound := findBracketMatch(matches, models.MatchBracketLosers, 1, 1)
+	assertHandler.Equal(models.MatchStatusFinished, losersFirstRound.Status, "Bracket of 5: losers match without opponent should be finished")
+	assertHandler.Equal("", losersFirstRound.WinnerId, "Bracket of 5: losers match without opponent should not have winner yet")
+	assertHandler.Equal(findBracketMatch(matches, models.MatchBracketLosers, 2, 1).ID, playedMatch.LoserMatchID.UUID, "Bracket of 5: first round loser should skip losers match without opponent")
+	assertHandler.Equal(1, playedMatch.LoserMatchSlot, "Bracket of 5: first round loser should take slot of skipped match winner")
+
+	// Other losers first round match only gathers byes, so its second round match only gets a main bracket loser
+	emptyMatch := findBracketMatch(matches, models.MatchBracketLosers, 1, 2)
+	assertHandler.Equal(models.MatchStatusFinished, emptyMatch.Status, "Bracket of 5: losers match without users should be finished")
+	secondRoundMatch := findMatch(matches, 2, 1)
+	assertHandler.Equal(findBracketMatch(matches, models.MatchBracketLosers, 3, 1).ID, secondRoundMatch.LoserMatchID.UUID, "Bracket of 5: second round loser should skip losers match without opponent")
+	assertHandler.Equal(2, secondRoundMatch.LoserMatchSlot, "Bracket of 5: second round loser should take slot of skipped match winner")
+
+	otherSecondRoundMatch := findMatch(matches, 2, 2)
+	assertHandler.Equal(findBracketMatch(matches, models.MatchBracketLosers, 2, 1).ID, otherSecondRoundMatch.LoserMatchID.UUID, "Bracket of 5: second round losers should drop in reverse order")
+	assertHandler.Equal(2, otherSecondRoundMatch.LoserMatchSlot, "Bracket of 5: second round loser should be second user of losers match")
+}
@@ -0,0 +1,206 @@
+package tournaments
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"sort"
+)
+
+// GroupStandings represents table of one group of group stage, ordered by rank.
+//
+type GroupStandings struct {
+	Group     int        `json:"group"`
+	Standings []Standing `json:"standings"`
+}
+
+// DrawGroups splits seeded users into groups of at most submitted size.
+//
+// Seeds are distributed in snake order (best seeds heading different groups, next seeds going back from last group),
+// so that groups are balanced.
+//
+func DrawGroups(seededUsers []string, groupSize int) (groups [][]string) {
+	groupsCount := (len(seededUsers) + groupSize - 1) / groupSize
+
+	groups = make([][]string, groupsCount)
+	for index, user := range seededUsers {
+		group := index % groupsCount
+		if (index/groupsCount)%2 != 0 {
+			group = groupsCount - 1 - group
+		}
+		groups[group] = append(groups[group], user)
+	}
+	return groups
+}
+
+// GroupStageMatches generates matches of group stage, where users of each group play each other once (see RoundRobinMatches).
+//
+// All groups play their rounds at the same time: positions of matches are numbered across groups in each round.
+//
+func GroupStageMatches(tournament models.Tournament, groups [][]string) (matches []models.Match, generationError error) {
+	tournament.HomeAndAway = false
+
+	for groupIndex, groupUsers := range groups {
+		groupMatches, generationError := RoundRobinMatches(tournament, groupUsers)
+		if generationError != nil {
+			return nil, generationError
+		}
+		for index := range groupMatches {
+			groupMatches[index].Bracket = models.MatchBracketGroups
+			groupMatches[index].GroupNumber = groupIndex + 1
+		}
+		matches = append(matches, groupMatches...)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Round < matches[j].Round
+	})
+	position := 0
+	for index := range matches {
+		if index > 0 && matches[index].Round != matches[index-1].Round {
+			position = 0
+		}
+		position++
+		matches[index].Position = position
+	}
+
+	return matches, nil
+}
+
+// ComputeGroupStandings computes table of each group from participants groups and group stage matches
+// (see ComputeStandings), marking first participants of each table as qualified for knockout.
+//
+func ComputeGroupStandings(participants []models.TournamentParticipant, matches []models.Match, matchScores []models.Score, qualifiersPerGroup int) (groupStandings []GroupStandings) {
+	var usersByGroup = make(map[int][]string)
+	var matchesByGroup = make(map[int][]models.Match)
+	var groupNumbers []int
+
+	for _, participant := range participants {
+		if _, groupExists := usersByGroup[participant.GroupNumber]; !groupExists {
+			groupNumbers = append(groupNumbers, participant.GroupNumber)
+		}
+		usersByGroup[participant.GroupNumber] = append(usersByGroup[participant.GroupNumber], participant.UserId)
+	}
+	for _, match := range matches {
+		if match.Bracket == models.MatchBracketGroups {
+			matchesByGroup[match.GroupNumber] = append(matchesByGroup[match.GroupNumber], match)
+		}
+	}
+	sort.Ints(groupNumbers)
+
+	groupStandings = []GroupStandings{}
+	for _, groupNumber := range groupNumbers {
+		standings := ComputeStandings(usersByGroup[groupNumber], matchesByGroup[groupNumber], matchScores)
+		for index := 0; index < qualifiersPerGroup && index < len(standings); index++ {
+			standings[index].Qualified = true
+		}
+		groupStandings = append(groupStandings, GroupStandings{Group: groupNumber, Standings: standings})
+	}
+
+	return groupStandings
+}
+
+// KnockoutSeeds seeds users qualified from group stage for knockout, from best to worst seed.
+//
+// Group winners come first, then runners-up, and so on: users having the same position in their group
+// are ranked against each other by standings points, then set difference, then point difference.
+//
+func KnockoutSeeds(groupStandings []GroupStandings) (seededUsers []string) {
+	for position := 0; ; position++ {
+		var positionStandings []Standing
+		for _, group := range groupStandings {
+			if position < len(group.Standings) && group.Standings[position].Qualified {
+				positionStandings = append(positionStandings, group.Standings[position])
+			}
+		}
+		if len(positionStandings) == 0 {
+			return seededUsers
+		}
+
+		sort.SliceStable(positionStandings, func(i, j int) bool {
+			return ranksBefore(positionStandings[i], positionStandings[j])
+		})
+		for _, standing := range positionStandings {
+			seededUsers = append(seededUsers, standing.UserId)
+		}
+	}
+}
+
+// FetchGroupStandings retrieves participants, matches and scores of tournament, then computes its group tables.
+//
+func FetchGroupStandings(tx *pop.Connection, tournament models.Tournament) (groupStandings []GroupStandings, fetchError error) {
+	var participants []models.TournamentParticipant
+
+	fetchError = tx.Where("tournament_id = ?", tournament.ID).All(&participants)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+
+	matches, matchScores, fetchError := fetchMatches(tx, tournament)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+
+	return ComputeGroupStandings(participants, matches, matchScores, tournament.QualifiersPerGroup), nil
+}
+
+// checkGroups checks that drawn groups allow to qualify submitted number of participants per group,
+// with at least 2 qualifiers for knockout.
+//
+func checkGroups(groups [][]string, qualifiersPerGroup int) (checkError error) {
+	if qualifiersPerGroup < 1 || len(groups)*qualifiersPerGroup < 2 {
+		return errors.New("knockout needs at least 2 qualifiers")
+	}
+	for _, groupUsers := range groups {
+		if len(groupUsers) < qualifiersPerGroup {
+			return fmt.Errorf("each group needs at least %d participants", qualifiersPerGroup)
+		}
+	}
+	return nil
+}
+
+// assignGroups stores group of each participant, according to drawn groups.
+//
+func assignGroups(tx *pop.Connection, participants []models.TournamentParticipant, groups [][]string) (assignError error) {
+	var groupsByUser = make(map[string]int)
+
+	for groupIndex, groupUsers := range groups {
+		for _, user := range groupUsers {
+			groupsByUser[user] = groupIndex + 1
+		}
+	}
+
+	for index := range participants {
+		participants[index].GroupNumber = groupsByUser[participants[index].UserId]
+		assignError = validateAndSave(tx, &participants[index])
+		if assignError != nil {
+			return assignError
+		}
+	}
+	return nil
+}
+
+// startKnockout generates knockout of a group-stage-plus-knockout tournament, once its group stage is over.
+//
+// Qualified users are seeded from their group positions (see KnockoutSeeds) in a single-elimination bracket.
+//
+func startKnockout(tx *pop.Connection, tournament models.Tournament) (startError error) {
+	groupStandings, startError := FetchGroupStandings(tx, tournament)
+	if startError != nil {
+		return startError
+	}
+
+	matches, startError := SingleEliminationMatches(tournament, KnockoutSeeds(groupStandings))
+	if startError != nil {
+		return startError
+	}
+
+	for index := range matches {
+		startError = validateAndCreate(tx, &matches[index])
+		if startError != nil {
+			return startError
+		}
+	}
+	return nil
+}
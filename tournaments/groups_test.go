@@ -0,0 +1,87 @@
+package tournaments
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+)
+
+// TestDrawGroups tests DrawGroups function with snake distribution of seeds.
+//
+func TestDrawGroups(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	groups := DrawGroups([]string{"user1", "user2", "user3", "user4", "user5", "user6", "user7", "user8"}, 4)
+	assertHandler.Equal([][]string{{"user1", "user4", "user5", "user8"}, {"user2", "user3", "user6", "user7"}}, groups, "8 users: groups not drawn as expected")
+
+	groups = DrawGroups([]string{"user1", "user2", "user3", "user4", "user5"}, 4)
+	assertHandler.Equal([][]string{{"user1", "user4", "user5"}, {"user2", "user3"}}, groups, "5 users: groups not drawn as expected")
+
+	assertHandler.NotNil(checkGroups(groups, 3), "5 users: groups of 2 cannot qualify 3 users")
+	assertHandler.NotNil(checkGroups([][]string{{"user1", "user2"}}, 1), "1 group: knockout needs 2 qualifiers")
+	assertHandler.Nil(checkGroups(groups, 2), "5 users: groups should qualify 2 users each")
+}
+
+// TestGroupStageMatches tests GroupStageMatches function numbering matches across groups.
+//
+func TestGroupStageMatches(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournament := models.Tournament{SetsToWin: 1, HomeAndAway: true}
+
+	matches, generationError := GroupStageMatches(tournament, [][]string{{"user1", "user4", "user5", "user8"}, {"user2", "user3", "user6", "user7"}})
+	assertHandler.Nil(generationError, "2 groups of 4: GroupStageMatches function should not raise an error")
+	assertHandler.Len(matches, 12, "2 groups of 4: each group should play 6 matches once")
+
+	for index, match := range matches {
+		assertHandler.Equal(models.MatchBracketGroups, match.Bracket, "2 groups of 4: matches should belong to groups bracket")
+		assertHandler.Equal(index/4+1, match.Round, "2 groups of 4: each round should have 4 matches")
+		assertHandler.Equal(index%4+1, match.Position, "2 groups of 4: positions should be numbered across groups")
+		assertHandler.Equal(index%4/2+1, match.GroupNumber, "2 groups of 4: matches should be ordered by group in each round")
+	}
+}
+
+// TestKnockoutSeeds tests ComputeGroupStandings and KnockoutSeeds functions seeding knockout from group positions.
+//
+func TestKnockoutSeeds(t *testing.T) {
+	assertHandler := assert.New(t)
+	var matches []models.Match
+	var matchScores []models.Score
+
+	participants := []models.TournamentParticipant{
+		{UserId: "user1", GroupNumber: 1}, {UserId: "user4", GroupNumber: 1}, {UserId: "user5", GroupNumber: 1},
+		{UserId: "user2", GroupNumber: 2}, {UserId: "user3", GroupNumber: 2}, {UserId: "user6", GroupNumber: 2},
+	}
+	for _, result := range []struct {
+		group                                            int
+		winner, loser                                    string
+		winnerSets, loserSets, winnerPoints, loserPoints int
+	}{
+		{1, "user1", "user4", 1, 0, 10, 2},
+		{1, "user4", "user5", 1, 0, 10, 8},
+		{1, "user5", "user1", 1, 0, 10, 9},
+		{2, "user2", "user3", 1, 0, 10, 5},
+		{2, "user2", "user6", 1, 0, 10, 5},
+		{2, "user6", "user3", 1, 0, 10, 9},
+	} {
+		match, matchScore := finishedMatch(result.winner, result.loser, result.winnerSets, result.loserSets, result.winnerPoints, result.loserPoints)
+		match.Bracket, match.GroupNumber = models.MatchBracketGroups, result.group
+		matches = append(matches, match)
+		matchScores = append(matchScores, matchScore)
+	}
+
+	groupStandings := ComputeGroupStandings(participants, matches, matchScores, 2)
+	assertHandler.Len(groupStandings, 2, "2 groups of 3: both groups should have a table")
+	assertHandler.Equal(1, groupStandings[0].Group, "2 groups of 3: tables should be ordered by group")
+
+	// All users of group 1 have 3 points and no set difference: user1 has best point difference (+7), user4 worst (-6)
+	group1Users := []string{}
+	for _, standing := range groupStandings[0].Standings {
+		group1Users = append(group1Users, standing.UserId)
+	}
+	assertHandler.Equal([]string{"user1", "user5", "user4"}, group1Users, "Group 1: users not ranked as expected")
+	assertHandler.True(groupStandings[0].Standings[1].Qualified, "Group 1: runner-up should be qualified")
+	assertHandler.False(groupStandings[0].Standings[2].Qualified, "Group 1: third should not be qualified")
+
+	// Group 2 winner has more points than group 1 winner, both runners-up have 3 points but user5 has better point difference (-1 vs -4)
+	assertHandler.Equal([]string{"user2", "user1", "user5", "user6"}, KnockoutSeeds(groupStandings), "Knockout: qualified users not seeded as expected")
+}
@@ -0,0 +1,62 @@
+package tournaments
+
+import (
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+)
+
+// RoundRobinMatches generates all matches of a round-robin tournament (league), where every user plays every other one.
+//
+// Fixtures are generated with circle method: first user stays in place while others rotate, so that each user
+// plays once per round. With an odd number of users, one user is exempt in each round.
+// When tournament is played home and away, second leg replays first leg with users swapped.
+//
+func RoundRobinMatches(tournament models.Tournament, users []string) (matches []models.Match, generationError error) {
+	var rotatingUsers = append([]string{}, users...)
+
+	if len(rotatingUsers)%2 != 0 {
+		rotatingUsers = append(rotatingUsers, "")
+	}
+	roundsCount := len(rotatingUsers) - 1
+
+	for round := 1; round <= roundsCount; round++ {
+		position := 0
+		for index := 0; index < len(rotatingUsers)/2; index++ {
+			homeUser, awayUser := rotatingUsers[index], rotatingUsers[len(rotatingUsers)-1-index]
+			if homeUser == "" || awayUser == "" {
+				continue
+			}
+			// First user alternates home and away, as it never rotates
+			if index == 0 && round%2 == 0 {
+				homeUser, awayUser = awayUser, homeUser
+			}
+
+			position++
+			for leg := 0; leg < 2; leg++ {
+				if leg == 1 && !tournament.HomeAndAway {
+					break
+				}
+				newMatch, generationError := newTournamentMatch(tournament, round+leg*roundsCount, position)
+				if generationError != nil {
+					return nil, generationError
+				}
+				if leg == 0 {
+					newMatch.PlaceUser(1, homeUser)
+					newMatch.PlaceUser(2, awayUser)
+				} else {
+					newMatch.PlaceUser(1, awayUser)
+					newMatch.PlaceUser(2, homeUser)
+				}
+				matches = append(matches, newMatch)
+			}
+		}
+
+		// Rotate all users but first one
+		lastUser := rotatingUsers[len(rotatingUsers)-1]
+		copy(rotatingUsers[2:], rotatingUsers[1:len(rotatingUsers)-1])
+		rotatingUsers[1] = lastUser
+	}
+
+	sortMatches(matches)
+
+	return matches, nil
+}
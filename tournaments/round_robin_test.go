@@ -0,0 +1,71 @@
+package tournaments
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+)
+
+// countPairings counts matches played between each pair of users (first user of pair being alphabetically first).
+//
+func countPairings(matches []models.Match) (pairings map[[2]string]int) {
+	pairings = make(map[[2]string]int)
+	for _, match := range matches {
+		pair := [2]string{match.User1Id, match.User2Id}
+		if pair[0] > pair[1] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		pairings[pair]++
+	}
+	return pairings
+}
+
+// TestRoundRobinMatchesEvenUsers tests RoundRobinMatches function for an even number of users.
+//
+func TestRoundRobinMatchesEvenUsers(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournament := models.Tournament{SetsToWin: 2}
+
+	matches, generationError := RoundRobinMatches(tournament, []string{"user1", "user2", "user3", "user4"})
+	assertHandler.Nil(generationError, "League of 4: RoundRobinMatches function should not raise an error")
+	assertHandler.Len(matches, 6, "League of 4: league should have 6 matches")
+
+	pairings := countPairings(matches)
+	assertHandler.Len(pairings, 6, "League of 4: every user should play every other one")
+	for pair, count := range pairings {
+		assertHandler.Equal(1, count, "League of 4: users %v should play once", pair)
+	}
+
+	for round := 1; round <= 3; round++ {
+		roundUsers := make(map[string]bool)
+		for _, match := range matches {
+			if match.Round == round {
+				roundUsers[match.User1Id] = true
+				roundUsers[match.User2Id] = true
+			}
+		}
+		assertHandler.Len(roundUsers, 4, "League of 4: every user should play once in round %d", round)
+	}
+
+	assertHandler.Equal(models.MatchStatusReady, matches[0].Status, "League of 4: matches should be ready")
+	assertHandler.Equal(1, matches[0].Round, "League of 4: matches should be sorted by round")
+}
+
+// TestRoundRobinMatchesOddUsersHomeAndAway tests RoundRobinMatches function for an odd number of users playing home and away.
+//
+func TestRoundRobinMatchesOddUsersHomeAndAway(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournament := models.Tournament{SetsToWin: 1, HomeAndAway: true}
+
+	matches, _ := RoundRobinMatches(tournament, []string{"user1", "user2", "user3"})
+	assertHandler.Len(matches, 6, "League of 3 home and away: league should have 6 matches")
+	assertHandler.Equal(6, matches[len(matches)-1].Round, "League of 3 home and away: league should have 6 rounds")
+
+	homeMatches := make(map[[2]string]int)
+	for _, match := range matches {
+		assertHandler.NotEqual("", match.User1Id, "League of 3 home and away: exempt user should not get a match")
+		assertHandler.NotEqual("", match.User2Id, "League of 3 home and away: exempt user should not get a match")
+		homeMatches[[2]string{match.User1Id, match.User2Id}]++
+	}
+	assertHandler.Len(homeMatches, 6, "League of 3 home and away: every user should play every other one at home")
+}
@@ -0,0 +1,95 @@
+package tournaments
+
+import (
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"sort"
+)
+
+// userBalance represents difference between sets won and lost by one user, all scores together.
+//
+type userBalance struct {
+	UserID  string `db:"user_id"`
+	Balance int    `db:"balance"`
+}
+
+// BracketSize returns smallest power of 2 able to hold submitted number of participants.
+//
+func BracketSize(participantsCount int) (bracketSize int) {
+	bracketSize = 1
+	for bracketSize < participantsCount {
+		bracketSize *= 2
+	}
+	return bracketSize
+}
+
+// SeedOrder returns seeds (starting from 1) placed in each slot of a bracket of submitted size.
+//
+// Seeds are placed so that best seeds meet as late as possible: seed 1 meets seed 2 in final at best,
+// and each first round match opposes seeds whose sum is bracket size + 1.
+//
+func SeedOrder(bracketSize int) (seeds []int) {
+	seeds = []int{1}
+	for len(seeds) < bracketSize {
+		roundSize := len(seeds) * 2
+		nextSeeds := make([]int, 0, roundSize)
+		for _, seed := range seeds {
+			nextSeeds = append(nextSeeds, seed, roundSize+1-seed)
+		}
+		seeds = nextSeeds
+	}
+	return seeds
+}
+
+// SeedParticipants sorts participants from best to worst seed.
+//
+// Participants with a seed come first, by ascending seed. Others follow, by descending set balance
+// (sets won minus sets lost), then by user id to keep order stable.
+//
+func SeedParticipants(participants []models.TournamentParticipant, setBalances map[string]int) (seededUsers []string) {
+	sortedParticipants := make([]models.TournamentParticipant, len(participants))
+	copy(sortedParticipants, participants)
+
+	sort.SliceStable(sortedParticipants, func(i, j int) bool {
+		iSeed, jSeed := sortedParticipants[i].Seed, sortedParticipants[j].Seed
+		switch {
+		case iSeed > 0 && jSeed > 0 && iSeed != jSeed:
+			return iSeed < jSeed
+		case iSeed > 0 && jSeed == 0:
+			return true
+		case iSeed == 0 && jSeed > 0:
+			return false
+		}
+		iBalance, jBalance := setBalances[sortedParticipants[i].UserId], setBalances[sortedParticipants[j].UserId]
+		if iBalance != jBalance {
+			return iBalance > jBalance
+		}
+		return sortedParticipants[i].UserId < sortedParticipants[j].UserId
+	})
+
+	for _, participant := range sortedParticipants {
+		seededUsers = append(seededUsers, participant.UserId)
+	}
+	return seededUsers
+}
+
+// fetchSetBalances retrieves difference between sets won and lost by each user, all scores together.
+//
+func fetchSetBalances(tx *pop.Connection) (setBalances map[string]int, fetchError error) {
+	var balances []userBalance
+
+	fetchError = tx.RawQuery(`SELECT user_id, SUM(won - lost) AS balance FROM (
+		SELECT user1_id AS user_id, user1_sets AS won, user2_sets AS lost FROM scores
+		UNION ALL
+		SELECT user2_id AS user_id, user2_sets AS won, user1_sets AS lost FROM scores
+	) AS user_sets GROUP BY user_id`).All(&balances)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+
+	setBalances = make(map[string]int)
+	for _, balance := range balances {
+		setBalances[balance.UserID] = balance.Balance
+	}
+	return setBalances, nil
+}
@@ -0,0 +1,47 @@
+package tournaments
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+)
+
+// TestBracketSize tests BracketSize function for several numbers of participants.
+//
+func TestBracketSize(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	assertHandler.Equal(2, BracketSize(2), "2 participants: bracket size not computed as expected")
+	assertHandler.Equal(4, BracketSize(3), "3 participants: bracket size not computed as expected")
+	assertHandler.Equal(8, BracketSize(8), "8 participants: bracket size not computed as expected")
+	assertHandler.Equal(16, BracketSize(9), "9 participants: bracket size not computed as expected")
+}
+
+// TestSeedOrder tests SeedOrder function for several bracket sizes.
+//
+func TestSeedOrder(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	assertHandler.Equal([]int{1, 2}, SeedOrder(2), "Bracket of 2: seeds not placed as expected")
+	assertHandler.Equal([]int{1, 4, 2, 3}, SeedOrder(4), "Bracket of 4: seeds not placed as expected")
+	assertHandler.Equal([]int{1, 8, 4, 5, 2, 7, 3, 6}, SeedOrder(8), "Bracket of 8: seeds not placed as expected")
+}
+
+// TestSeedParticipants tests SeedParticipants function for seeded and unseeded participants.
+//
+func TestSeedParticipants(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	participants := []models.TournamentParticipant{
+		{UserId: "user1"},
+		{UserId: "user2", Seed: 2},
+		{UserId: "user3"},
+		{UserId: "user4", Seed: 1},
+		{UserId: "user5"},
+		{UserId: "user6"},
+	}
+	setBalances := map[string]int{"user1": -2, "user3": 5, "user5": 5, "user2": 10}
+
+	awaitedSeeds := []string{"user4", "user2", "user3", "user5", "user6", "user1"}
+	assertHandler.Equal(awaitedSeeds, SeedParticipants(participants, setBalances), "Seeded and unseeded participants: participants not seeded as expected")
+}
@@ -0,0 +1,180 @@
+package tournaments
+
+import (
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+)
+
+// SingleEliminationMatches generates all matches of a single-elimination bracket for submitted seeded users.
+//
+// Bracket size is the smallest power of 2 able to hold all users: best seeds get a bye in first round
+// when there are not enough users, their first round match being directly won.
+//
+func SingleEliminationMatches(tournament models.Tournament, seededUsers []string) (matches []models.Match, generationError error) {
+	matches, generationError = eliminationBracket(tournament, seededUsers)
+	if generationError != nil {
+		return nil, generationError
+	}
+
+	resolveByes(matches)
+
+	return matches, nil
+}
+
+// eliminationBracket generates all matches of main bracket of an elimination tournament, seeded users being placed
+// in first round (byes are not resolved).
+//
+func eliminationBracket(tournament models.Tournament, seededUsers []string) (matches []models.Match, generationError error) {
+	bracketSize := BracketSize(len(seededUsers))
+	seeds := SeedOrder(bracketSize)
+
+	for roundMatches, round := bracketSize/2, 1; roundMatches >= 1; roundMatches, round = roundMatches/2, round+1 {
+		for position := 1; position <= roundMatches; position++ {
+			newMatch, generationError := newTournamentMatch(tournament, round, position)
+			if generationError != nil {
+				return nil, generationError
+			}
+			matches = append(matches, newMatch)
+		}
+	}
+
+	linkRounds(matches)
+
+	for position := 1; position <= bracketSize/2; position++ {
+		firstRoundMatch := findMatch(matches, 1, position)
+		for slot := 1; slot <= 2; slot++ {
+			if seed := seeds[2*(position-1)+slot-1]; seed <= len(seededUsers) {
+				firstRoundMatch.PlaceUser(slot, seededUsers[seed-1])
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// newTournamentMatch initializes a pending match of tournament.
+//
+func newTournamentMatch(tournament models.Tournament, round int, position int) (newMatch models.Match, generationError error) {
+	matchID, generationError := uuid.NewV4()
+	if generationError != nil {
+		return newMatch, generationError
+	}
+
+	return models.Match{
+		ID:           matchID,
+		TournamentID: nulls.NewUUID(tournament.ID),
+		Round:        round,
+		Position:     position,
+		Status:       models.MatchStatusPending,
+		SetsToWin:    tournament.SetsToWin,
+		Bracket:      models.MatchBracketMain,
+	}, nil
+}
+
+// findMatch returns match of main bracket of submitted round and position (nil if it does not exist).
+//
+func findMatch(matches []models.Match, round int, position int) (foundMatch *models.Match) {
+	return findBracketMatch(matches, models.MatchBracketMain, round, position)
+}
+
+// findBracketMatch returns match of submitted bracket, round and position (nil if it does not exist).
+//
+func findBracketMatch(matches []models.Match, bracket string, round int, position int) (foundMatch *models.Match) {
+	for index := range matches {
+		if matches[index].Bracket == bracket && matches[index].Round == round && matches[index].Position == position {
+			return &matches[index]
+		}
+	}
+	return nil
+}
+
+// findMatchByID returns match of submitted id (nil if it does not exist).
+//
+func findMatchByID(matches []models.Match, matchID uuid.UUID) (foundMatch *models.Match) {
+	for index := range matches {
+		if matches[index].ID == matchID {
+			return &matches[index]
+		}
+	}
+	return nil
+}
+
+// linkRounds links each match to match of next round that its winner will play.
+//
+// Winners of matches at positions 2n-1 and 2n play match at position n of next round (in slots 1 and 2).
+//
+func linkRounds(matches []models.Match) {
+	for index := range matches {
+		nextMatch := findMatch(matches, matches[index].Round+1, (matches[index].Position+1)/2)
+		if nextMatch == nil {
+			continue
+		}
+		matches[index].NextMatchID = nulls.NewUUID(nextMatch.ID)
+		matches[index].NextMatchSlot = 2 - matches[index].Position%2
+	}
+}
+
+// resolveByes finishes matches that only one user (or none) can play, this user advancing directly to next match.
+//
+// Matches must be ordered so that each match comes after matches sending it their winner or loser.
+// A slot of match can be filled when a user is already placed in it, or when a match to be played sends it its
+// winner or loser. When only one slot can be filled by a user not known yet, match sending this user is directly
+// linked to next match, so that this user skips finished match.
+//
+func resolveByes(matches []models.Match) {
+	var playedMatches = make(map[uuid.UUID]bool)
+
+	for index := range matches {
+		byeMatch := &matches[index]
+		if byeMatch.Status != models.MatchStatusPending {
+			playedMatches[byeMatch.ID] = byeMatch.Status == models.MatchStatusReady
+			continue
+		}
+
+		var feedingMatches = make(map[int]*models.Match)
+		for feedingIndex := range matches[:index] {
+			feedingMatch := &matches[feedingIndex]
+			if !playedMatches[feedingMatch.ID] {
+				continue
+			}
+			if feedingMatch.NextMatchID.Valid && feedingMatch.NextMatchID.UUID == byeMatch.ID {
+				feedingMatches[feedingMatch.NextMatchSlot] = feedingMatch
+			}
+			if feedingMatch.LoserMatchID.Valid && feedingMatch.LoserMatchID.UUID == byeMatch.ID {
+				feedingMatches[feedingMatch.LoserMatchSlot] = feedingMatch
+			}
+		}
+
+		var filledSlots []int
+		for slot, slotUser := range map[int]string{1: byeMatch.User1Id, 2: byeMatch.User2Id} {
+			if slotUser != "" || feedingMatches[slot] != nil {
+				filledSlots = append(filledSlots, slot)
+			}
+		}
+		if len(filledSlots) == 2 {
+			playedMatches[byeMatch.ID] = true
+			continue
+		}
+
+		byeMatch.Status = models.MatchStatusFinished
+		if len(filledSlots) == 0 {
+			continue
+		}
+
+		byeMatch.WinnerId = byeMatch.User1Id + byeMatch.User2Id
+		if byeMatch.WinnerId != "" {
+			if nextMatch := findMatchByID(matches, byeMatch.NextMatchID.UUID); byeMatch.NextMatchID.Valid && nextMatch != nil {
+				nextMatch.PlaceUser(byeMatch.NextMatchSlot, byeMatch.WinnerId)
+			}
+			continue
+		}
+
+		feedingMatch := feedingMatches[filledSlots[0]]
+		if feedingMatch.NextMatchID.Valid && feedingMatch.NextMatchID.UUID == byeMatch.ID {
+			feedingMatch.NextMatchID, feedingMatch.NextMatchSlot = byeMatch.NextMatchID, byeMatch.NextMatchSlot
+		} else {
+			feedingMatch.LoserMatchID, feedingMatch.LoserMatchSlot = byeMatch.NextMatchID, byeMatch.NextMatchSlot
+		}
+	}
+}
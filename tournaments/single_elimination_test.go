@@ -0,0 +1,67 @@
+package tournaments
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+)
+
+// TestSingleEliminationMatchesFullBracket tests SingleEliminationMatches function for a bracket without byes.
+//
+func TestSingleEliminationMatchesFullBracket(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournamentID, _ := uuid.NewV4()
+	tournament := models.Tournament{ID: tournamentID, SetsToWin: 2}
+
+	matches, generationError := SingleEliminationMatches(tournament, []string{"user1", "user2", "user3", "user4"})
+	assertHandler.Nil(generationError, "Bracket of 4: SingleEliminationMatches function should not raise an error")
+	assertHandler.Len(matches, 3, "Bracket of 4: bracket should have 3 matches")
+
+	firstSemiFinal := findMatch(matches, 1, 1)
+	secondSemiFinal := findMatch(matches, 1, 2)
+	final := findMatch(matches, 2, 1)
+
+	assertHandler.Equal("user1", firstSemiFinal.User1Id, "Bracket of 4: seed 1 not placed as expected")
+	assertHandler.Equal("user4", firstSemiFinal.User2Id, "Bracket of 4: seed 4 not placed as expected")
+	assertHandler.Equal("user2", secondSemiFinal.User1Id, "Bracket of 4: seed 2 not placed as expected")
+	assertHandler.Equal("user3", secondSemiFinal.User2Id, "Bracket of 4: seed 3 not placed as expected")
+	assertHandler.Equal(models.MatchStatusReady, firstSemiFinal.Status, "Bracket of 4: first round matches should be ready")
+	assertHandler.Equal(models.MatchStatusPending, final.Status, "Bracket of 4: final should be pending")
+	assertHandler.Equal(2, final.SetsToWin, "Bracket of 4: matches should be won with tournament number of sets")
+	assertHandler.True(final.TournamentID.Valid && final.TournamentID.UUID == tournamentID, "Bracket of 4: matches should belong to tournament")
+
+	assertHandler.Equal(final.ID, firstSemiFinal.NextMatchID.UUID, "Bracket of 4: first semi-final winner should play final")
+	assertHandler.Equal(1, firstSemiFinal.NextMatchSlot, "Bracket of 4: first semi-final winner should be first user of final")
+	assertHandler.Equal(final.ID, secondSemiFinal.NextMatchID.UUID, "Bracket of 4: second semi-final winner should play final")
+	assertHandler.Equal(2, secondSemiFinal.NextMatchSlot, "Bracket of 4: second semi-final winner should be second user of final")
+	assertHandler.False(final.NextMatchID.Valid, "Bracket of 4: final should not have next match")
+}
+
+// TestSingleEliminationMatchesWithByes tests SingleEliminationMatches function for a bracket with byes.
+//
+func TestSingleEliminationMatchesWithByes(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournamentID, _ := uuid.NewV4()
+	tournament := models.Tournament{ID: tournamentID, SetsToWin: 1}
+
+	matches, _ := SingleEliminationMatches(tournament, []string{"user1", "user2", "user3", "user4", "user5"})
+	assertHandler.Len(matches, 7, "Bracket of 5: bracket should have 7 matches")
+
+	byeMatch := findMatch(matches, 1, 1)
+	assertHandler.Equal(models.MatchStatusFinished, byeMatch.Status, "Bracket of 5: seed 1 first match should be finished")
+	assertHandler.Equal("user1", byeMatch.WinnerId, "Bracket of 5: seed 1 should win first match")
+
+	playedMatch := findMatch(matches, 1, 2)
+	assertHandler.Equal(models.MatchStatusReady, playedMatch.Status, "Bracket of 5: seed 4 vs seed 5 match should be ready")
+	assertHandler.Equal([]string{"user4", "user5"}, []string{playedMatch.User1Id, playedMatch.User2Id}, "Bracket of 5: seeds 4 and 5 should play first round")
+
+	secondRoundMatch := findMatch(matches, 2, 1)
+	assertHandler.Equal("user1", secondRoundMatch.User1Id, "Bracket of 5: seed 1 should advance to second round")
+	assertHandler.Equal("", secondRoundMatch.User2Id, "Bracket of 5: seed 1 opponent should not be known yet")
+	assertHandler.Equal(models.MatchStatusPending, secondRoundMatch.Status, "Bracket of 5: seed 1 second round match should be pending")
+
+	otherSecondRoundMatch := findMatch(matches, 2, 2)
+	assertHandler.Equal([]string{"user2", "user3"}, []string{otherSecondRoundMatch.User1Id, otherSecondRoundMatch.User2Id}, "Bracket of 5: seeds 2 and 3 should advance to second round")
+	assertHandler.Equal(models.MatchStatusReady, otherSecondRoundMatch.Status, "Bracket of 5: seeds 2 vs 3 match should be ready")
+}
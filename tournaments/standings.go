@@ -0,0 +1,133 @@
+package tournaments
+
+import (
+	"github.com/gobuffalo/pop"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"sort"
+)
+
+// pointsPerMatchWin is the number of standings points earned by winning a match (losing a match earns none).
+const pointsPerMatchWin = 3
+
+// Standing represents ranking of one participant in standings of a tournament.
+//
+// Only finished matches are counted, a bye counting as a won match (without any set).
+// Points scored are points of finished sets.
+// Qualified is only set in group tables, for participants qualified for knockout.
+//
+type Standing struct {
+	Rank            int    `json:"rank"`
+	UserId          string `json:"user_id"`
+	Points          int    `json:"points"`
+	Played          int    `json:"played"`
+	Won             int    `json:"won"`
+	Lost            int    `json:"lost"`
+	SetsWon         int    `json:"sets_won"`
+	SetsLost        int    `json:"sets_lost"`
+	SetDifference   int    `json:"set_difference"`
+	PointsScored    int    `json:"points_scored"`
+	PointsConceded  int    `json:"points_conceded"`
+	PointDifference int    `json:"point_difference"`
+	Qualified       bool   `json:"qualified,omitempty"`
+}
+
+// HasStandings checks if tournaments of submitted format are ranked through standings.
+//
+func HasStandings(format string) (standingsAvailable bool) {
+	return format == models.TournamentFormatRoundRobin || format == models.TournamentFormatSwiss
+}
+
+// ComputeStandings ranks participants according to their finished matches and sets of their scores.
+//
+// Participants are sorted by standings points, then set difference, then point difference (then user id).
+//
+func ComputeStandings(participants []string, matches []models.Match, matchScores []models.Score) (standings []Standing) {
+	var standingsByUser = make(map[string]*Standing)
+	var scoresByMatch = scoresByMatchID(matchScores)
+
+	standings = make([]Standing, len(participants))
+	for index, participant := range participants {
+		standings[index].UserId = participant
+		standingsByUser[participant] = &standings[index]
+	}
+
+	for _, match := range matches {
+		if match.Status != models.MatchStatusFinished {
+			continue
+		}
+		matchScore := scoresByMatch[match.ID.String()]
+
+		for _, userID := range []string{match.User1Id, match.User2Id} {
+			userStanding, participantExists := standingsByUser[userID]
+			if !participantExists {
+				continue
+			}
+			opponentID := match.Opponent(userID)
+
+			userStanding.Played++
+			if match.WinnerId == userID {
+				userStanding.Won++
+				userStanding.Points += pointsPerMatchWin
+			} else {
+				userStanding.Lost++
+			}
+			userStanding.SetsWon += matchScore.UserSets(userID)
+			userStanding.SetsLost += matchScore.UserSets(opponentID)
+			userStanding.PointsScored += match.UserPoints(userID)
+			userStanding.PointsConceded += match.UserPoints(opponentID)
+		}
+	}
+
+	for index := range standings {
+		standings[index].SetDifference = standings[index].SetsWon - standings[index].SetsLost
+		standings[index].PointDifference = standings[index].PointsScored - standings[index].PointsConceded
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		return ranksBefore(standings[i], standings[j])
+	})
+
+	for index := range standings {
+		standings[index].Rank = index + 1
+	}
+
+	return standings
+}
+
+// ranksBefore checks if first standing ranks before second one: by standings points, then set difference,
+// then point difference (then user id).
+//
+func ranksBefore(firstStanding Standing, secondStanding Standing) (before bool) {
+	if firstStanding.Points != secondStanding.Points {
+		return firstStanding.Points > secondStanding.Points
+	}
+	if firstStanding.SetDifference != secondStanding.SetDifference {
+		return firstStanding.SetDifference > secondStanding.SetDifference
+	}
+	if firstStanding.PointDifference != secondStanding.PointDifference {
+		return firstStanding.PointDifference > secondStanding.PointDifference
+	}
+	return firstStanding.UserId < secondStanding.UserId
+}
+
+// FetchStandings retrieves participants, matches and scores of tournament, then computes its standings.
+//
+func FetchStandings(tx *pop.Connection, tournament models.Tournament) (standings []Standing, fetchError error) {
+	var participants []models.TournamentParticipant
+	var participantIDs []string
+
+	fetchError = tx.Where("tournament_id = ?", tournament.ID).All(&participants)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+	for _, participant := range participants {
+		participantIDs = append(participantIDs, participant.UserId)
+	}
+
+	matches, matchScores, fetchError := fetchMatches(tx, tournament)
+	if fetchError != nil {
+		return nil, fetchError
+	}
+
+	return ComputeStandings(participantIDs, matches, matchScores), nil
+}
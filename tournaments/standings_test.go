@@ -0,0 +1,63 @@
+package tournaments
+
+import (
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+)
+
+// finishedMatch initializes a finished match and its score for standings tests.
+//
+func finishedMatch(winnerID string, loserID string, winnerSets int, loserSets int, winnerPoints int, loserPoints int) (match models.Match, matchScore models.Score) {
+	matchID, _ := uuid.NewV4()
+	match = models.Match{ID: matchID, User1Id: winnerID, User2Id: loserID, WinnerId: winnerID, Status: models.MatchStatusFinished, User1Points: winnerPoints, User2Points: loserPoints}
+	matchScore = models.Score{User1Id: loserID, User2Id: winnerID, User1Sets: loserSets, User2Sets: winnerSets, MatchID: nulls.NewUUID(matchID)}
+	return match, matchScore
+}
+
+// TestComputeStandings tests ComputeStandings function with its tiebreakers.
+//
+func TestComputeStandings(t *testing.T) {
+	assertHandler := assert.New(t)
+	var matches []models.Match
+	var matchScores []models.Score
+
+	for _, result := range []struct {
+		winner, loser                                     string
+		winnerSets, loserSets, winnerPoints, loserPoints int
+	}{
+		{"user1", "user2", 2, 0, 20, 12},
+		{"user2", "user3", 2, 1, 28, 25},
+		{"user3", "user1", 2, 0, 20, 5},
+		{"user4", "user3", 2, 1, 26, 24},
+	} {
+		match, matchScore := finishedMatch(result.winner, result.loser, result.winnerSets, result.loserSets, result.winnerPoints, result.loserPoints)
+		matches = append(matches, match)
+		matchScores = append(matchScores, matchScore)
+	}
+	pendingMatch := models.Match{User1Id: "user4", User2Id: "user1", Status: models.MatchStatusReady}
+	matches = append(matches, pendingMatch)
+
+	standings := ComputeStandings([]string{"user1", "user2", "user3", "user4", "user5"}, matches, matchScores)
+	assertHandler.Len(standings, 5, "League of 5: all participants should be ranked")
+
+	rankedUsers := []string{}
+	for _, standing := range standings {
+		rankedUsers = append(rankedUsers, standing.UserId)
+	}
+	// user1, user2 and user3 have 3 points: user2 has worst set difference (-1), user3 has better point difference than user1 (+10 vs -7)
+	assertHandler.Equal([]string{"user4", "user3", "user1", "user2", "user5"}, rankedUsers, "League of 5: participants not ranked as expected")
+
+	user3Standing := standings[1]
+	assertHandler.Equal(2, user3Standing.Rank, "League of 5: rank not computed as expected")
+	assertHandler.Equal(3, user3Standing.Points, "League of 5: points not computed as expected")
+	assertHandler.Equal(3, user3Standing.Played, "League of 5: played matches not counted as expected")
+	assertHandler.Equal(1, user3Standing.Won, "League of 5: won matches not counted as expected")
+	assertHandler.Equal(2, user3Standing.Lost, "League of 5: lost matches not counted as expected")
+	assertHandler.Equal(4, user3Standing.SetsWon, "League of 5: won sets not counted as expected")
+	assertHandler.Equal(4, user3Standing.SetsLost, "League of 5: lost sets not counted as expected")
+	assertHandler.Equal(69, user3Standing.PointsScored, "League of 5: scored points not counted as expected")
+	assertHandler.Equal(0, standings[4].Played, "League of 5: participant without match should not have played")
+}
@@ -0,0 +1,137 @@
+package tournaments
+
+import (
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+)
+
+// maxPairingSteps is the maximum number of pairs tried while searching a pairing of a round without rematch.
+const maxPairingSteps = 10000
+
+// SwissRoundsCount returns default number of rounds of a Swiss-system tournament,
+// which is enough to designate a single unbeaten user.
+//
+func SwissRoundsCount(participantsCount int) (roundsCount int) {
+	for bracketSize := 1; bracketSize < participantsCount; bracketSize *= 2 {
+		roundsCount++
+	}
+	return roundsCount
+}
+
+// MaxSwissRoundsCount returns maximum number of rounds of a Swiss-system tournament, after which users could not be
+// paired without rematches anymore: each user plays all others once (and gets a bye, with an odd number of users).
+//
+func MaxSwissRoundsCount(participantsCount int) (roundsCount int) {
+	if participantsCount%2 != 0 {
+		return participantsCount
+	}
+	return participantsCount - 1
+}
+
+// SwissRoundMatches generates matches of one round of a Swiss-system tournament.
+//
+// Users must be ranked from best to worst (by standings, or by seeds for first round): each user is paired
+// with closest ranked user they have not played yet in previous matches. When no pairing avoids rematches (or none
+// is found within maxPairingSteps), users are paired by rank regardless of previous matches.
+// With an odd number of users, lowest ranked user who did not get a bye yet gets one: their match is directly won.
+//
+func SwissRoundMatches(tournament models.Tournament, round int, rankedUsers []string, previousMatches []models.Match) (matches []models.Match, generationError error) {
+	var playedPairs = make(map[[2]string]bool)
+	var byeUsers = make(map[string]bool)
+	var usersToPair = append([]string{}, rankedUsers...)
+	var byeUser string
+
+	for _, previousMatch := range previousMatches {
+		switch {
+		case previousMatch.User1Id != "" && previousMatch.User2Id != "":
+			playedPairs[userPair(previousMatch.User1Id, previousMatch.User2Id)] = true
+		case previousMatch.WinnerId != "":
+			byeUsers[previousMatch.WinnerId] = true
+		}
+	}
+
+	if len(usersToPair)%2 != 0 {
+		byeIndex := len(usersToPair) - 1
+		for index := len(usersToPair) - 1; index >= 0; index-- {
+			if !byeUsers[usersToPair[index]] {
+				byeIndex = index
+				break
+			}
+		}
+		byeUser = usersToPair[byeIndex]
+		usersToPair = append(usersToPair[:byeIndex:byeIndex], usersToPair[byeIndex+1:]...)
+	}
+
+	pairingSteps := maxPairingSteps
+	pairs, pairingFound := pairWithoutRematch(usersToPair, playedPairs, &pairingSteps)
+	if !pairingFound {
+		pairs = nil
+		for index := 0; index < len(usersToPair); index += 2 {
+			pairs = append(pairs, [2]string{usersToPair[index], usersToPair[index+1]})
+		}
+	}
+
+	for position, pair := range pairs {
+		newMatch, generationError := newTournamentMatch(tournament, round, position+1)
+		if generationError != nil {
+			return nil, generationError
+		}
+		newMatch.PlaceUser(1, pair[0])
+		newMatch.PlaceUser(2, pair[1])
+		matches = append(matches, newMatch)
+	}
+
+	if byeUser != "" {
+		byeMatch, generationError := newTournamentMatch(tournament, round, len(pairs)+1)
+		if generationError != nil {
+			return nil, generationError
+		}
+		byeMatch.PlaceUser(1, byeUser)
+		byeMatch.Status = models.MatchStatusFinished
+		byeMatch.WinnerId = byeUser
+		matches = append(matches, byeMatch)
+	}
+
+	return matches, nil
+}
+
+// pairWithoutRematch pairs ranked users two by two, each user being paired with closest ranked user
+// they have not played yet (backtracking when remaining users cannot be paired).
+//
+// Backtracking can take exponential time when almost all pairs were already played: search gives up once remaining
+// steps are exhausted.
+//
+func pairWithoutRematch(rankedUsers []string, playedPairs map[[2]string]bool, remainingSteps *int) (pairs [][2]string, pairingFound bool) {
+	if len(rankedUsers) == 0 {
+		return [][2]string{}, true
+	}
+
+	for index := 1; index < len(rankedUsers); index++ {
+		if playedPairs[userPair(rankedUsers[0], rankedUsers[index])] {
+			continue
+		}
+		if *remainingSteps <= 0 {
+			return nil, false
+		}
+		*remainingSteps--
+
+		remainingUsers := make([]string, 0, len(rankedUsers)-2)
+		remainingUsers = append(remainingUsers, rankedUsers[1:index]...)
+		remainingUsers = append(remainingUsers, rankedUsers[index+1:]...)
+
+		remainingPairs, remainingPairingFound := pairWithoutRematch(remainingUsers, playedPairs, remainingSteps)
+		if remainingPairingFound {
+			return append([][2]string{{rankedUsers[0], rankedUsers[index]}}, remainingPairs...), true
+		}
+	}
+
+	return nil, false
+}
+
+// userPair returns pair of submitted users, independently of their order.
+//
+func userPair(firstUserID string, secondUserID string) (pair [2]string) {
+	if firstUserID > secondUserID {
+		return [2]string{secondUserID, firstUserID}
+	}
+	return [2]string{firstUserID, secondUserID}
+}
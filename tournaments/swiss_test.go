@@ -0,0 +1,120 @@
+package tournaments
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"testing"
+	"time"
+)
+
+// TestSwissRoundsCount tests SwissRoundsCount function for several numbers of participants.
+//
+func TestSwissRoundsCount(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	assertHandler.Equal(1, SwissRoundsCount(2), "2 participants: number of rounds not computed as expected")
+	assertHandler.Equal(3, SwissRoundsCount(7), "7 participants: number of rounds not computed as expected")
+	assertHandler.Equal(5, SwissRoundsCount(32), "32 participants: number of rounds not computed as expected")
+}
+
+// TestMaxSwissRoundsCount tests MaxSwissRoundsCount function for even and odd numbers of participants.
+//
+func TestMaxSwissRoundsCount(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	assertHandler.Equal(3, MaxSwissRoundsCount(4), "4 participants: each user should be able to play 3 others")
+	assertHandler.Equal(5, MaxSwissRoundsCount(5), "5 participants: each user should be able to play 4 others and get a bye")
+}
+
+// TestSwissRoundMatchesFirstRound tests SwissRoundMatches function for a first round with a bye.
+//
+func TestSwissRoundMatchesFirstRound(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournament := models.Tournament{SetsToWin: 1, Rounds: 3}
+
+	matches, generationError := SwissRoundMatches(tournament, 1, []string{"user1", "user2", "user3", "user4", "user5"}, nil)
+	assertHandler.Nil(generationError, "First round: SwissRoundMatches function should not raise an error")
+	assertHandler.Len(matches, 3, "First round: round should have 2 matches and a bye")
+
+	assertHandler.Equal([]string{"user1", "user2"}, []string{matches[0].User1Id, matches[0].User2Id}, "First round: closest ranked users should be paired")
+	assertHandler.Equal([]string{"user3", "user4"}, []string{matches[1].User1Id, matches[1].User2Id}, "First round: closest ranked users should be paired")
+	assertHandler.Equal(models.MatchStatusReady, matches[1].Status, "First round: paired matches should be ready")
+
+	assertHandler.Equal("user5", matches[2].WinnerId, "First round: lowest ranked user should get a bye")
+	assertHandler.Equal(models.MatchStatusFinished, matches[2].Status, "First round: bye should be directly won")
+	assertHandler.Equal(3, matches[2].Position, "First round: bye should be last match of round")
+}
+
+// TestSwissRoundMatchesAvoidRematches tests SwissRoundMatches function avoiding rematches and second byes.
+//
+func TestSwissRoundMatchesAvoidRematches(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournament := models.Tournament{SetsToWin: 1, Rounds: 3}
+
+	previousMatches := []models.Match{
+		{Round: 1, User1Id: "user1", User2Id: "user2", WinnerId: "user1", Status: models.MatchStatusFinished},
+		{Round: 1, User1Id: "user3", User2Id: "user4", WinnerId: "user3", Status: models.MatchStatusFinished},
+		{Round: 1, User1Id: "user5", WinnerId: "user5", Status: models.MatchStatusFinished},
+	}
+	rankedUsers := []string{"user1", "user3", "user5", "user2", "user4"}
+
+	matches, _ := SwissRoundMatches(tournament, 2, rankedUsers, previousMatches)
+	assertHandler.Len(matches, 3, "Second round: round should have 2 matches and a bye")
+	assertHandler.Equal([]string{"user1", "user3"}, []string{matches[0].User1Id, matches[0].User2Id}, "Second round: closest ranked users should be paired")
+	assertHandler.Equal([]string{"user5", "user2"}, []string{matches[1].User1Id, matches[1].User2Id}, "Second round: user who got a bye should play")
+	assertHandler.Equal("user4", matches[2].WinnerId, "Second round: lowest ranked user without bye should get a bye")
+	assertHandler.Equal(2, matches[0].Round, "Second round: matches should belong to submitted round")
+
+	previousMatches = append(previousMatches, matches...)
+	previousMatches[3].WinnerId, previousMatches[4].WinnerId = "user1", "user5"
+	rankedUsers = []string{"user1", "user5", "user3", "user4", "user2"}
+
+	matches, _ = SwissRoundMatches(tournament, 3, rankedUsers, previousMatches)
+	for _, match := range matches {
+		for _, previousMatch := range previousMatches {
+			if match.User2Id != "" && previousMatch.User2Id != "" {
+				assertHandler.False(match.HasUsers(previousMatch.User1Id, previousMatch.User2Id), "Third round: users %s and %s should not play again", match.User1Id, match.User2Id)
+			}
+		}
+	}
+	assertHandler.Equal("user2", matches[2].WinnerId, "Third round: lowest ranked user without bye should get a bye")
+}
+
+// TestSwissRoundMatchesForcedRematch tests SwissRoundMatches function when rematches cannot be avoided.
+//
+func TestSwissRoundMatchesForcedRematch(t *testing.T) {
+	assertHandler := assert.New(t)
+	tournament := models.Tournament{SetsToWin: 1, Rounds: 2}
+
+	previousMatches := []models.Match{
+		{Round: 1, User1Id: "user1", User2Id: "user2", WinnerId: "user1", Status: models.MatchStatusFinished},
+	}
+
+	matches, _ := SwissRoundMatches(tournament, 2, []string{"user1", "user2"}, previousMatches)
+	assertHandler.Len(matches, 1, "Forced rematch: round should have 1 match")
+	assertHandler.True(matches[0].HasUsers("user1", "user2"), "Forced rematch: users should play again")
+}
+
+// TestSwissRoundMatchesBoundedSearch tests SwissRoundMatches function giving up search of a pairing without rematch
+// when none exists and backtracking would take exponential time.
+//
+func TestSwissRoundMatchesBoundedSearch(t *testing.T) {
+	var rankedUsers []string
+	var previousMatches []models.Match
+
+	// Users of two groups of 15 played all users of other group: remaining users of a group can never all be paired
+	for index := 0; index < 30; index++ {
+		rankedUsers = append(rankedUsers, fmt.Sprintf("user%02d", index))
+	}
+	for firstIndex := 0; firstIndex < 15; firstIndex++ {
+		for secondIndex := 15; secondIndex < 30; secondIndex++ {
+			previousMatches = append(previousMatches, models.Match{User1Id: rankedUsers[firstIndex], User2Id: rankedUsers[secondIndex], WinnerId: rankedUsers[firstIndex], Status: models.MatchStatusFinished})
+		}
+	}
+
+	start := time.Now()
+	matches, _ := SwissRoundMatches(models.Tournament{SetsToWin: 1, Rounds: 16}, 16, rankedUsers, previousMatches)
+	assert.Len(t, matches, 15, "No pairing without rematch: users should be paired by rank")
+	assert.True(t, time.Since(start) < time.Second, "No pairing without rematch: search should give up quickly")
+}
@@ -0,0 +1,322 @@
+package tournaments
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"github.com/vlarrat-theodo/lbc-foosball/webhooks"
+	"sort"
+)
+
+// Errors raised when next round of a Swiss-system tournament cannot be generated yet.
+var (
+	ErrRoundInProgress = errors.New("all matches of current round must be finished")
+	ErrNoRoundLeft     = errors.New("all rounds of tournament have been generated")
+)
+
+// ErrTooManyRounds is raised when a Swiss-system tournament is started with more rounds than its participants can
+// play without rematch.
+var ErrTooManyRounds = errors.New("tournament has more rounds than participants can play without rematch")
+
+// Start generates all matches of tournament according to its format, and sets tournament as running.
+//
+// Participants are seeded beforehand (see SeedParticipants), unseeded ones according to their set balance.
+//
+func Start(tx *pop.Connection, tournament *models.Tournament) (matches []models.Match, startError error) {
+	var participants []models.TournamentParticipant
+
+	if tournament.Status != models.TournamentStatusRegistration {
+		return nil, fmt.Errorf("tournament is %s", tournament.Status)
+	}
+
+	startError = tx.Where("tournament_id = ?", tournament.ID).All(&participants)
+	if startError != nil {
+		return nil, startError
+	}
+	if len(participants) < 2 {
+		return nil, errors.New("tournament needs at least 2 participants")
+	}
+
+	setBalances, startError := fetchSetBalances(tx)
+	if startError != nil {
+		return nil, startError
+	}
+	seededUsers := SeedParticipants(participants, setBalances)
+
+	switch tournament.Format {
+	case models.TournamentFormatSingleElimination:
+		matches, startError = SingleEliminationMatches(*tournament, seededUsers)
+	case models.TournamentFormatRoundRobin:
+		matches, startError = RoundRobinMatches(*tournament, seededUsers)
+	case models.TournamentFormatSwiss:
+		// Only first round is generated, next ones depending on results (see NextRound)
+		if tournament.Rounds == 0 {
+			tournament.Rounds = SwissRoundsCount(len(seededUsers))
+		}
+		if tournament.Rounds > MaxSwissRoundsCount(len(seededUsers)) {
+			return nil, ErrTooManyRounds
+		}
+		matches, startError = SwissRoundMatches(*tournament, 1, seededUsers, nil)
+	case models.TournamentFormatDoubleElimination:
+		matches, startError = DoubleEliminationMatches(*tournament, seededUsers)
+	case models.TournamentFormatGroupsKnockout:
+		// Only group stage is generated, knockout depending on group tables (see startKnockout)
+		groups := DrawGroups(seededUsers, tournament.GroupSize)
+		startError = checkGroups(groups, tournament.QualifiersPerGroup)
+		if startError == nil {
+			startError = assignGroups(tx, participants, groups)
+		}
+		if startError == nil {
+			matches, startError = GroupStageMatches(*tournament, groups)
+		}
+	default:
+		startError = fmt.Errorf("tournament format %s is not supported", tournament.Format)
+	}
+	if startError != nil {
+		return nil, startError
+	}
+
+	// Matches IDs are generated beforehand to link them together, so they must be explicitly created
+	for index := range matches {
+		startError = validateAndCreate(tx, &matches[index])
+		if startError != nil {
+			return nil, startError
+		}
+	}
+
+	tournament.Status = models.TournamentStatusRunning
+	startError = validateAndSave(tx, tournament)
+	if startError != nil {
+		return nil, startError
+	}
+
+	return matches, completeTournament(tx, tournament)
+}
+
+// NextRound generates matches of next round of a running Swiss-system tournament,
+// once all matches of previous rounds are finished.
+//
+// Users are paired according to current standings, avoiding rematches.
+//
+func NextRound(tx *pop.Connection, tournament *models.Tournament) (matches []models.Match, roundError error) {
+	if tournament.Format != models.TournamentFormatSwiss {
+		return nil, fmt.Errorf("rounds of %s tournaments cannot be generated", tournament.Format)
+	}
+	if tournament.Status != models.TournamentStatusRunning {
+		return nil, fmt.Errorf("tournament is %s", tournament.Status)
+	}
+
+	previousMatches, _, roundError := fetchMatches(tx, *tournament)
+	if roundError != nil {
+		return nil, roundError
+	}
+
+	lastRound := 0
+	for _, previousMatch := range previousMatches {
+		if previousMatch.Status != models.MatchStatusFinished {
+			return nil, ErrRoundInProgress
+		}
+		if previousMatch.Round > lastRound {
+			lastRound = previousMatch.Round
+		}
+	}
+	if lastRound >= tournament.Rounds {
+		return nil, ErrNoRoundLeft
+	}
+
+	standings, roundError := FetchStandings(tx, *tournament)
+	if roundError != nil {
+		return nil, roundError
+	}
+	rankedUsers := make([]string, 0, len(standings))
+	for _, standing := range standings {
+		rankedUsers = append(rankedUsers, standing.UserId)
+	}
+
+	matches, roundError = SwissRoundMatches(*tournament, lastRound+1, rankedUsers, previousMatches)
+	if roundError != nil {
+		return nil, roundError
+	}
+
+	for index := range matches {
+		roundError = validateAndCreate(tx, &matches[index])
+		if roundError != nil {
+			return nil, roundError
+		}
+	}
+
+	return matches, completeTournament(tx, tournament)
+}
+
+// RecordSet records a finished set of match, accumulating points scored in set by both users,
+// then finishes match when submitted score designates its winner.
+//
+func RecordSet(tx *pop.Connection, setMatch *models.Match, matchScore models.Score, setWinnerID string, winnerPoints int, loserPoints int) (recordError error) {
+	setMatch.RecordSetPoints(setWinnerID, winnerPoints)
+	setMatch.RecordSetPoints(setMatch.Opponent(setWinnerID), loserPoints)
+
+	if winnerID, matchFinished := setMatch.Winner(matchScore); matchFinished {
+		return FinishMatch(tx, setMatch, winnerID)
+	}
+	return validateAndSave(tx, setMatch)
+}
+
+// FinishMatch records winner of match (queuing its event for webhooks), then places winner in next match (if any),
+// loser in losers bracket match (if any), and finishes tournament when all its matches have been played.
+//
+func FinishMatch(tx *pop.Connection, finishedMatch *models.Match, winnerID string) (finishError error) {
+	finishedMatch.Status = models.MatchStatusFinished
+	finishedMatch.WinnerId = winnerID
+	finishError = validateAndSave(tx, finishedMatch)
+	if finishError != nil {
+		return finishError
+	}
+	finishError = webhooks.Enqueue(tx, models.WebhookEventMatchFinished, finishedMatch, finishedMatch.UpdatedAt)
+	if finishError != nil {
+		return finishError
+	}
+
+	if finishedMatch.NextMatchID.Valid {
+		finishError = placeUserInMatch(tx, finishedMatch.NextMatchID.UUID, finishedMatch.NextMatchSlot, winnerID)
+		if finishError != nil {
+			return finishError
+		}
+	}
+
+	if finishedMatch.LoserMatchID.Valid {
+		finishError = placeUserInMatch(tx, finishedMatch.LoserMatchID.UUID, finishedMatch.LoserMatchSlot, finishedMatch.Opponent(winnerID))
+		if finishError != nil {
+			return finishError
+		}
+	}
+
+	if !finishedMatch.TournamentID.Valid {
+		return nil
+	}
+
+	var tournament models.Tournament
+	finishError = tx.Find(&tournament, finishedMatch.TournamentID.UUID)
+	if finishError != nil {
+		return finishError
+	}
+	return completeTournament(tx, &tournament)
+}
+
+// placeUserInMatch places user in submitted slot of a match stored in database.
+//
+func placeUserInMatch(tx *pop.Connection, matchID interface{}, slot int, userID string) (placeError error) {
+	var matchToUpdate models.Match
+
+	placeError = tx.Find(&matchToUpdate, matchID)
+	if placeError != nil {
+		return placeError
+	}
+
+	matchToUpdate.PlaceUser(slot, userID)
+	return validateAndSave(tx, &matchToUpdate)
+}
+
+// completeTournament finishes running tournament when all its matches have been played, designating its winner.
+//
+// Group stage of a group-stage-plus-knockout tournament being over, its knockout is generated instead.
+//
+func completeTournament(tx *pop.Connection, tournament *models.Tournament) (completeError error) {
+	var tournamentMatches []models.Match
+
+	if tournament.Status != models.TournamentStatusRunning {
+		return nil
+	}
+
+	completeError = tx.Where("tournament_id = ?", tournament.ID).Order("round, position").All(&tournamentMatches)
+	if completeError != nil {
+		return completeError
+	}
+	sortMatches(tournamentMatches)
+
+	for _, tournamentMatch := range tournamentMatches {
+		if tournamentMatch.Status != models.MatchStatusFinished {
+			return nil
+		}
+	}
+
+	// Last match of main bracket is the final (or grand final) of elimination formats
+	var lastMatch models.Match
+	for _, tournamentMatch := range tournamentMatches {
+		if tournamentMatch.Bracket == models.MatchBracketMain {
+			lastMatch = tournamentMatch
+		}
+	}
+
+	// Swiss-system tournament is only finished once its last round has been played
+	if tournament.Format == models.TournamentFormatSwiss && lastMatch.Round < tournament.Rounds {
+		return nil
+	}
+	if tournament.Format == models.TournamentFormatGroupsKnockout && lastMatch.Round == 0 {
+		return startKnockout(tx, *tournament)
+	}
+
+	switch tournament.Format {
+	case models.TournamentFormatSingleElimination, models.TournamentFormatDoubleElimination, models.TournamentFormatGroupsKnockout:
+		tournament.WinnerId = lastMatch.WinnerId
+	case models.TournamentFormatRoundRobin, models.TournamentFormatSwiss:
+		standings, completeError := FetchStandings(tx, *tournament)
+		if completeError != nil {
+			return completeError
+		}
+		tournament.WinnerId = standings[0].UserId
+	}
+
+	tournament.Status = models.TournamentStatusFinished
+	return validateAndSave(tx, tournament)
+}
+
+// sortMatches sorts matches by bracket (in display order), then round, then position.
+//
+func sortMatches(matches []models.Match) {
+	var bracketOrder = make(map[string]int)
+	for index, bracket := range models.MatchBrackets {
+		bracketOrder[bracket] = index
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Bracket != matches[j].Bracket {
+			return bracketOrder[matches[i].Bracket] < bracketOrder[matches[j].Bracket]
+		}
+		if matches[i].Round != matches[j].Round {
+			return matches[i].Round < matches[j].Round
+		}
+		return matches[i].Position < matches[j].Position
+	})
+}
+
+// validateAndSave validates and saves submitted model, validation errors being returned as an error.
+//
+func validateAndSave(tx *pop.Connection, model interface{}) (saveError error) {
+	var validateError *validate.Errors
+
+	validateError, saveError = tx.ValidateAndSave(model)
+	if saveError != nil {
+		return saveError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return validateError
+	}
+	return nil
+}
+
+// validateAndCreate validates and creates submitted model, validation errors being returned as an error.
+//
+func validateAndCreate(tx *pop.Connection, model interface{}) (createError error) {
+	var validateError *validate.Errors
+
+	validateError, createError = tx.ValidateAndCreate(model)
+	if createError != nil {
+		return createError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return validateError
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"strings"
+)
+
+// Exporters of traces, configured in OTEL_TRACES_EXPORTER environment variable.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// defaultOTLPEndpoint is the address of OTLP/HTTP collector when none is configured, reached without TLS.
+const defaultOTLPEndpoint = "localhost:4318"
+
+// instrumentationName is the name of instrumentation creating spans of this project.
+const instrumentationName = "github.com/vlarrat-theodo/lbc-foosball"
+
+// provider is the tracer provider configured by Init (nil when traces are not exported).
+var provider *sdktrace.TracerProvider
+
+// Init configures export of traces of submitted service, according to environment variables:
+//     - OTEL_TRACES_EXPORTER: "otlp", "stdout" or "none" ("none" by default, spans being then discarded)
+//     - OTEL_EXPORTER_OTLP_ENDPOINT: base URL of OTLP/HTTP collector ("http://localhost:4318" by default)
+//
+// Spans are exported in batches: Flush must be called before Lambda functions return, as they are frozen afterwards.
+//
+func Init(serviceName string) (initError error) {
+	var exporter sdktrace.SpanExporter
+
+	switch exporterName := strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")); exporterName {
+	case "", ExporterNone:
+		return nil
+	case ExporterStdout:
+		exporter, initError = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		// Exporter reads OTEL_EXPORTER_OTLP_ENDPOINT by itself
+		var options []otlptracehttp.Option
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+			options = append(options, otlptracehttp.WithEndpoint(defaultOTLPEndpoint), otlptracehttp.WithInsecure())
+		}
+		exporter, initError = otlptracehttp.New(context.Background(), options...)
+	default:
+		return fmt.Errorf("unknown traces exporter '%s'", exporterName)
+	}
+	if initError != nil {
+		return initError
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return nil
+}
+
+// Flush exports all spans ended so far.
+//
+func Flush(ctx context.Context) (flushError error) {
+	if provider == nil {
+		return nil
+	}
+	return provider.ForceFlush(ctx)
+}
+
+// Shutdown exports all spans ended so far, then stops exporting spans.
+//
+func Shutdown(ctx context.Context) (shutdownError error) {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+// Start starts a span as a child of span of submitted context (if any), returning context of new span.
+//
+func Start(ctx context.Context, spanName string, attributes ...attribute.KeyValue) (spanContext context.Context, span trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, spanName, trace.WithAttributes(attributes...))
+}
+
+// End ends a span, recording submitted error (if any) as error of span.
+//
+func End(span trace.Span, spanError error) {
+	if spanError != nil {
+		span.RecordError(spanError)
+		span.SetStatus(codes.Error, spanError.Error())
+	}
+	span.End()
+}
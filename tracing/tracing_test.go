@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TestInit tests Init function configuring exporter of traces.
+//
+func TestInit(t *testing.T) {
+	assertHandler := assert.New(t)
+	defer os.Unsetenv("OTEL_TRACES_EXPORTER")
+
+	os.Setenv("OTEL_TRACES_EXPORTER", "zipkin")
+	assertHandler.NotNil(Init("StoreGoal"), "Unknown exporters should raise an error")
+	assertHandler.Nil(provider, "Traces should not be exported with an unknown exporter")
+
+	os.Unsetenv("OTEL_TRACES_EXPORTER")
+	assertHandler.Nil(Init("StoreGoal"), "Traces should not be exported by default")
+	assertHandler.Nil(Flush(context.Background()), "Flushing unexported traces should do nothing")
+
+	os.Setenv("OTEL_TRACES_EXPORTER", "OTLP")
+	assertHandler.Nil(Init("StoreGoal"), "Exporter should be read whatever its case")
+	assertHandler.NotNil(provider, "Traces should be exported with a known exporter")
+	assertHandler.Nil(Shutdown(context.Background()), "Exporting no spans should succeed")
+	provider = nil
+}
+
+// TestInitOTLP tests spans being sent to collector configured in environment variables through OTLP/HTTP.
+//
+func TestInitOTLP(t *testing.T) {
+	assertHandler := assert.New(t)
+	var receivedPath, receivedContentType string
+
+	collector := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		receivedPath, receivedContentType = request.URL.Path, request.Header.Get("Content-Type")
+	}))
+	defer collector.Close()
+
+	os.Setenv("OTEL_TRACES_EXPORTER", "otlp")
+	os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	defer os.Unsetenv("OTEL_TRACES_EXPORTER")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+
+	assertHandler.Nil(Init("StoreGoal"), "OTLP exporter should be configured")
+	ctx, parentSpan := Start(context.Background(), "StoreGoal")
+	_, childSpan := Start(ctx, "update_score")
+	End(childSpan, errors.New("player does not exist"))
+	End(parentSpan, nil)
+
+	assertHandler.Nil(Flush(context.Background()), "Spans should be accepted by collector")
+	assertHandler.Equal("/v1/traces", receivedPath, "Spans should be sent to traces endpoint of collector")
+	assertHandler.Equal("application/x-protobuf", receivedContentType, "Spans should be sent as OTLP/protobuf")
+	assertHandler.Nil(Shutdown(context.Background()), "Exporter should be stopped")
+	provider = nil
+}
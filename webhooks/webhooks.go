@@ -0,0 +1,318 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Headers sent with payloads, so that receivers can check their origin and ignore deliveries received twice.
+const (
+	SignatureHeader = "X-Foosball-Signature"
+	EventHeader     = "X-Foosball-Event"
+	DeliveryHeader  = "X-Foosball-Delivery"
+)
+
+// NotifyChannel is the database channel on which events are notified when they are queued.
+const NotifyChannel = "foosball_events"
+
+// maxAttempts is the number of attempts after which a delivery fails for good.
+const maxAttempts = 6
+
+// baseRetryDelay is the delay before second attempt of a delivery, doubled after each failed attempt up to maxRetryDelay.
+const (
+	baseRetryDelay = 30 * time.Second
+	maxRetryDelay  = time.Hour
+)
+
+// deliveryTimeout is the time given to webhooks to answer.
+const deliveryTimeout = 5 * time.Second
+
+// claimDuration is the time after which deliveries claimed by a caller never recording their attempt are due again.
+const claimDuration = 5 * time.Minute
+
+// claimBatchSize is the maximum number of deliveries claimed at once, and deliveryWorkers the number of deliveries
+// sent concurrently: a batch is sent within 5 timeouts of webhooks, before scheduled function times out.
+const (
+	claimBatchSize  = 50
+	deliveryWorkers = 10
+)
+
+// Event represents payload sent to webhooks.
+//
+type Event struct {
+	Type       string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// NewClient creates HTTP client used to send payloads to webhooks.
+//
+func NewClient() (client *http.Client) {
+	return &http.Client{Timeout: deliveryTimeout}
+}
+
+// GenerateSecret generates a random secret used to sign payloads sent to a webhook.
+//
+func GenerateSecret() (secret string, generateError error) {
+	secretBytes := make([]byte, 32)
+	_, generateError = rand.Read(secretBytes)
+	if generateError != nil {
+		return "", generateError
+	}
+	return hex.EncodeToString(secretBytes), nil
+}
+
+// Sign computes signature of a payload, as sent in SignatureHeader header.
+//
+func Sign(secret string, payload []byte) (signature string) {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
+// RetryDelay computes delay before next attempt of a delivery which already failed submitted number of times.
+//
+func RetryDelay(attempts int) (delay time.Duration) {
+	delay = baseRetryDelay
+	for attempt := 1; attempt < attempts && delay < maxRetryDelay; attempt++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// RecordAttempt records result of an attempt in delivery, scheduling next attempt if delivery failed.
+//
+// Delivery succeeds when webhook answers with a 2xx status, and fails for good after maxAttempts attempts.
+//
+func RecordAttempt(delivery *models.WebhookDelivery, responseStatus int, attemptError error, at time.Time) {
+	delivery.Attempts++
+	delivery.ResponseStatus = responseStatus
+
+	if attemptError == nil && responseStatus >= 200 && responseStatus < 300 {
+		delivery.Status = models.DeliveryStatusDelivered
+		delivery.DeliveredAt = nulls.NewTime(at)
+		delivery.LastError = ""
+		return
+	}
+
+	if attemptError != nil {
+		delivery.LastError = attemptError.Error()
+	} else {
+		delivery.LastError = fmt.Sprintf("unexpected response status %d", responseStatus)
+	}
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = models.DeliveryStatusFailed
+		return
+	}
+	delivery.NextAttemptAt = at.Add(RetryDelay(delivery.Attempts))
+}
+
+// Send posts payload of delivery to its webhook, returning status of webhook response.
+//
+func Send(client *http.Client, webhook models.Webhook, delivery models.WebhookDelivery) (responseStatus int, sendError error) {
+	request, sendError := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if sendError != nil {
+		return 0, sendError
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, Sign(webhook.Secret, []byte(delivery.Payload)))
+	request.Header.Set(EventHeader, delivery.Event)
+	request.Header.Set(DeliveryHeader, delivery.ID.String())
+
+	response, sendError := client.Do(request)
+	if sendError != nil {
+		return 0, sendError
+	}
+	defer response.Body.Close()
+	return response.StatusCode, nil
+}
+
+// Enqueue creates deliveries of an event for all webhooks subscribing to it.
+//
+// Deliveries are created in submitted transaction, so that events are only sent once their changes are committed.
+// Payload is also notified on NotifyChannel database channel, which PostgreSQL only broadcasts on commit as well,
+// so that live streams (see stream package) get events without waiting for webhooks.
+//
+func Enqueue(tx *pop.Connection, eventType string, data interface{}, at time.Time) (enqueueError error) {
+	var webhooks []models.Webhook
+
+	payload, enqueueError := json.Marshal(Event{Type: eventType, OccurredAt: at, Data: data})
+	if enqueueError != nil {
+		return enqueueError
+	}
+	enqueueError = tx.RawQuery("SELECT pg_notify(?, ?)", NotifyChannel, string(payload)).Exec()
+	if enqueueError != nil {
+		return enqueueError
+	}
+
+	enqueueError = tx.All(&webhooks)
+	if enqueueError != nil {
+		return enqueueError
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(eventType) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         eventType,
+			Payload:       string(payload),
+			Status:        models.DeliveryStatusPending,
+			NextAttemptAt: at,
+		}
+		enqueueError = validateAndSave(tx, &delivery)
+		if enqueueError != nil {
+			return enqueueError
+		}
+	}
+	return nil
+}
+
+// DeliverPending attempts pending deliveries whose next attempt is due (at most claimBatchSize, oldest first).
+//
+// Deliveries are claimed before being sent (see claim), so that concurrent calls never send a delivery twice.
+// Deliveries left due are attempted by next calls.
+//
+func DeliverPending(tx *pop.Connection, client *http.Client, at time.Time) (deliverError error) {
+	deliveries, deliverError := claim(tx, at)
+	if deliverError != nil || len(deliveries) == 0 {
+		return deliverError
+	}
+	return deliver(tx, client, deliveries, at)
+}
+
+// FetchDeliveries retrieves deliveries of a webhook (optionally only deliveries with submitted status), from newest to oldest.
+//
+func FetchDeliveries(tx *pop.Connection, webhookID interface{}, status string) (deliveries []models.WebhookDelivery, fetchError error) {
+	deliveriesQuery := tx.Where("webhook_id = ?", webhookID)
+	if status != "" {
+		deliveriesQuery = deliveriesQuery.Where("status = ?", status)
+	}
+
+	deliveries = []models.WebhookDelivery{}
+	fetchError = deliveriesQuery.Order("created_at DESC").All(&deliveries)
+	return deliveries, fetchError
+}
+
+// claim retrieves oldest pending deliveries whose next attempt is due (at most claimBatchSize), postponing their next attempt by claimDuration
+// in the same statement.
+//
+// Rows locked by a concurrent claim are skipped, and claimed deliveries are not due anymore until their attempt is
+// recorded (or until claim expires, if attempt is never recorded), so that each delivery is only sent by one caller.
+//
+func claim(tx *pop.Connection, at time.Time) (deliveries []models.WebhookDelivery, claimError error) {
+	deliveries = []models.WebhookDelivery{}
+	claimError = tx.RawQuery(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY created_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		at.Add(claimDuration), models.DeliveryStatusPending, at, claimBatchSize,
+	).All(&deliveries)
+	return deliveries, claimError
+}
+
+// deliver attempts claimed deliveries (see attempt), recording result of each attempt as soon as it is known,
+// so that deliveries already attempted are not sent again when caller is interrupted.
+//
+// Recording goes on after a failure, first failure being returned.
+//
+func deliver(tx *pop.Connection, client *http.Client, deliveries []models.WebhookDelivery, at time.Time) (deliverError error) {
+	var webhooks []models.Webhook
+	var webhookIDs []interface{}
+
+	for _, delivery := range deliveries {
+		webhookIDs = append(webhookIDs, delivery.WebhookID)
+	}
+	deliverError = tx.Where("id IN (?)", webhookIDs...).All(&webhooks)
+	if deliverError != nil {
+		return deliverError
+	}
+	webhooksByID := make(map[string]models.Webhook)
+	for _, webhook := range webhooks {
+		webhooksByID[webhook.ID.String()] = webhook
+	}
+
+	for attemptedDelivery := range attempt(client, webhooksByID, deliveries, at) {
+		saveError := validateAndSave(tx, attemptedDelivery)
+		if saveError != nil && deliverError == nil {
+			deliverError = saveError
+		}
+	}
+	return deliverError
+}
+
+// attempt sends deliveries to their webhooks with deliveryWorkers concurrent workers, returning a channel of
+// deliveries whose attempt is recorded (see RecordAttempt), closed once all deliveries are attempted.
+//
+// Deliveries of a webhook deleted in the meantime fail for good.
+//
+func attempt(client *http.Client, webhooksByID map[string]models.Webhook, deliveries []models.WebhookDelivery, at time.Time) (attemptedDeliveries <-chan *models.WebhookDelivery) {
+	var waitGroup sync.WaitGroup
+	var pendingDeliveries = make(chan *models.WebhookDelivery)
+	var attempted = make(chan *models.WebhookDelivery)
+
+	for worker := 0; worker < deliveryWorkers; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for delivery := range pendingDeliveries {
+				webhook, webhookExists := webhooksByID[delivery.WebhookID.String()]
+				if webhookExists {
+					responseStatus, sendError := Send(client, webhook, *delivery)
+					RecordAttempt(delivery, responseStatus, sendError, at)
+				} else {
+					delivery.Status = models.DeliveryStatusFailed
+					delivery.LastError = "webhook has been deleted"
+				}
+				attempted <- delivery
+			}
+		}()
+	}
+
+	go func() {
+		for index := range deliveries {
+			pendingDeliveries <- &deliveries[index]
+		}
+		close(pendingDeliveries)
+		waitGroup.Wait()
+		close(attempted)
+	}()
+	return attempted
+}
+
+// validateAndSave validates and saves submitted model, validation errors being returned as an error.
+//
+func validateAndSave(tx *pop.Connection, model interface{}) (saveError error) {
+	var validateError *validate.Errors
+
+	validateError, saveError = tx.ValidateAndSave(model)
+	if saveError != nil {
+		return saveError
+	}
+	if validateError != nil && len(validateError.Errors) != 0 {
+		return validateError
+	}
+	return nil
+}
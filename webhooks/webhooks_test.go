@@ -0,0 +1,120 @@
+package webhooks
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vlarrat-theodo/lbc-foosball/models"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRetryDelay tests RetryDelay function doubling delay after each failed attempt.
+//
+func TestRetryDelay(t *testing.T) {
+	assertHandler := assert.New(t)
+
+	assertHandler.Equal(30*time.Second, RetryDelay(1), "First failure: next attempt should be in 30 seconds")
+	assertHandler.Equal(time.Minute, RetryDelay(2), "Second failure: delay should be doubled")
+	assertHandler.Equal(8*time.Minute, RetryDelay(5), "Fifth failure: delay should be doubled after each failure")
+	assertHandler.Equal(time.Hour, RetryDelay(20), "Many failures: delay should be capped")
+}
+
+// TestRecordAttempt tests RecordAttempt function for successful, failed and last attempts.
+//
+func TestRecordAttempt(t *testing.T) {
+	assertHandler := assert.New(t)
+	at := time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)
+
+	delivery := models.WebhookDelivery{Status: models.DeliveryStatusPending}
+	RecordAttempt(&delivery, http.StatusServiceUnavailable, nil, at)
+	assertHandler.Equal(models.DeliveryStatusPending, delivery.Status, "Failed attempt: delivery should still be pending")
+	assertHandler.Equal(1, delivery.Attempts, "Failed attempt: attempt should be counted")
+	assertHandler.Equal(at.Add(30*time.Second), delivery.NextAttemptAt, "Failed attempt: next attempt not scheduled as expected")
+	assertHandler.Equal("unexpected response status 503", delivery.LastError, "Failed attempt: error not recorded as expected")
+
+	RecordAttempt(&delivery, http.StatusNoContent, nil, at.Add(time.Minute))
+	assertHandler.Equal(models.DeliveryStatusDelivered, delivery.Status, "Successful attempt: delivery should be delivered")
+	assertHandler.Equal(at.Add(time.Minute), delivery.DeliveredAt.Time, "Successful attempt: delivery time not recorded as expected")
+	assertHandler.Empty(delivery.LastError, "Successful attempt: previous error should be cleared")
+
+	delivery = models.WebhookDelivery{Status: models.DeliveryStatusPending, Attempts: maxAttempts - 1}
+	RecordAttempt(&delivery, 0, errors.New("connection refused"), at)
+	assertHandler.Equal(models.DeliveryStatusFailed, delivery.Status, "Last attempt: delivery should fail for good")
+	assertHandler.Equal("connection refused", delivery.LastError, "Last attempt: error not recorded as expected")
+}
+
+// TestSend tests Send function posting signed payloads to webhooks.
+//
+func TestSend(t *testing.T) {
+	var receivedRequest *http.Request
+	var receivedPayload []byte
+
+	assertHandler := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		receivedRequest = request
+		receivedPayload, _ = ioutil.ReadAll(request.Body)
+		writer.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	webhook := models.Webhook{URL: server.URL, Secret: "secret", Events: models.WebhookEventGoalRecorded}
+	delivery := models.WebhookDelivery{ID: uuid.Must(uuid.NewV4()), Event: models.WebhookEventGoalRecorded, Payload: `{"event":"goal.recorded"}`}
+
+	responseStatus, sendError := Send(NewClient(), webhook, delivery)
+	assertHandler.Nil(sendError, "Send function should not raise an error")
+	assertHandler.Equal(http.StatusAccepted, responseStatus, "Status of webhook response should be returned")
+	assertHandler.Equal(delivery.Payload, string(receivedPayload), "Payload should be sent as it was stored")
+	assertHandler.Equal("sha256=13b8ef2b4fc6fedcb559fcafdcf743091ea100d75cc04d528a20e53d213d1244", receivedRequest.Header.Get(SignatureHeader), "Payload signature not sent as expected")
+	assertHandler.Equal(Sign("secret", receivedPayload), receivedRequest.Header.Get(SignatureHeader), "Payload signature should match received payload")
+	assertHandler.Equal(models.WebhookEventGoalRecorded, receivedRequest.Header.Get(EventHeader), "Event not sent as expected")
+	assertHandler.Equal(delivery.ID.String(), receivedRequest.Header.Get(DeliveryHeader), "Delivery not sent as expected")
+
+	server.Close()
+	_, sendError = Send(NewClient(), webhook, delivery)
+	assertHandler.NotNil(sendError, "Unreachable webhook: Send function should raise an error")
+}
+
+// TestAttempt tests deliveries being sent by a bounded number of workers, each attempt being recorded.
+//
+func TestAttempt(t *testing.T) {
+	assertHandler := assert.New(t)
+	var mutex sync.Mutex
+	var inFlight, maxInFlight int
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+	}))
+	defer receiver.Close()
+
+	webhook := models.Webhook{ID: uuid.Must(uuid.NewV4()), URL: receiver.URL, Secret: "secret"}
+	deliveries := []models.WebhookDelivery{{ID: uuid.Must(uuid.NewV4()), WebhookID: uuid.Must(uuid.NewV4()), Status: models.DeliveryStatusPending}}
+	for index := 0; index < 3*deliveryWorkers; index++ {
+		deliveries = append(deliveries, models.WebhookDelivery{ID: uuid.Must(uuid.NewV4()), WebhookID: webhook.ID, Status: models.DeliveryStatusPending})
+	}
+
+	attemptsCount := 0
+	for attemptedDelivery := range attempt(NewClient(), map[string]models.Webhook{webhook.ID.String(): webhook}, deliveries, time.Now()) {
+		attemptsCount++
+		if attemptedDelivery.WebhookID == webhook.ID {
+			assertHandler.Equal(models.DeliveryStatusDelivered, attemptedDelivery.Status, "Delivery should be recorded as delivered")
+		} else {
+			assertHandler.Equal(models.DeliveryStatusFailed, attemptedDelivery.Status, "Delivery of deleted webhook should fail for good")
+		}
+	}
+	assertHandler.Equal(len(deliveries), attemptsCount, "All deliveries should be attempted once")
+	assertHandler.True(maxInFlight <= deliveryWorkers, "At most %d deliveries should be sent concurrently", deliveryWorkers)
+}